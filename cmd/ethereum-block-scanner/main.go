@@ -2,19 +2,26 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"math"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
-	"github.com/pkg/errors"
 	"github.com/powerslider/ethereum-block-scanner/pkg/configs"
 	"github.com/powerslider/ethereum-block-scanner/pkg/handlers"
+	"github.com/powerslider/ethereum-block-scanner/pkg/logging"
+	"github.com/powerslider/ethereum-block-scanner/pkg/metrics"
 	"github.com/powerslider/ethereum-block-scanner/pkg/sdk"
-	"github.com/powerslider/ethereum-block-scanner/pkg/storage/memory"
+	"github.com/powerslider/ethereum-block-scanner/pkg/storage"
+	"github.com/powerslider/ethereum-block-scanner/pkg/supervisor"
+	"github.com/powerslider/ethereum-block-scanner/pkg/tracing"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/httpx"
 	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/rpcpool"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/wsrpc"
 	"github.com/powerslider/ethereum-block-scanner/pkg/transport/server"
 )
 
@@ -32,34 +39,114 @@ import (
 // @host 0.0.0.0:8080
 // @BasePath /
 func main() {
-	ctx := context.Background()
-
 	var err error
 
 	setEnvironment()
 
 	conf := configs.InitializeConfig()
-	client := jsonrpc.NewDefaultClient(conf.EthereumHost)
 
-	txStore := memory.NewTransactionsRepository()
-	subsStore := memory.NewSubscriptionsRepository()
+	logger, err := logging.New(os.Stderr, conf.LogLevel, conf.LogFormat)
+	if err != nil {
+		slog.Error("error initializing logger", "error", err)
+		os.Exit(1)
+	}
+
+	slog.SetDefault(logger)
+
+	tracerProvider, err := tracing.NewProvider(
+		context.Background(),
+		conf.TraceExporter,
+		tracing.WithFilePath(conf.TraceFile),
+		tracing.WithOTLPEndpoint(conf.TraceOTLPEndpoint),
+		tracing.WithSampleRatio(conf.TraceSampleRatio),
+	)
+	if err != nil {
+		slog.Error("error initializing tracing", "error", err)
+		os.Exit(1)
+	}
+
+	store, err := storage.InitializeStorage(conf)
+	if err != nil {
+		slog.Error("error initializing storage", "error", err)
+		os.Exit(1)
+	}
+
+	scannerMetrics := metrics.New(store)
+	workers := supervisor.New(supervisor.WithRestartBackoff(conf.WorkerRestartInitialDelay, conf.WorkerRestartMaxDelay))
 
-	blockParser := sdk.NewBlockParser(client, txStore, subsStore)
-	blockListener := sdk.NewBlockObserver(blockParser, subsStore)
+	client, budget := newRPCClient(conf, scannerMetrics, store.UsageStore, workers)
+
+	blockParser := sdk.NewBlockParser(
+		client,
+		store.TxStore,
+		store.SubsStore,
+		sdk.WithHistoryScanWorkers(conf.HistoryScanWorkers),
+		sdk.WithMaxBlockRange(conf.MaxBlockRange),
+		sdk.WithTracer(sdk.Tracer(conf.Tracer)),
+	)
 
 	errServerCh := make(chan error)
-	errListenerCh := make(chan error)
+	errWorkersCh := make(chan error)
+
+	webhookDispatcher := sdk.NewWebhookDispatcher(
+		store.SubsStore,
+		store.WebhookStore,
+		sdk.WithDeliveryMaxAttempts(conf.WebhookMaxAttempts),
+		sdk.WithDeliveryBackoff(conf.WebhookInitialBackoff, conf.WebhookMaxBackoff),
+		sdk.WithDeliveryTimeout(conf.WebhookTimeout),
+	)
+
+	// Deliver observed transactions to the webhooks of subscribed addresses.
+	workers.Add("webhook-dispatcher", webhookDispatcher.Run)
+
+	healthChecker := sdk.NewHealthChecker(
+		blockParser,
+		sdk.WithHealthCheckInterval(conf.HealthCheckInterval),
+		sdk.WithExpectedChainID(conf.ChainID),
+		sdk.WithHeadStallTimeout(conf.HeadStallTimeout),
+		sdk.WithMaxObserverFailures(conf.ObserverMaxFailures),
+	)
+
+	// Keep checking the node, so that the readiness probe reflects whether it is synced and advancing.
+	workers.Add("health-checker", healthChecker.Run)
+
+	observerOpts := []sdk.ObserverOption{
+		sdk.WithConfirmationDepth(conf.ConfirmationDepth),
+		sdk.WithFinalityTags(conf.UseFinalityTags),
+		sdk.WithObserverMetrics(scannerMetrics),
+		sdk.WithIterationReporter(healthChecker),
+	}
+
+	if conf.EthereumWSHost != "" {
+		wsClient := wsrpc.NewClient(conf.EthereumWSHost)
 
-	// Start block observer to track new transactions that have occurred in the latest block
-	// involving subscribed addresses.
-	go blockListener.ListenForNewTransactions(ctx, errListenerCh)
+		// Keep the WebSocket connection open, so that new heads are pushed to the block observer. While it is down,
+		// the observer falls back to polling.
+		workers.Add("websocket-client", wsClient.Run)
+
+		observerOpts = append(observerOpts, sdk.WithHeadsSubscriber(wsClient))
+	}
+
+	// Observed transactions are stored and fanned out to the webhooks and the event streams of subscribed addresses.
+	transactionBus := sdk.NewTransactionBus(store.SubsStore, webhookDispatcher)
+	blockListener := sdk.NewBlockObserver(
+		blockParser, store.SubsStore, store.TxStore, transactionBus, observerOpts...)
+
+	// Track new transactions that have occurred in the latest block involving subscribed addresses.
+	workers.Add("block-observer", blockListener.ListenForNewTransactions)
+
+	if err = workers.Start(context.Background(), errWorkersCh); err != nil {
+		slog.Error("error starting background workers", "error", err)
+		os.Exit(1)
+	}
 
 	router := mux.NewRouter()
-	router = handlers.InitializeHandlers(conf, router, blockParser)
-	s := server.NewServer(conf, router)
+	router = handlers.InitializeHandlers(
+		conf, router, blockParser, budget, webhookDispatcher, transactionBus, healthChecker)
+	s := server.NewServer(conf, router, server.WithInstrumentation(scannerMetrics))
 
 	// Start HTTP server.
-	go s.Start(ctx, errServerCh)
+	go s.Start(context.Background(), errServerCh)
 
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
@@ -67,17 +154,142 @@ func main() {
 	for {
 		select {
 		case <-sigs:
-			if err = s.Stop(ctx); err != nil {
-				log.Fatal("error stopping HTTP server:", errors.WithStack(err))
+			if !shutdown(conf, workers, store, s, tracerProvider) {
+				os.Exit(1)
 			}
 
-			os.Exit(0)
+			return
 		case err = <-errServerCh:
-			log.Fatal("HTTP server error: ", errors.WithStack(err))
-		case err = <-errListenerCh:
-			log.Println(err)
+			slog.Error("HTTP server error", "error", err)
+
+			shutdown(conf, workers, store, s, tracerProvider)
+			os.Exit(1)
+		case err = <-errWorkersCh:
+			slog.Warn("background worker failed", "error", err)
+		case reorg := <-blockListener.ReorgEvents():
+			slog.Warn("chain reorganization detected",
+				"depth", reorg.Depth,
+				"common_ancestor", reorg.CommonAncestor,
+				"old_hashes", reorg.OldHashes,
+				"new_hashes", reorg.NewHashes,
+			)
+
+			transactionBus.PublishReorg(reorg)
+		}
+	}
+}
+
+// shutdown stops the background workers, letting the observer complete the block it is processing, stops
+// the HTTP server, exports the remaining spans and closes the stores. It reports if all steps succeeded in time.
+func shutdown(
+	conf *configs.Config,
+	workers *supervisor.Supervisor,
+	store *storage.Storage,
+	s *server.Server,
+	tracerProvider *tracing.Provider,
+) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+	defer cancel()
+
+	ok := true
+
+	slog.Info("background workers are stopping")
+
+	if err := workers.Stop(ctx); err != nil {
+		slog.Error("error stopping background workers", "error", err)
+
+		ok = false
+	}
+
+	if err := s.Stop(ctx); err != nil {
+		slog.Error("error stopping HTTP server", "error", err)
+
+		ok = false
+	}
+
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		slog.Error("error exporting remaining spans", "error", err)
+
+		ok = false
+	}
+
+	if err := store.Close(); err != nil {
+		slog.Error("error closing storage", "error", err)
+
+		ok = false
+	}
+
+	return ok
+}
+
+// newRPCClient creates the client for the Ethereum node or the pool of clients if multiple nodes are configured.
+// All clients share the same retry policy, rate limits, compute unit budget and call metrics. The compute units
+// spent are persisted in a usage store.
+func newRPCClient(
+	conf *configs.Config,
+	scannerMetrics *metrics.Metrics,
+	usageStore sdk.RPCUsageStore,
+	workers *supervisor.Supervisor,
+) (sdk.RPCClient, *jsonrpc.Budget) {
+	retryPolicy := httpx.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = conf.RPCMaxAttempts
+	httpClient := httpx.NewClient(httpx.WithRetryPolicy(retryPolicy))
+
+	methodRateLimits := make(map[string]jsonrpc.RateLimit, len(conf.RPCMethodRateLimits))
+
+	for method, requestsPerSecond := range conf.RPCMethodRateLimits {
+		methodRateLimits[method] = jsonrpc.RateLimit{
+			RequestsPerSecond: requestsPerSecond,
+			Burst:             int(math.Ceil(requestsPerSecond)),
 		}
 	}
+
+	rateLimiter := jsonrpc.NewRateLimiter(jsonrpc.RateLimit{
+		RequestsPerSecond: conf.RPCRateLimit,
+		Burst:             int(math.Ceil(conf.RPCRateLimit)),
+	}, methodRateLimits)
+
+	computeUnits := jsonrpc.DefaultComputeUnits()
+
+	for method, cost := range conf.RPCComputeUnits {
+		computeUnits[method] = int(cost)
+	}
+
+	budget := jsonrpc.NewBudget(
+		conf.RPCDailyBudget, conf.RPCMonthlyBudget, computeUnits, jsonrpc.WithBudgetStore(usageStore))
+
+	// Store the compute units spent, so that the budget still holds after a restart.
+	workers.Add("rpc-budget", budget.Run)
+
+	clientOpts := []jsonrpc.ClientOption{
+		jsonrpc.WithRateLimiter(rateLimiter),
+		jsonrpc.WithBudget(budget),
+		jsonrpc.WithCallObserver(scannerMetrics),
+	}
+
+	if len(conf.EthereumHosts) == 0 {
+		return jsonrpc.NewClient(httpClient, conf.EthereumHost, clientOpts...), budget
+	}
+
+	poolClients := make(map[string]*jsonrpc.RPCClient, len(conf.EthereumHosts))
+
+	for _, host := range conf.EthereumHosts {
+		poolClients[host] = jsonrpc.NewClient(httpClient, host, clientOpts...)
+	}
+
+	pool := rpcpool.NewPool(
+		conf.EthereumHosts,
+		poolClients,
+		rpcpool.WithMaxHeadLag(conf.RPCPoolMaxHeadLag),
+		rpcpool.WithHealthCheckInterval(conf.RPCPoolHealthCheckInterval),
+	)
+
+	scannerMetrics.WatchEndpoints(pool)
+
+	// Keep track of the head and latency of all endpoints, so that lagging endpoints are ejected.
+	workers.Add("rpc-pool", pool.Run)
+
+	return pool, budget
 }
 
 func setEnvironment() {
@@ -53,7 +53,7 @@ const docTemplate = `{
         },
         "/api/v1/address/{address}/transactions": {
             "get": {
-                "description": "Get all transactions for a fixed block range given an address.",
+                "description": "Get all transactions for a fixed block range given an address.\nThe lower bound is given either by fromBlock, fromTime or as blockRange blocks back\nfrom the upper bound.\nThe upper bound is given either by toBlock, toTime or defaults to the latest block.\nTimes are accepted as RFC3339 strings or unix timestamps in seconds.\nTransactions are ordered by block number and transaction index and listed one page at a time.\nThe next page is requested with the nextCursor of the previous page.\nThe response is an object holding the transactions, the nextCursor and the limit of the page.\nThe limit defaults to 50.\nBlocks not scanned for the address yet are fetched by a low priority backfill. Once the RPC compute unit\nbudget is exhausted, the backfill is rejected with 503 Service Unavailable.",
                 "consumes": [
                     "application/json"
                 ],
@@ -78,6 +78,71 @@ const docTemplate = `{
                         "description": "Block Range",
                         "name": "blockRange",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "From Block",
+                        "name": "fromBlock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "To Block",
+                        "name": "toBlock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From Time",
+                        "name": "fromTime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To Time",
+                        "name": "toTime",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "inbound",
+                            "outbound"
+                        ],
+                        "type": "string",
+                        "description": "Direction",
+                        "name": "direction",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Minimum value in wei, decimal or 0x prefixed hex",
+                        "name": "minValue",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum value in wei, decimal or 0x prefixed hex",
+                        "name": "maxValue",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Counterparty Address",
+                        "name": "counterparty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {}
@@ -99,9 +164,183 @@ const docTemplate = `{
                 "responses": {}
             }
         },
+        "/api/v1/rpc/usage": {
+            "get": {
+                "description": "Get the compute units spent on RPC calls in the current day and month against the configured budget.\nUsage per method covers the current month.\nThe usage is stored, so with the bolt storage backend the limits still hold after a restart.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rpc"
+                ],
+                "summary": "Get the compute units spent on RPC calls.",
+                "responses": {}
+            }
+        },
+        "/api/v1/subscription/{address}": {
+            "get": {
+                "description": "Get the creation time, the last checked block and the number of observed transactions\nof a subscribed address.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get the details of a subscribed address.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "delete": {
+                "description": "Stop observing an address for new transactions and remove its webhooks. Its observed transactions,\ntoken transfers and webhook deliveries are kept unless purge is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Unsubscribe an address from the observer.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Purge observed transactions",
+                        "name": "purge",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/subscription/{address}/deliveries": {
+            "get": {
+                "description": "Get the deliveries of observed transactions to the webhooks of a subscribed address\nin the order they were enqueued, optionally filtered by status.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get the webhook delivery log of a subscribed address.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "delivered",
+                            "dead-letter"
+                        ],
+                        "type": "string",
+                        "description": "Delivery Status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/subscription/{address}/stream": {
+            "get": {
+                "description": "Push every transaction observed for a subscribed address as a Server-Sent Event of type\n\"transaction\" with the sequence of the transaction as event ID. A client reconnecting with\nthe Last-Event-ID header first receives all transactions observed since that event.\nA transaction removed since its block was orphaned by a chain reorganization is pushed as an event\nof type \"retraction\" without an event ID. Retractions are only pushed to connected clients.\nThe chain reorganization itself is pushed afterwards as an event of type \"reorg\" without an event ID,\nholding the depth, the common ancestor and the orphaned and new block hashes.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "Stream the transactions observed for a subscribed address.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Sequence of the last received event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/subscription/{address}/token-transfers": {
+            "get": {
+                "description": "Get all ERC-20 token transfers sent from or to a subscribed address.\nAmounts are raw hex encoded token units not adjusted by the token decimals.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blocks"
+                ],
+                "summary": "Get all ERC-20 token transfers for a subscribed address.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
         "/api/v1/subscription/{address}/transactions": {
             "get": {
-                "description": "Get all transactions for a subscribed address.",
+                "description": "Get all transactions for a subscribed address ordered by block number and transaction index\none page at a time. The next page is requested with the nextCursor of the previous page.\nInternal transfers are filtered by their own sender, recipient and value.\nThe response is an object holding the transactions, the nextCursor and the limit of the page.\nThe limit defaults to 50.",
                 "consumes": [
                     "application/json"
                 ],
@@ -119,13 +358,254 @@ const docTemplate = `{
                         "name": "address",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "From Block",
+                        "name": "fromBlock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "To Block",
+                        "name": "toBlock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From Time",
+                        "name": "fromTime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To Time",
+                        "name": "toTime",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "inbound",
+                            "outbound"
+                        ],
+                        "type": "string",
+                        "description": "Direction",
+                        "name": "direction",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Minimum value in wei, decimal or 0x prefixed hex",
+                        "name": "minValue",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum value in wei, decimal or 0x prefixed hex",
+                        "name": "maxValue",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Counterparty Address",
+                        "name": "counterparty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/subscription/{address}/webhooks": {
+            "get": {
+                "description": "List all webhooks registered for a subscribed address.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List the webhooks of a subscribed address.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "post": {
+                "description": "Register a URL which every transaction observed for the address is POSTed to as JSON.\nPayloads are signed with the returned secret, which is shared by all webhooks of the address.\nThe X-Webhook-Signature header holds \"sha256=\" followed by the hex encoded HMAC-SHA256 of the body.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Register a webhook for a subscribed address.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RegisterWebhook.request"
+                        }
                     }
                 ],
                 "responses": {}
             }
+        },
+        "/api/v1/subscription/{address}/webhooks/{id}": {
+            "delete": {
+                "description": "Remove a webhook of a subscribed address. Its pending deliveries are dead-lettered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Remove a webhook of a subscribed address.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/subscriptions": {
+            "get": {
+                "description": "List the subscribed addresses ordered by address one page at a time.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "List all subscribed addresses.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/subscriptions/stream": {
+            "get": {
+                "description": "Push every transaction observed for any subscribed address as a Server-Sent Event of type\n\"transaction\" with the sequence of the transaction as event ID. A client reconnecting with\nthe Last-Event-ID header first receives all transactions observed since that event.\nA transaction removed since its block was orphaned by a chain reorganization is pushed as an event\nof type \"retraction\" without an event ID. Retractions are only pushed to connected clients.\nThe chain reorganization itself is pushed afterwards as an event of type \"reorg\" without an event ID,\nholding the depth, the common ancestor and the orphaned and new block hashes.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "Stream the transactions observed for all subscribed addresses.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Sequence of the last received event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Succeeds as long as the HTTP server is serving requests, regardless of the state of the node.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Check if the scanner is alive.",
+                "responses": {}
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Report the state and the last error of every readiness check. Responds with 503 unless all checks\npass, i.e. the node is synced, connected to the configured chain and its head is advancing\nand the block observer has not failed repeatedly.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Check if the scanner is ready.",
+                "responses": {}
+            }
         }
     },
     "definitions": {
+        "handlers.RegisterWebhook.request": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
         "handlers.SubscribeAddress.request": {
             "type": "object",
             "properties": {
@@ -0,0 +1,99 @@
+package blocks
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// AddressLength is the number of bytes of an Ethereum account address.
+const AddressLength = 20
+
+// Address represents a 20 byte Ethereum account address. It is encoded as a 0x prefixed hex string
+// with the mixed case checksum of EIP-55.
+type Address [AddressLength]byte
+
+// ParseAddress parses a 0x prefixed hex address. Addresses in all lower or all upper case are accepted as is,
+// while mixed case addresses must carry a valid EIP-55 checksum.
+func ParseAddress(s string) (Address, error) {
+	var a Address
+
+	if err := a.UnmarshalText([]byte(s)); err != nil {
+		return Address{}, err
+	}
+
+	return a, nil
+}
+
+// Hex returns the EIP-55 checksum encoding of the address.
+func (a Address) Hex() string {
+	lower := []byte(hex.EncodeToString(a[:]))
+
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(lower)
+	digest := hasher.Sum(nil)
+
+	for i, c := range lower {
+		// A letter is upper cased if the matching nibble of the keccak256 hash of the lower case address is >= 8.
+		nibble := digest[i/2] >> 4
+		if i%2 == 1 {
+			nibble = digest[i/2] & 0x0f
+		}
+
+		if c > '9' && nibble >= 8 {
+			lower[i] = c - 'a' + 'A'
+		}
+	}
+
+	return "0x" + string(lower)
+}
+
+// Lower returns the lower case hex encoding of the address, which is used to key subscriptions and transactions.
+func (a Address) Lower() string {
+	return "0x" + hex.EncodeToString(a[:])
+}
+
+// String implements fmt.Stringer.
+func (a Address) String() string {
+	return a.Hex()
+}
+
+// IsZero reports if the address is the zero address.
+func (a Address) IsZero() bool {
+	return a == Address{}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.Hex()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *Address) UnmarshalText(input []byte) error {
+	var decoded Address
+
+	if err := decodeFixedHexBytes(input, decoded[:], "address"); err != nil {
+		return err
+	}
+
+	digits := string(input[2:])
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && decoded.Hex()[2:] != digits {
+		return fmt.Errorf("could not decode address %q: invalid EIP-55 checksum", input)
+	}
+
+	*a = decoded
+
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *Address) UnmarshalJSON(input []byte) error {
+	text, err := unquote(input, "address")
+	if err != nil {
+		return err
+	}
+
+	return a.UnmarshalText(text)
+}
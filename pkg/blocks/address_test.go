@@ -0,0 +1,63 @@
+package blocks
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	// The address is taken from the test vectors of EIP-55.
+	const checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+	tests := []struct {
+		name    string
+		s       string
+		wantErr bool
+	}{
+		{name: "valid checksum", s: checksummed},
+		{name: "lower case", s: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+		{name: "upper case", s: "0X5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"},
+		{name: "invalid checksum", s: "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", wantErr: true},
+		{name: "missing prefix", s: "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", wantErr: true},
+		{name: "too short", s: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea", wantErr: true},
+		{name: "invalid character", s: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beagg", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, err := ParseAddress(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && address.Hex() != checksummed {
+				t.Errorf("Hex() = %s, want %s", address.Hex(), checksummed)
+			}
+		})
+	}
+}
+
+func TestAddressJSON(t *testing.T) {
+	var address Address
+
+	if err := json.Unmarshal([]byte(`"0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359"`), &address); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	encoded, err := json.Marshal(address)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	if want := `"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"`; string(encoded) != want {
+		t.Errorf("Marshal() = %s, want %s", encoded, want)
+	}
+
+	if address.Lower() != "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359" {
+		t.Errorf("Lower() = %s, want the lower case address", address.Lower())
+	}
+
+	if err = json.Unmarshal([]byte(`null`), &address); err == nil {
+		t.Error("Unmarshal() of null error = nil, want an error")
+	}
+}
@@ -2,51 +2,70 @@ package blocks
 
 // Block represents an Ethereum block.
 type Block struct {
-	BaseFeePerGas    string        `json:"baseFeePerGas"`
-	Difficulty       string        `json:"difficulty"`
-	ExtraData        string        `json:"extraData"`
-	GasLimit         string        `json:"gasLimit"`
-	GasUsed          string        `json:"gasUsed"`
-	Hash             string        `json:"hash"`
-	LogsBloom        string        `json:"logsBloom"`
-	Miner            string        `json:"miner"`
-	MixHash          string        `json:"mixHash"`
-	Nonce            string        `json:"nonce"`
-	Number           string        `json:"number"`
-	ParentHash       string        `json:"parentHash"`
-	ReceiptsRoot     string        `json:"receiptsRoot"`
-	Sha3Uncles       string        `json:"sha3Uncles"`
-	Size             string        `json:"size"`
-	StateRoot        string        `json:"stateRoot"`
-	Timestamp        string        `json:"timestamp"`
-	TotalDifficulty  string        `json:"totalDifficulty"`
+	// BaseFeePerGas is only set for blocks since the London fork.
+	BaseFeePerGas    *Quantity     `json:"baseFeePerGas,omitempty"`
+	Difficulty       Quantity      `json:"difficulty"`
+	ExtraData        Bytes         `json:"extraData"`
+	GasLimit         Quantity      `json:"gasLimit"`
+	GasUsed          Quantity      `json:"gasUsed"`
+	Hash             Hash          `json:"hash"`
+	LogsBloom        Bytes         `json:"logsBloom"`
+	Miner            Address       `json:"miner"`
+	MixHash          Hash          `json:"mixHash"`
+	Nonce            Bytes         `json:"nonce"`
+	Number           Quantity      `json:"number"`
+	ParentHash       Hash          `json:"parentHash"`
+	ReceiptsRoot     Hash          `json:"receiptsRoot"`
+	Sha3Uncles       Hash          `json:"sha3Uncles"`
+	Size             Quantity      `json:"size"`
+	StateRoot        Hash          `json:"stateRoot"`
+	Timestamp        Quantity      `json:"timestamp"`
+	TotalDifficulty  *Quantity     `json:"totalDifficulty,omitempty"`
 	Transactions     []Transaction `json:"transactions"`
-	TransactionsRoot string        `json:"transactionsRoot"`
-	Uncles           []any         `json:"uncles"`
+	TransactionsRoot Hash          `json:"transactionsRoot"`
+	Uncles           []Hash        `json:"uncles"`
+}
+
+// AccessTuple represents an entry of the access list of an EIP-2930 transaction.
+type AccessTuple struct {
+	Address     Address `json:"address"`
+	StorageKeys []Hash  `json:"storageKeys"`
 }
 
 // Transaction represents an Ethereum block transaction.
 type Transaction struct {
-	AccessList []struct {
-		Address     string   `json:"address"`
-		StorageKeys []string `json:"storageKeys"`
-	} `json:"accessList"`
-	BlockHash            string `json:"blockHash"`
-	BlockNumber          string `json:"blockNumber"`
-	ChainID              string `json:"chainId"`
-	From                 string `json:"from"`
-	Gas                  string `json:"gas"`
-	GasPrice             string `json:"gasPrice"`
-	Hash                 string `json:"hash"`
-	Input                string `json:"input"`
-	MaxFeePerGas         string `json:"maxFeePerGas"`
-	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
-	Nonce                string `json:"nonce"`
-	R                    string `json:"r"`
-	S                    string `json:"s"`
-	To                   string `json:"to"`
-	TransactionIndex     string `json:"transactionIndex"`
-	Type                 string `json:"type"`
-	V                    string `json:"v"`
-	Value                string `json:"value"`
+	AccessList  []AccessTuple `json:"accessList,omitempty"`
+	BlockHash   Hash          `json:"blockHash"`
+	BlockNumber Quantity      `json:"blockNumber"`
+	ChainID     *Quantity     `json:"chainId,omitempty"`
+	From        Address       `json:"from"`
+	Gas         Quantity      `json:"gas"`
+	GasPrice    Quantity      `json:"gasPrice"`
+	Hash        Hash          `json:"hash"`
+	Input       Bytes         `json:"input"`
+	// MaxFeePerGas and MaxPriorityFeePerGas are only set for EIP-1559 transactions.
+	MaxFeePerGas         *Quantity `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *Quantity `json:"maxPriorityFeePerGas,omitempty"`
+	Nonce                Quantity  `json:"nonce"`
+	R                    Quantity  `json:"r"`
+	S                    Quantity  `json:"s"`
+	// To is nil for contract creation transactions.
+	To               *Address `json:"to"`
+	TransactionIndex Quantity `json:"transactionIndex"`
+	Type             Quantity `json:"type"`
+	V                Quantity `json:"v"`
+	Value            Quantity `json:"value"`
+
+	// Receipt is attached to observed and historical transactions after execution, it is not part of a block.
+	Receipt *Receipt `json:"receipt,omitempty"`
+}
+
+// Parties returns the sender and the recipient of the transaction.
+// Contract creation transactions have no recipient, so only the sender is returned.
+func (t Transaction) Parties() []Address {
+	if t.To == nil {
+		return []Address{t.From}
+	}
+
+	return []Address{t.From, *t.To}
 }
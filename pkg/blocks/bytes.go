@@ -0,0 +1,47 @@
+package blocks
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// Bytes represents arbitrary binary data, e.g. call data, event data or a logs bloom.
+// It is encoded as a 0x prefixed hex string of an even number of digits.
+type Bytes []byte
+
+// Hex returns the hex encoding of the data.
+func (b Bytes) Hex() string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+// String implements fmt.Stringer.
+func (b Bytes) String() string {
+	return b.Hex()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b Bytes) MarshalText() ([]byte, error) {
+	return []byte(b.Hex()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *Bytes) UnmarshalText(input []byte) error {
+	decoded, err := decodeHexBytes(input)
+	if err != nil {
+		return fmt.Errorf("could not decode bytes %q: %w", input, err)
+	}
+
+	*b = decoded
+
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Bytes) UnmarshalJSON(input []byte) error {
+	text, err := unquote(input, "bytes")
+	if err != nil {
+		return err
+	}
+
+	return b.UnmarshalText(text)
+}
@@ -0,0 +1,57 @@
+package blocks
+
+import (
+	"encoding/hex"
+)
+
+// HashLength is the number of bytes of a keccak256 hash, e.g. of a block or a transaction.
+const HashLength = 32
+
+// Hash represents a 32 byte keccak256 hash. It is encoded as a 0x prefixed lower case hex string.
+type Hash [HashLength]byte
+
+// ParseHash parses a 0x prefixed hex hash.
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+
+	if err := h.UnmarshalText([]byte(s)); err != nil {
+		return Hash{}, err
+	}
+
+	return h, nil
+}
+
+// Hex returns the hex encoding of the hash.
+func (h Hash) Hex() string {
+	return "0x" + hex.EncodeToString(h[:])
+}
+
+// String implements fmt.Stringer.
+func (h Hash) String() string {
+	return h.Hex()
+}
+
+// IsZero reports if all bytes of the hash are zero.
+func (h Hash) IsZero() bool {
+	return h == Hash{}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.Hex()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (h *Hash) UnmarshalText(input []byte) error {
+	return decodeFixedHexBytes(input, h[:], "hash")
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (h *Hash) UnmarshalJSON(input []byte) error {
+	text, err := unquote(input, "hash")
+	if err != nil {
+		return err
+	}
+
+	return h.UnmarshalText(text)
+}
@@ -0,0 +1,31 @@
+package blocks
+
+import "time"
+
+// CheckStatus represents the state of a health check.
+type CheckStatus string
+
+const (
+	// CheckPending marks a check which has not been run yet.
+	CheckPending CheckStatus = "pending"
+	// CheckPassing marks a check which passed on its last run.
+	CheckPassing CheckStatus = "pass"
+	// CheckFailing marks a check which failed on its last run.
+	CheckFailing CheckStatus = "fail"
+)
+
+// HealthCheck represents the state of a single readiness check together with its last failure.
+type HealthCheck struct {
+	Name      string      `json:"name"`
+	Status    CheckStatus `json:"status"`
+	CheckedAt *time.Time  `json:"checkedAt,omitempty"`
+	// LastError is the error of the last failed run, which is kept after the check passes again.
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// HealthReport represents the state of all readiness checks. It is passing only if all checks are passing.
+type HealthReport struct {
+	Status CheckStatus   `json:"status"`
+	Checks []HealthCheck `json:"checks"`
+}
@@ -0,0 +1,61 @@
+package blocks
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+var (
+	errNonString      = errors.New("value must be a JSON string")
+	errMissingPrefix  = errors.New("hex string without 0x prefix")
+	errEmptyNumber    = errors.New("hex number without digits")
+	errLeadingZero    = errors.New("hex number with leading zero digits")
+	errOddLength      = errors.New("hex string of odd length")
+	errInvalidHexChar = errors.New("invalid hex character")
+)
+
+// unquote strips the quotes of a JSON string. Strict JSON decoding of hex values only accepts strings,
+// so numbers, null and other literals are rejected.
+func unquote(input []byte, typeName string) ([]byte, error) {
+	if len(input) < 2 || input[0] != '"' || input[len(input)-1] != '"' {
+		return nil, fmt.Errorf("could not decode %s: %w", typeName, errNonString)
+	}
+
+	return input[1 : len(input)-1], nil
+}
+
+// decodeHexBytes decodes 0x prefixed hex data of an even number of digits.
+func decodeHexBytes(input []byte) ([]byte, error) {
+	if len(input) < 2 || input[0] != '0' || (input[1] != 'x' && input[1] != 'X') {
+		return nil, errMissingPrefix
+	}
+
+	digits := input[2:]
+	if len(digits)%2 != 0 {
+		return nil, errOddLength
+	}
+
+	decoded := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(decoded, digits); err != nil {
+		return nil, errInvalidHexChar
+	}
+
+	return decoded, nil
+}
+
+// decodeFixedHexBytes decodes 0x prefixed hex data of exactly len(out) bytes into out.
+func decodeFixedHexBytes(input []byte, out []byte, typeName string) error {
+	decoded, err := decodeHexBytes(input)
+	if err != nil {
+		return fmt.Errorf("could not decode %s %q: %w", typeName, input, err)
+	}
+
+	if len(decoded) != len(out) {
+		return fmt.Errorf("could not decode %s %q: expected %d bytes, got %d", typeName, input, len(out), len(decoded))
+	}
+
+	copy(out, decoded)
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package blocks
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHashJSON(t *testing.T) {
+	const want = "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "hash", input: `"` + want + `"`},
+		{name: "odd length", input: `"0x8"`, wantErr: true},
+		{name: "too short", input: `"0x88df"`, wantErr: true},
+		{name: "number", input: `1`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hash Hash
+
+			err := json.Unmarshal([]byte(tt.input), &hash)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && hash.Hex() != want {
+				t.Errorf("Hex() = %s, want %s", hash.Hex(), want)
+			}
+		})
+	}
+}
+
+func TestBytesJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "data", input: `"0xA9059CBB"`, want: "0xa9059cbb"},
+		{name: "empty", input: `"0x"`, want: "0x"},
+		{name: "odd length", input: `"0xa90"`, wantErr: true},
+		{name: "missing prefix", input: `"a9059cbb"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data Bytes
+
+			err := json.Unmarshal([]byte(tt.input), &data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && data.Hex() != tt.want {
+				t.Errorf("Hex() = %s, want %s", data.Hex(), tt.want)
+			}
+		})
+	}
+}
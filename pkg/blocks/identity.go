@@ -0,0 +1,49 @@
+package blocks
+
+// TransactionKey identifies a transaction stored for an address by its ID and its direction relative
+// to the address, so that storing the same transaction again is a no-op.
+func TransactionKey(id string, direction Direction) string {
+	return id + "/" + string(direction)
+}
+
+// TransferDirection returns the direction of a transfer relative to a lowercase address.
+// Transfers an address sends to itself are inbound.
+func TransferDirection(address string, to *Address) Direction {
+	if to != nil && to.Lower() == address {
+		return DirectionInbound
+	}
+
+	return DirectionOutbound
+}
+
+// Key identifies the observed transaction among the transactions stored for a lowercase address.
+func (t ObservedTransaction) Key(address string) string {
+	_, to, _ := t.Transfer()
+
+	return TransactionKey(t.ID(), TransferDirection(address, to))
+}
+
+// ID identifies a token transfer by the transaction and the position of the log it was decoded from.
+func (t TokenTransfer) ID() string {
+	return t.TransactionHash.Hex() + ":" + t.LogIndex.Hex()
+}
+
+// Key identifies the token transfer among the token transfers stored for a lowercase address.
+func (t TokenTransfer) Key(address string) string {
+	to := t.To
+
+	return TransactionKey(t.ID(), TransferDirection(address, &to))
+}
+
+// Key identifies the delivery among the deliveries stored for its address by the webhook, the block and
+// the delivered transaction and whether it was retracted, so that a transaction is delivered to a webhook once
+// per block it was included in and once more if it was retracted from that block.
+func (d WebhookDelivery) Key() string {
+	key := d.WebhookID + "/" + d.Payload.Transaction.BlockHash.Hex() + "/" + d.Payload.Transaction.Key(d.Address)
+
+	if d.Payload.Retracted {
+		key += "/retracted"
+	}
+
+	return key
+}
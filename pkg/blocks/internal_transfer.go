@@ -0,0 +1,14 @@
+package blocks
+
+// InternalTransfer represents a value transfer made by an internal call of a transaction,
+// e.g. a contract wallet forwarding ETH, which never shows up as the recipient of a transaction.
+type InternalTransfer struct {
+	TransactionHash Hash `json:"transactionHash"`
+	// Type is the kind of the call frame, e.g. CALL, CREATE or SELFDESTRUCT.
+	Type  string   `json:"type"`
+	From  Address  `json:"from"`
+	To    Address  `json:"to"`
+	Value Quantity `json:"value"`
+	// TraceAddress is the position of the call frame in the call tree of the transaction.
+	TraceAddress []int `json:"traceAddress"`
+}
@@ -0,0 +1,62 @@
+package blocks
+
+import "fmt"
+
+// ConfirmationStatus represents how settled an observed transaction is on the canonical chain.
+type ConfirmationStatus string
+
+const (
+	// StatusPendingConfirmation marks a transaction that has not reached the required confirmation depth yet.
+	StatusPendingConfirmation ConfirmationStatus = "pending-confirmation"
+	// StatusConfirmed marks a transaction that reached the confirmation depth or the "safe" block.
+	StatusConfirmed ConfirmationStatus = "confirmed"
+	// StatusFinalized marks a transaction contained in a block at or below the "finalized" block.
+	StatusFinalized ConfirmationStatus = "finalized"
+)
+
+// ObservedTransaction represents a transaction involving a subscribed address together with its confirmation state.
+type ObservedTransaction struct {
+	Transaction
+	// Sequence orders all observed transactions across subscribed addresses in the order they were stored.
+	Sequence      uint64             `json:"sequence"`
+	Status        ConfirmationStatus `json:"status"`
+	Confirmations int                `json:"confirmations"`
+	// Internal is set for value transfers made by an internal call of the transaction described by Trace.
+	Internal bool              `json:"internal"`
+	Trace    *InternalTransfer `json:"trace,omitempty"`
+}
+
+// TransactionEvent represents an observed transaction as published to the streams of subscribed addresses.
+type TransactionEvent struct {
+	Sequence    uint64              `json:"sequence"`
+	Address     string              `json:"address"`
+	Transaction ObservedTransaction `json:"transaction"`
+	// Retracted is set if the transaction was removed because its block was orphaned by a chain reorganization.
+	Retracted bool `json:"retracted,omitempty"`
+	// Reorg is set instead of the transaction for the chain reorganizations, which are published to all streams.
+	Reorg *ReorgEvent `json:"reorg,omitempty"`
+}
+
+// ReorgEvent describes a chain reorganization detected while observing new blocks.
+type ReorgEvent struct {
+	// Depth is the number of already processed blocks that were orphaned.
+	Depth int `json:"depth"`
+	// CommonAncestor is the number of the last block shared by the old and the new chain.
+	CommonAncestor int `json:"commonAncestor"`
+	// OldHashes are the hashes of the orphaned blocks in ascending block order.
+	OldHashes []Hash `json:"oldHashes"`
+	// NewHashes are the hashes of the canonical blocks replacing the orphaned ones in ascending block order.
+	NewHashes []Hash `json:"newHashes"`
+}
+
+// ID identifies an observed transaction, so that the internal transfers of a transaction
+// are told apart from each other and from the transaction itself.
+func (t ObservedTransaction) ID() string {
+	id := t.Hash.Hex()
+
+	if t.Internal && t.Trace != nil {
+		id += fmt.Sprint(t.Trace.TraceAddress)
+	}
+
+	return id
+}
@@ -0,0 +1,118 @@
+package blocks
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Quantity represents an unsigned integer of arbitrary size, e.g. a wei value, a gas price or a block number.
+// It is encoded as a 0x prefixed hex string without leading zeros, with "0x0" for zero.
+type Quantity big.Int
+
+// NewQuantity returns a Quantity of a non negative int.
+func NewQuantity(i int) Quantity {
+	var q Quantity
+
+	(*big.Int)(&q).SetInt64(int64(i))
+
+	return q
+}
+
+// NewQuantityFromBig returns a Quantity of a non negative big.Int.
+func NewQuantityFromBig(i *big.Int) Quantity {
+	var q Quantity
+
+	(*big.Int)(&q).Set(i)
+
+	return q
+}
+
+// ParseQuantity parses a 0x prefixed hex quantity.
+func ParseQuantity(s string) (Quantity, error) {
+	var q Quantity
+
+	if err := q.UnmarshalText([]byte(s)); err != nil {
+		return Quantity{}, err
+	}
+
+	return q, nil
+}
+
+// Big returns a copy of the quantity as a big.Int.
+func (q Quantity) Big() *big.Int {
+	return new(big.Int).Set((*big.Int)(&q))
+}
+
+// Int returns the quantity as an int, e.g. for block numbers and indexes.
+// It fails if the quantity does not fit into an int.
+func (q Quantity) Int() (int, error) {
+	i := (*big.Int)(&q)
+	if !i.IsInt64() || i.Int64() > math.MaxInt {
+		return 0, fmt.Errorf("quantity %s overflows int", q.Hex())
+	}
+
+	return int(i.Int64()), nil
+}
+
+// Cmp compares two quantities and returns -1, 0 or +1 like big.Int.Cmp.
+func (q Quantity) Cmp(other Quantity) int {
+	return (*big.Int)(&q).Cmp((*big.Int)(&other))
+}
+
+// IsZero reports if the quantity is zero.
+func (q Quantity) IsZero() bool {
+	return (*big.Int)(&q).Sign() == 0
+}
+
+// Hex returns the hex encoding of the quantity.
+func (q Quantity) Hex() string {
+	return "0x" + (*big.Int)(&q).Text(16)
+}
+
+// String implements fmt.Stringer.
+func (q Quantity) String() string {
+	return q.Hex()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (q Quantity) MarshalText() ([]byte, error) {
+	return []byte(q.Hex()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (q *Quantity) UnmarshalText(input []byte) error {
+	if len(input) < 2 || input[0] != '0' || (input[1] != 'x' && input[1] != 'X') {
+		return fmt.Errorf("could not decode quantity %q: %w", input, errMissingPrefix)
+	}
+
+	digits := input[2:]
+
+	switch {
+	case len(digits) == 0:
+		return fmt.Errorf("could not decode quantity %q: %w", input, errEmptyNumber)
+	case len(digits) > 1 && digits[0] == '0':
+		return fmt.Errorf("could not decode quantity %q: %w", input, errLeadingZero)
+	case digits[0] == '+' || digits[0] == '-':
+		return fmt.Errorf("could not decode quantity %q: %w", input, errInvalidHexChar)
+	}
+
+	i, ok := new(big.Int).SetString(string(digits), 16)
+	if !ok {
+		return fmt.Errorf("could not decode quantity %q: %w", input, errInvalidHexChar)
+	}
+
+	*q = Quantity(*i)
+
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (q *Quantity) UnmarshalJSON(input []byte) error {
+	text, err := unquote(input, "quantity")
+	if err != nil {
+		return err
+	}
+
+	return q.UnmarshalText(text)
+}
@@ -0,0 +1,95 @@
+package blocks
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    string
+		wantErr error
+	}{
+		{name: "zero", s: "0x0", want: "0x0"},
+		{name: "block number", s: "0x10d4f", want: "0x10d4f"},
+		{name: "upper case digits", s: "0XABC", want: "0xabc"},
+		{name: "beyond 64 bits", s: "0x1000000000000000000000000", want: "0x1000000000000000000000000"},
+		{name: "missing prefix", s: "10", wantErr: errMissingPrefix},
+		{name: "without digits", s: "0x", wantErr: errEmptyNumber},
+		{name: "leading zero", s: "0x01", wantErr: errLeadingZero},
+		{name: "sign", s: "0x-1", wantErr: errInvalidHexChar},
+		{name: "invalid character", s: "0xg", wantErr: errInvalidHexChar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseQuantity(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseQuantity() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && q.Hex() != tt.want {
+				t.Errorf("Hex() = %s, want %s", q.Hex(), tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantityInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    int
+		wantErr bool
+	}{
+		{name: "block number", s: "0x10", want: 16},
+		{name: "largest int", s: "0x7fffffffffffffff", want: 1<<63 - 1},
+		{name: "overflow", s: "0x8000000000000000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseQuantity(tt.s)
+			if err != nil {
+				t.Fatalf("ParseQuantity() error = %v", err)
+			}
+
+			got, err := q.Int()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Int() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Int() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantityJSON(t *testing.T) {
+	var tx struct {
+		Value    Quantity  `json:"value"`
+		MaxFee   *Quantity `json:"maxFee,omitempty"`
+		GasPrice *Quantity `json:"gasPrice,omitempty"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"value":"0xde0b6b3a7640000","maxFee":"0x3b9aca00"}`), &tx); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	encoded, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	if want := `{"value":"0xde0b6b3a7640000","maxFee":"0x3b9aca00"}`; string(encoded) != want {
+		t.Errorf("Marshal() = %s, want %s", encoded, want)
+	}
+
+	if err = json.Unmarshal([]byte(`{"value":16}`), &tx); err == nil {
+		t.Error("Unmarshal() of a number error = nil, want an error")
+	}
+}
@@ -0,0 +1,243 @@
+package blocks
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Direction represents the direction of a transaction relative to an address.
+type Direction string
+
+const (
+	// DirectionInbound marks transactions sent to an address.
+	DirectionInbound Direction = "inbound"
+	// DirectionOutbound marks transactions sent from an address.
+	DirectionOutbound Direction = "outbound"
+)
+
+// ErrInvalidCursor is returned when parsing a cursor which was not returned by a transaction listing.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TransactionQuery represents the filters and the page of a transaction listing of an address
+// ordered by block number and transaction index.
+type TransactionQuery struct {
+	// Direction restricts the listing to inbound or outbound transactions, an empty direction matches both.
+	Direction Direction
+	// MinValue and MaxValue bound the transferred value in wei inclusively, if set.
+	MinValue *Quantity
+	MaxValue *Quantity
+	// Counterparty restricts the listing to transactions sent from or to the given address, if set.
+	Counterparty *Address
+	// BlockRange restricts the listing to transactions contained in the given blocks, if set.
+	BlockRange *Range
+	// After restricts the listing to transactions positioned after the given cursor, if set.
+	After *TransactionCursor
+	// Limit is the maximum number of listed transactions. A zero limit lists all matching transactions.
+	Limit int
+}
+
+// TransactionCursor represents the position of a transaction in a listing. Transactions at the same
+// position, e.g. the internal transfers of a transaction, are told apart by their ID.
+type TransactionCursor struct {
+	BlockNumber int    `json:"b"`
+	Index       int    `json:"i"`
+	ID          string `json:"id"`
+}
+
+// ParseTransactionCursor parses an opaque cursor returned by a transaction listing.
+func ParseTransactionCursor(s string) (TransactionCursor, error) {
+	var c TransactionCursor
+
+	cursorBytes, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(cursorBytes, &c) != nil {
+		return TransactionCursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// String encodes the cursor as an opaque URL safe string.
+func (c TransactionCursor) String() string {
+	cursorBytes, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(cursorBytes)
+}
+
+// Before reports whether the cursor is positioned before another one.
+func (c TransactionCursor) Before(other TransactionCursor) bool {
+	if c.BlockNumber != other.BlockNumber {
+		return c.BlockNumber < other.BlockNumber
+	}
+
+	if c.Index != other.Index {
+		return c.Index < other.Index
+	}
+
+	return c.ID < other.ID
+}
+
+// Queryable is implemented by the transactions which can be listed by a TransactionQuery.
+type Queryable interface {
+	// Cursor returns the position of the transaction in a listing.
+	Cursor() (TransactionCursor, error)
+
+	// Transfer returns the sender, the recipient and the value of the transfer described by the transaction.
+	Transfer() (Address, *Address, Quantity)
+}
+
+// Cursor returns the position of the transaction in a listing.
+func (t Transaction) Cursor() (TransactionCursor, error) {
+	blockNum, err := t.BlockNumber.Int()
+	if err != nil {
+		return TransactionCursor{}, err
+	}
+
+	index, err := t.TransactionIndex.Int()
+	if err != nil {
+		return TransactionCursor{}, err
+	}
+
+	return TransactionCursor{BlockNumber: blockNum, Index: index, ID: t.Hash.Hex()}, nil
+}
+
+// Transfer returns the sender, the recipient and the value of the transaction.
+func (t Transaction) Transfer() (Address, *Address, Quantity) {
+	return t.From, t.To, t.Value
+}
+
+// Cursor returns the position of the observed transaction in a listing.
+func (t ObservedTransaction) Cursor() (TransactionCursor, error) {
+	c, err := t.Transaction.Cursor()
+	c.ID = t.ID()
+
+	return c, err
+}
+
+// Transfer returns the sender, the recipient and the value of the observed transaction
+// or of its internal transfer if it was observed because of one.
+func (t ObservedTransaction) Transfer() (Address, *Address, Quantity) {
+	if t.Internal && t.Trace != nil {
+		to := t.Trace.To
+
+		return t.Trace.From, &to, t.Trace.Value
+	}
+
+	return t.Transaction.Transfer()
+}
+
+// ApplyQuery filters the transactions of an address by a query and returns them ordered by their cursor,
+// starting after the cursor of the query and limited to its limit. If further transactions match,
+// the cursor of the last returned transaction is returned as well.
+func ApplyQuery[T Queryable](address string, txs []T, query TransactionQuery) ([]T, *TransactionCursor, error) {
+	address = strings.ToLower(address)
+
+	type positioned struct {
+		tx     T
+		cursor TransactionCursor
+	}
+
+	matched := make([]positioned, 0)
+
+	for _, tx := range txs {
+		cursor, err := tx.Cursor()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if query.matches(address, tx, cursor) {
+			matched = append(matched, positioned{tx: tx, cursor: cursor})
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].cursor.Before(matched[j].cursor)
+	})
+
+	var next *TransactionCursor
+
+	if query.Limit > 0 && len(matched) > query.Limit {
+		matched = matched[:query.Limit]
+		next = &matched[query.Limit-1].cursor
+	}
+
+	page := make([]T, len(matched))
+
+	for i, m := range matched {
+		page[i] = m.tx
+	}
+
+	return page, next, nil
+}
+
+// Matches reports whether a transaction of an address matches the filters and the cursor of the query.
+// Stores use it to stop reading once a page and the first transaction of the next page have matched.
+func (q TransactionQuery) Matches(address string, tx Queryable) (bool, error) {
+	cursor, err := tx.Cursor()
+	if err != nil {
+		return false, err
+	}
+
+	return q.matches(strings.ToLower(address), tx, cursor), nil
+}
+
+// matches reports whether a transaction positioned at a given cursor matches the query
+// relative to a lowercase address.
+func (q TransactionQuery) matches(address string, tx Queryable, cursor TransactionCursor) bool {
+	if q.After != nil && !q.After.Before(cursor) {
+		return false
+	}
+
+	if q.BlockRange != nil && !q.BlockRange.Contains(cursor.BlockNumber) {
+		return false
+	}
+
+	return q.matchesTransfer(address, tx)
+}
+
+// Full reports whether a given number of matching transactions fill the page of the query together with
+// the first transaction of the next page, which tells whether there are further transactions.
+func (q TransactionQuery) Full(matched int) bool {
+	return q.Limit > 0 && matched > q.Limit
+}
+
+// matchesTransfer reports whether the transfer of a transaction matches the direction, value
+// and counterparty filters of the query relative to a lowercase address.
+func (q TransactionQuery) matchesTransfer(address string, tx Queryable) bool {
+	from, to, value := tx.Transfer()
+
+	inbound := to != nil && to.Lower() == address
+	outbound := from.Lower() == address
+
+	switch q.Direction {
+	case DirectionInbound:
+		if !inbound {
+			return false
+		}
+	case DirectionOutbound:
+		if !outbound {
+			return false
+		}
+	}
+
+	if q.MinValue != nil && value.Cmp(*q.MinValue) < 0 {
+		return false
+	}
+
+	if q.MaxValue != nil && value.Cmp(*q.MaxValue) > 0 {
+		return false
+	}
+
+	if q.Counterparty != nil {
+		fromCounterparty := inbound && from == *q.Counterparty
+		toCounterparty := outbound && to != nil && *to == *q.Counterparty
+
+		if !fromCounterparty && !toCounterparty {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,247 @@
+package blocks
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+const _testAddress = "0x00000000000000000000000000000000000000aa"
+
+func TestTransactionCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursor  string
+		want    TransactionCursor
+		wantErr error
+	}{
+		{
+			name:   "encoded cursor",
+			cursor: TransactionCursor{BlockNumber: 17, Index: 3, ID: "0x01"}.String(),
+			want:   TransactionCursor{BlockNumber: 17, Index: 3, ID: "0x01"},
+		},
+		{
+			name:    "not base64",
+			cursor:  "not a cursor!",
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "not json",
+			cursor:  "bm90IGpzb24",
+			wantErr: ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTransactionCursor(tt.cursor)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseTransactionCursor() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseTransactionCursor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionCursorBefore(t *testing.T) {
+	tests := []struct {
+		name  string
+		c     TransactionCursor
+		other TransactionCursor
+		want  bool
+	}{
+		{
+			name:  "earlier block",
+			c:     TransactionCursor{BlockNumber: 1, Index: 5},
+			other: TransactionCursor{BlockNumber: 2},
+			want:  true,
+		},
+		{
+			name:  "earlier index",
+			c:     TransactionCursor{BlockNumber: 2, Index: 1},
+			other: TransactionCursor{BlockNumber: 2, Index: 2},
+			want:  true,
+		},
+		{
+			name:  "same position, lower ID",
+			c:     TransactionCursor{BlockNumber: 2, Index: 2, ID: "a"},
+			other: TransactionCursor{BlockNumber: 2, Index: 2, ID: "b"},
+			want:  true,
+		},
+		{
+			name:  "same cursor",
+			c:     TransactionCursor{BlockNumber: 2, Index: 2, ID: "a"},
+			other: TransactionCursor{BlockNumber: 2, Index: 2, ID: "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Before(tt.other); got != tt.want {
+				t.Errorf("Before() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyQueryPages(t *testing.T) {
+	address, err := ParseAddress(_testAddress)
+	if err != nil {
+		t.Fatalf("ParseAddress() error = %v", err)
+	}
+
+	// Transactions are passed out of order, every other one is sent to the address.
+	txs := make([]Transaction, 0)
+
+	for blockNum := 3; blockNum >= 1; blockNum-- {
+		for i := 0; i < 4; i++ {
+			tx := testTransaction(blockNum, i)
+			if i%2 == 0 {
+				tx.To = &address
+			}
+
+			txs = append(txs, tx)
+		}
+	}
+
+	query := TransactionQuery{
+		Direction:  DirectionInbound,
+		BlockRange: &Range{From: 2, To: 3},
+		Limit:      3,
+	}
+
+	pages := make([][]string, 0)
+
+	for {
+		page, next, errQuery := ApplyQuery(_testAddress, txs, query)
+		if errQuery != nil {
+			t.Fatalf("ApplyQuery() error = %v", errQuery)
+		}
+
+		ids := make([]string, len(page))
+
+		for i, tx := range page {
+			ids[i] = fmt.Sprintf("%d-%d", mustInt(t, tx.BlockNumber), mustInt(t, tx.TransactionIndex))
+		}
+
+		pages = append(pages, ids)
+
+		if next == nil {
+			break
+		}
+
+		query.After = next
+	}
+
+	want := [][]string{{"2-0", "2-2", "3-0"}, {"3-2"}}
+
+	if fmt.Sprint(pages) != fmt.Sprint(want) {
+		t.Errorf("ApplyQuery() pages = %v, want %v", pages, want)
+	}
+}
+
+func TestApplyQueryFilters(t *testing.T) {
+	address, err := ParseAddress(_testAddress)
+	if err != nil {
+		t.Fatalf("ParseAddress() error = %v", err)
+	}
+
+	counterparty := Address{AddressLength - 1: 0xbb}
+	other := Address{AddressLength - 1: 0xcc}
+	minValue, maxValue := NewQuantity(10), NewQuantity(100)
+
+	// The first transaction is received from the counterparty, the second one sent to it
+	// and the third one received from another address.
+	txs := []Transaction{testTransaction(1, 0), testTransaction(1, 1), testTransaction(1, 2)}
+	txs[0].From, txs[0].To, txs[0].Value = counterparty, &address, NewQuantity(5)
+	txs[1].From, txs[1].To, txs[1].Value = address, &counterparty, NewQuantity(50)
+	txs[2].From, txs[2].To, txs[2].Value = other, &address, NewQuantity(500)
+
+	tests := []struct {
+		name  string
+		query TransactionQuery
+		want  string
+	}{
+		{name: "no filters", want: "[1-0 1-1 1-2]"},
+		{name: "inbound", query: TransactionQuery{Direction: DirectionInbound}, want: "[1-0 1-2]"},
+		{name: "outbound", query: TransactionQuery{Direction: DirectionOutbound}, want: "[1-1]"},
+		{name: "minimum value", query: TransactionQuery{MinValue: &minValue}, want: "[1-1 1-2]"},
+		{name: "maximum value", query: TransactionQuery{MaxValue: &maxValue}, want: "[1-0 1-1]"},
+		{name: "counterparty", query: TransactionQuery{Counterparty: &counterparty}, want: "[1-0 1-1]"},
+		{
+			name:  "combined filters",
+			query: TransactionQuery{Direction: DirectionInbound, Counterparty: &counterparty, MinValue: &minValue},
+			want:  "[]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, next, errQuery := ApplyQuery(_testAddress, txs, tt.query)
+			if errQuery != nil {
+				t.Fatalf("ApplyQuery() error = %v", errQuery)
+			}
+
+			ids := make([]string, len(page))
+
+			for i, tx := range page {
+				ids[i] = fmt.Sprintf("%d-%d", mustInt(t, tx.BlockNumber), mustInt(t, tx.TransactionIndex))
+			}
+
+			if fmt.Sprint(ids) != tt.want || next != nil {
+				t.Errorf("ApplyQuery() = %v, next %v, want %s and no next cursor", ids, next, tt.want)
+			}
+		})
+	}
+}
+
+// testTransaction returns a transaction positioned at a given index of a given block.
+func TestTransactionQueryFull(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		matched int
+		want    bool
+	}{
+		{name: "no limit", limit: 0, matched: 100},
+		{name: "page not filled", limit: 2, matched: 1},
+		{name: "page filled without next page", limit: 2, matched: 2},
+		{name: "page filled with next page", limit: 2, matched: 3, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (TransactionQuery{Limit: tt.limit}).Full(tt.matched); got != tt.want {
+				t.Errorf("Full() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testTransaction returns a transaction positioned at a given index of a given block.
+func testTransaction(blockNum, index int) Transaction {
+	var hash Hash
+
+	hash[0] = byte(blockNum)
+	hash[1] = byte(index)
+
+	return Transaction{
+		Hash:             hash,
+		BlockNumber:      NewQuantity(blockNum),
+		TransactionIndex: NewQuantity(index),
+	}
+}
+
+func mustInt(t *testing.T, q Quantity) int {
+	t.Helper()
+
+	i, err := q.Int()
+	if err != nil {
+		t.Fatalf("Int() error = %v", err)
+	}
+
+	return i
+}
@@ -0,0 +1,94 @@
+package blocks
+
+import "sort"
+
+// Range represents an inclusive range of block numbers.
+type Range struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// Len returns the number of blocks in the range.
+func (r Range) Len() int {
+	if r.To < r.From {
+		return 0
+	}
+
+	return r.To - r.From + 1
+}
+
+// Contains reports whether a block number is part of the range.
+func (r Range) Contains(blockNum int) bool {
+	return r.From <= blockNum && blockNum <= r.To
+}
+
+// MergeRanges adds a range to a set of ranges and returns them sorted with overlapping and adjacent ranges joined.
+func MergeRanges(ranges []Range, r Range) []Range {
+	all := make([]Range, 0, len(ranges)+1)
+	all = append(all, ranges...)
+	all = append(all, r)
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].From < all[j].From
+	})
+
+	merged := make([]Range, 0, len(all))
+
+	for _, current := range all {
+		last := len(merged) - 1
+		if last >= 0 && current.From <= merged[last].To+1 {
+			if current.To > merged[last].To {
+				merged[last].To = current.To
+			}
+
+			continue
+		}
+
+		merged = append(merged, current)
+	}
+
+	return merged
+}
+
+// TruncateRanges returns the parts of a set of sorted ranges up to a given block number inclusive.
+func TruncateRanges(ranges []Range, blockNum int) []Range {
+	truncated := make([]Range, 0, len(ranges))
+
+	for _, r := range ranges {
+		if r.From > blockNum {
+			break
+		}
+
+		if r.To > blockNum {
+			r.To = blockNum
+		}
+
+		truncated = append(truncated, r)
+	}
+
+	return truncated
+}
+
+// SubtractRanges returns the parts of a range not covered by a set of sorted non-overlapping ranges.
+func SubtractRanges(r Range, covered []Range) []Range {
+	missing := make([]Range, 0)
+	next := r.From
+
+	for _, c := range covered {
+		if c.To < next || c.From > r.To {
+			continue
+		}
+
+		if c.From > next {
+			missing = append(missing, Range{From: next, To: c.From - 1})
+		}
+
+		next = c.To + 1
+	}
+
+	if next <= r.To {
+		missing = append(missing, Range{From: next, To: r.To})
+	}
+
+	return missing
+}
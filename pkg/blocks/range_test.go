@@ -0,0 +1,80 @@
+package blocks
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMergeRanges(t *testing.T) {
+	ranges := []Range{{From: 0, To: 4}, {From: 10, To: 12}}
+
+	tests := []struct {
+		name string
+		r    Range
+		want []Range
+	}{
+		{
+			name: "disjoint range",
+			r:    Range{From: 6, To: 8},
+			want: []Range{{From: 0, To: 4}, {From: 6, To: 8}, {From: 10, To: 12}},
+		},
+		{name: "adjacent range", r: Range{From: 5, To: 6}, want: []Range{{From: 0, To: 6}, {From: 10, To: 12}}},
+		{name: "bridging range", r: Range{From: 3, To: 11}, want: []Range{{From: 0, To: 12}}},
+		{name: "covered range", r: Range{From: 1, To: 2}, want: ranges},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeRanges(ranges, tt.r); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("MergeRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubtractRanges(t *testing.T) {
+	covered := []Range{{From: 0, To: 4}, {From: 10, To: 12}}
+
+	tests := []struct {
+		name string
+		r    Range
+		want []Range
+	}{
+		{name: "uncovered range", r: Range{From: 5, To: 9}, want: []Range{{From: 5, To: 9}}},
+		{name: "covered range", r: Range{From: 1, To: 3}, want: []Range{}},
+		{name: "gap between ranges", r: Range{From: 2, To: 15}, want: []Range{{From: 5, To: 9}, {From: 13, To: 15}}},
+		{name: "range after the covered ones", r: Range{From: 13, To: 20}, want: []Range{{From: 13, To: 20}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SubtractRanges(tt.r, covered); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("SubtractRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateRanges(t *testing.T) {
+	ranges := []Range{{From: 0, To: 4}, {From: 6, To: 9}, {From: 12, To: 15}}
+
+	tests := []struct {
+		name     string
+		blockNum int
+		want     []Range
+	}{
+		{name: "after all ranges", blockNum: 20, want: ranges},
+		{name: "within a range", blockNum: 7, want: []Range{{From: 0, To: 4}, {From: 6, To: 7}}},
+		{name: "at the start of a range", blockNum: 6, want: []Range{{From: 0, To: 4}, {From: 6, To: 6}}},
+		{name: "between ranges", blockNum: 10, want: []Range{{From: 0, To: 4}, {From: 6, To: 9}}},
+		{name: "before all ranges", blockNum: -1, want: []Range{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TruncateRanges(ranges, tt.blockNum); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("TruncateRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
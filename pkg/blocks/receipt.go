@@ -0,0 +1,34 @@
+package blocks
+
+// Receipt represents the receipt of an executed Ethereum transaction.
+type Receipt struct {
+	BlockHash   Hash     `json:"blockHash"`
+	BlockNumber Quantity `json:"blockNumber"`
+	// ContractAddress is only set for contract creation transactions.
+	ContractAddress   *Address `json:"contractAddress"`
+	CumulativeGasUsed Quantity `json:"cumulativeGasUsed"`
+	EffectiveGasPrice Quantity `json:"effectiveGasPrice"`
+	From              Address  `json:"from"`
+	GasUsed           Quantity `json:"gasUsed"`
+	Logs              []Log    `json:"logs"`
+	LogsBloom         Bytes    `json:"logsBloom"`
+	Status            Quantity `json:"status"`
+	// To is nil for contract creation transactions.
+	To               *Address `json:"to"`
+	TransactionHash  Hash     `json:"transactionHash"`
+	TransactionIndex Quantity `json:"transactionIndex"`
+	Type             Quantity `json:"type"`
+}
+
+// Log represents an event log emitted during the execution of a transaction.
+type Log struct {
+	Address          Address  `json:"address"`
+	BlockHash        Hash     `json:"blockHash"`
+	BlockNumber      Quantity `json:"blockNumber"`
+	Data             Bytes    `json:"data"`
+	LogIndex         Quantity `json:"logIndex"`
+	Removed          bool     `json:"removed"`
+	Topics           []Hash   `json:"topics"`
+	TransactionHash  Hash     `json:"transactionHash"`
+	TransactionIndex Quantity `json:"transactionIndex"`
+}
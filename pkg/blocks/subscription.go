@@ -0,0 +1,12 @@
+package blocks
+
+import "time"
+
+// Subscription represents an address observed for new transactions.
+type Subscription struct {
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"createdAt"`
+	// LastCheckedBlock is the last block checked for transactions of the address or -1 if none was checked yet.
+	LastCheckedBlock     int `json:"lastCheckedBlock"`
+	ObservedTransactions int `json:"observedTransactions"`
+}
@@ -0,0 +1,60 @@
+package blocks
+
+import "math/big"
+
+// TransferEventTopic is the keccak256 hash of the Transfer(address,address,uint256) event signature.
+var TransferEventTopic = Hash{
+	0xdd, 0xf2, 0x52, 0xad, 0x1b, 0xe2, 0xc8, 0x9b, 0x69, 0xc2, 0xb0, 0x68, 0xfc, 0x37, 0x8d, 0xaa,
+	0x95, 0x2b, 0xa7, 0xf1, 0x63, 0xc4, 0xa1, 0x16, 0x28, 0xf5, 0x5a, 0x4d, 0xf5, 0x23, 0xb3, 0xef,
+}
+
+// TokenTransfer represents an ERC-20 token transfer decoded from a Transfer event log.
+type TokenTransfer struct {
+	Token            Address  `json:"token"`
+	From             Address  `json:"from"`
+	To               Address  `json:"to"`
+	Amount           Quantity `json:"amount"`
+	BlockHash        Hash     `json:"blockHash"`
+	BlockNumber      Quantity `json:"blockNumber"`
+	LogIndex         Quantity `json:"logIndex"`
+	TransactionHash  Hash     `json:"transactionHash"`
+	TransactionIndex Quantity `json:"transactionIndex"`
+}
+
+// DecodeTokenTransfer decodes an ERC-20 Transfer event log. ERC-721 transfers share the event signature,
+// but index the token ID as a fourth topic, so they are not reported as token transfers.
+func DecodeTokenTransfer(log Log) (TokenTransfer, bool) {
+	if len(log.Topics) != 3 || log.Topics[0] != TransferEventTopic || len(log.Data) != HashLength {
+		return TokenTransfer{}, false
+	}
+
+	return TokenTransfer{
+		Token:            log.Address,
+		From:             topicToAddress(log.Topics[1]),
+		To:               topicToAddress(log.Topics[2]),
+		Amount:           NewQuantityFromBig(new(big.Int).SetBytes(log.Data)),
+		BlockHash:        log.BlockHash,
+		BlockNumber:      log.BlockNumber,
+		LogIndex:         log.LogIndex,
+		TransactionHash:  log.TransactionHash,
+		TransactionIndex: log.TransactionIndex,
+	}, true
+}
+
+// AddressToTopic left pads an address to the 32 bytes of an indexed event topic.
+func AddressToTopic(address Address) Hash {
+	var topic Hash
+
+	copy(topic[HashLength-AddressLength:], address[:])
+
+	return topic
+}
+
+// topicToAddress extracts the address from the lower 20 bytes of an indexed event topic.
+func topicToAddress(topic Hash) Address {
+	var address Address
+
+	copy(address[:], topic[HashLength-AddressLength:])
+
+	return address
+}
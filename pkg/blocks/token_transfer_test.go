@@ -0,0 +1,72 @@
+package blocks
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDecodeTokenTransfer(t *testing.T) {
+	var (
+		token  = Address{AddressLength - 1: 0xcc}
+		from   = Address{AddressLength - 1: 0xaa}
+		to     = Address{AddressLength - 1: 0xbb}
+		amount = Hash{HashLength - 2: 0x03, HashLength - 1: 0xe8}
+	)
+
+	tests := []struct {
+		name   string
+		log    Log
+		want   string
+		wantOK bool
+	}{
+		{
+			name: "erc-20 transfer",
+			log: Log{
+				Address: token,
+				Topics:  []Hash{TransferEventTopic, AddressToTopic(from), AddressToTopic(to)},
+				Data:    amount[:],
+			},
+			want:   fmt.Sprintf("%s %s %s 0x3e8", token, from, to),
+			wantOK: true,
+		},
+		{
+			name: "zero amount",
+			log: Log{
+				Topics: []Hash{TransferEventTopic, AddressToTopic(from), AddressToTopic(to)},
+				Data:   make(Bytes, HashLength),
+			},
+			want:   fmt.Sprintf("%s %s %s 0x0", Address{}, from, to),
+			wantOK: true,
+		},
+		{
+			name: "erc-721 transfer",
+			log: Log{
+				Topics: []Hash{TransferEventTopic, AddressToTopic(from), AddressToTopic(to), amount},
+			},
+		},
+		{
+			name: "other event",
+			log: Log{
+				Topics: []Hash{amount, AddressToTopic(from), AddressToTopic(to)},
+				Data:   amount[:],
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DecodeTokenTransfer(tt.log)
+			if ok != tt.wantOK {
+				t.Fatalf("DecodeTokenTransfer() ok = %v, want %v", ok, tt.wantOK)
+			}
+
+			if !ok {
+				return
+			}
+
+			if transfer := fmt.Sprintf("%s %s %s %s", got.Token, got.From, got.To, got.Amount); transfer != tt.want {
+				t.Errorf("DecodeTokenTransfer() = %s, want %s", transfer, tt.want)
+			}
+		})
+	}
+}
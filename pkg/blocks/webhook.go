@@ -0,0 +1,48 @@
+package blocks
+
+import "time"
+
+// DeliveryStatus represents the state of a webhook delivery.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending marks a delivery which has not succeeded yet and is still retried.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered marks a delivery acknowledged by the webhook with a 2xx status.
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDeadLetter marks a delivery which failed too many times and is not retried anymore.
+	DeliveryDeadLetter DeliveryStatus = "dead-letter"
+)
+
+// Webhook represents a URL registered for a subscribed address, which observed transactions are POSTed to.
+type Webhook struct {
+	ID        string    `json:"id"`
+	Address   string    `json:"address"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookPayload represents the signed JSON body POSTed to a webhook for an observed transaction.
+type WebhookPayload struct {
+	Address     string              `json:"address"`
+	Transaction ObservedTransaction `json:"transaction"`
+	// Retracted is set if the transaction was removed because its block was orphaned by a chain reorganization.
+	Retracted bool `json:"retracted,omitempty"`
+}
+
+// WebhookDelivery represents the delivery of an observed transaction to a webhook together with its attempts.
+type WebhookDelivery struct {
+	ID            string         `json:"id"`
+	WebhookID     string         `json:"webhookId"`
+	Address       string         `json:"address"`
+	URL           string         `json:"url"`
+	Payload       WebhookPayload `json:"payload"`
+	Status        DeliveryStatus `json:"status"`
+	Attempts      int            `json:"attempts"`
+	NextAttemptAt time.Time      `json:"nextAttemptAt"`
+	// LastStatusCode is the HTTP status of the last attempt or 0 if it failed before a response was received.
+	LastStatusCode int       `json:"lastStatusCode"`
+	LastError      string    `json:"lastError,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
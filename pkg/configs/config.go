@@ -1,15 +1,51 @@
 package configs
 
 import (
+	"time"
+
 	"github.com/joeshaw/envdecode"
 	"github.com/pkg/errors"
 )
 
 // Config represents all HTTP server configuration options.
 type Config struct {
-	Host         string `env:"SERVER_HOST"`
-	Port         int    `env:"SERVER_PORT"`
-	EthereumHost string `env:"ETHEREUM_HOST"`
+	Host                       string        `env:"SERVER_HOST"`
+	Port                       int           `env:"SERVER_PORT"`
+	EthereumHost               string        `env:"ETHEREUM_HOST"`
+	EthereumWSHost             string        `env:"ETHEREUM_WS_HOST"`
+	EthereumHosts              []string      `env:"ETHEREUM_HOSTS"`
+	RPCPoolMaxHeadLag          int           `env:"RPC_POOL_MAX_HEAD_LAG,default=5"`
+	RPCPoolHealthCheckInterval time.Duration `env:"RPC_POOL_HEALTH_CHECK_INTERVAL,default=10s"`
+	RPCMaxAttempts             int           `env:"RPC_MAX_ATTEMPTS,default=3"`
+	RPCRateLimit               float64       `env:"RPC_RATE_LIMIT,default=0"`
+	RPCMethodRateLimits        MethodValues  `env:"RPC_METHOD_RATE_LIMITS"`
+	RPCComputeUnits            MethodValues  `env:"RPC_COMPUTE_UNITS"`
+	RPCDailyBudget             int64         `env:"RPC_DAILY_CU_BUDGET,default=0"`
+	RPCMonthlyBudget           int64         `env:"RPC_MONTHLY_CU_BUDGET,default=0"`
+	ConfirmationDepth          int           `env:"CONFIRMATION_DEPTH,default=12"`
+	UseFinalityTags            bool          `env:"USE_FINALITY_TAGS,default=true"`
+	HistoryScanWorkers         int           `env:"HISTORY_SCAN_WORKERS,default=8"`
+	MaxBlockRange              int           `env:"MAX_BLOCK_RANGE,default=10000"`
+	StorageBackend             string        `env:"STORAGE_BACKEND,default=memory"`
+	StoragePath                string        `env:"STORAGE_PATH,default=ethereum-block-scanner.db"`
+	Tracer                     string        `env:"TRACER,default=none"`
+	WebhookMaxAttempts         int           `env:"WEBHOOK_MAX_ATTEMPTS,default=8"`
+	WebhookInitialBackoff      time.Duration `env:"WEBHOOK_INITIAL_BACKOFF,default=10s"`
+	WebhookMaxBackoff          time.Duration `env:"WEBHOOK_MAX_BACKOFF,default=1h"`
+	WebhookTimeout             time.Duration `env:"WEBHOOK_TIMEOUT,default=10s"`
+	ChainID                    int           `env:"CHAIN_ID,default=1"`
+	HealthCheckInterval        time.Duration `env:"HEALTH_CHECK_INTERVAL,default=15s"`
+	HeadStallTimeout           time.Duration `env:"HEAD_STALL_TIMEOUT,default=2m"`
+	ObserverMaxFailures        int           `env:"OBSERVER_MAX_FAILURES,default=3"`
+	WorkerRestartInitialDelay  time.Duration `env:"WORKER_RESTART_INITIAL_DELAY,default=1s"`
+	WorkerRestartMaxDelay      time.Duration `env:"WORKER_RESTART_MAX_DELAY,default=1m"`
+	ShutdownTimeout            time.Duration `env:"SHUTDOWN_TIMEOUT,default=30s"`
+	LogLevel                   string        `env:"LOG_LEVEL,default=info"`
+	LogFormat                  string        `env:"LOG_FORMAT,default=text"`
+	TraceExporter              string        `env:"TRACE_EXPORTER,default=none"`
+	TraceFile                  string        `env:"TRACE_FILE,default=traces.json"`
+	TraceOTLPEndpoint          string        `env:"TRACE_OTLP_ENDPOINT"`
+	TraceSampleRatio           float64       `env:"TRACE_SAMPLE_RATIO,default=1"`
 }
 
 // NewConfig constructs a new instance of Config via decoding
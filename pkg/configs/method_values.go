@@ -0,0 +1,39 @@
+package configs
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MethodValues maps JSON-RPC method names to numbers decoded from "method=value" pairs separated by ";",
+// e.g. "eth_blockNumber=25;debug_traceBlockByNumber=0.5".
+type MethodValues map[string]float64
+
+// Decode decodes MethodValues from an env var value.
+func (m *MethodValues) Decode(value string) error {
+	values := make(MethodValues)
+
+	for _, pair := range strings.Split(value, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		method, number, found := strings.Cut(pair, "=")
+		if !found {
+			return errors.Errorf("invalid method value %q, expected method=value", pair)
+		}
+
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value for method %s", method)
+		}
+
+		values[strings.TrimSpace(method)] = parsed
+	}
+
+	*m = values
+
+	return nil
+}
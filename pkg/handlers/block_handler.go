@@ -1,67 +1,120 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"math"
+	"math/big"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	pkgErrors "github.com/pkg/errors"
 
 	"github.com/gorilla/mux"
 
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
 	"github.com/powerslider/ethereum-block-scanner/pkg/sdk"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+const (
+	_defaultPageLimit = 50
+	_maxPageLimit     = 500
 )
 
 // BlockHandler represents an HTTP handler for Ethereum block operations.
 type BlockHandler struct {
-	Parser sdk.Parser
+	Parser   sdk.Parser
+	Webhooks sdk.WebhookManager
 }
 
 // NewBlockHandler initializes a new instance of BlockHandler.
-func NewBlockHandler(parser sdk.Parser) *BlockHandler {
+func NewBlockHandler(parser sdk.Parser, webhooks sdk.WebhookManager) *BlockHandler {
 	return &BlockHandler{
-		Parser: parser,
+		Parser:   parser,
+		Webhooks: webhooks,
 	}
 }
 
 // GetBlockTransactionsPerAddress godoc
 // @Summary Get all transactions for a fixed block range given an address.
 // @Description Get all transactions for a fixed block range given an address.
+// @Description The lower bound is given either by fromBlock, fromTime or as blockRange blocks back
+// @Description from the upper bound.
+// @Description The upper bound is given either by toBlock, toTime or defaults to the latest block.
+// @Description Times are accepted as RFC3339 strings or unix timestamps in seconds.
+// @Description Transactions are ordered by block number and transaction index and listed one page at a time.
+// @Description The next page is requested with the nextCursor of the previous page.
+// @Description The response is an object holding the transactions, the nextCursor and the limit of the page.
+// @Description The limit defaults to 50.
+// @Description Blocks not scanned for the address yet are fetched by a low priority backfill. Once the RPC compute unit
+// @Description budget is exhausted, the backfill is rejected with 503 Service Unavailable.
 // @Tags blocks
 // @Accept  json
 // @Produce  json
 // @Param address path string true "Address"
 // @Param blockRange query int false "Block Range" default(0)
+// @Param fromBlock query int false "From Block"
+// @Param toBlock query int false "To Block"
+// @Param fromTime query string false "From Time"
+// @Param toTime query string false "To Time"
+// @Param direction query string false "Direction" Enums(inbound, outbound)
+// @Param minValue query string false "Minimum value in wei, decimal or 0x prefixed hex"
+// @Param maxValue query string false "Maximum value in wei, decimal or 0x prefixed hex"
+// @Param counterparty query string false "Counterparty Address"
+// @Param cursor query string false "Cursor"
+// @Param limit query int false "Limit" default(50)
 // @Router /api/v1/address/{address}/transactions [get]
 func (h *BlockHandler) GetBlockTransactionsPerAddress() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		blockRange, err := strconv.Atoi(r.URL.Query().Get("blockRange"))
-		if err != nil {
+		vars := mux.Vars(r)
+
+		address, ok := vars["address"]
+		if !ok {
 			badRequestError(
 				rw,
-				pkgErrors.Wrap(err, "invalid query param 'blockRange':"),
+				errors.New("required path param 'address' is missing"),
 			)
 
 			return
 		}
 
-		vars := mux.Vars(r)
+		query := r.URL.Query()
 
-		address, ok := vars["address"]
-		if !ok {
-			badRequestError(
+		txQuery, err := parseTransactionQuery(query)
+		if err != nil {
+			badRequestError(rw, err)
+
+			return
+		}
+
+		fromBlock, toBlock, err := h.resolveBlockBounds(ctx, query)
+		if err != nil {
+			badRequestError(rw, err)
+
+			return
+		}
+
+		txs, next, err := h.Parser.GetTransactionsForBlocks(ctx, address, fromBlock, toBlock, txQuery)
+		if errors.Is(err, jsonrpc.ErrBudgetExhausted) {
+			errorResponse(
 				rw,
-				errors.New("required path param 'address' is missing"),
+				http.StatusServiceUnavailable,
+				pkgErrors.Wrapf(err, "Could not get transactions for address %s", address),
 			)
 
 			return
 		}
 
-		txs, err := h.Parser.GetTransactionsForBlockRange(ctx, address, blockRange)
 		if err != nil {
 			badRequestError(
 				rw,
@@ -71,17 +124,125 @@ func (h *BlockHandler) GetBlockTransactionsPerAddress() http.HandlerFunc {
 			return
 		}
 
-		handleResponse(rw, txs)
+		handleResponse(rw, transactionsPage[blocks.Transaction]{
+			Transactions: txs,
+			NextCursor:   cursorString(next),
+			Limit:        txQuery.Limit,
+		})
+	}
+}
+
+// resolveBlockBounds resolves the block range of a transaction history query from its query params.
+func (h *BlockHandler) resolveBlockBounds(ctx context.Context, query url.Values) (int, int, error) {
+	toBlock, found, err := h.resolveBlockBound(ctx, query, "toBlock", "toTime", h.Parser.GetLastBlockNumberAtOrBefore)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	if !found {
+		if toBlock, err = h.Parser.GetCurrentBlock(ctx); err != nil {
+			return -1, -1, err
+		}
+	}
+
+	fromBlock, found, err := h.resolveBlockBound(
+		ctx, query, "fromBlock", "fromTime", h.Parser.GetFirstBlockNumberAtOrAfter)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	if !found {
+		blockRange, errBlockRange := parseIntParam(query, "blockRange")
+		if errBlockRange != nil {
+			return -1, -1, errBlockRange
+		}
+
+		fromBlock = toBlock - blockRange
+	}
+
+	if fromBlock < 0 {
+		fromBlock = 0
+	}
+
+	return fromBlock, toBlock, nil
+}
+
+// resolveBlockWindow resolves the optional block window of an observed transactions query from its query params.
+// Either bound may be left open. It returns nil if neither bound is given.
+func (h *BlockHandler) resolveBlockWindow(ctx context.Context, query url.Values) (*blocks.Range, error) {
+	fromBlock, foundFrom, err := h.resolveBlockBound(
+		ctx, query, "fromBlock", "fromTime", h.Parser.GetFirstBlockNumberAtOrAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	toBlock, foundTo, err := h.resolveBlockBound(ctx, query, "toBlock", "toTime", h.Parser.GetLastBlockNumberAtOrBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !foundFrom && !foundTo:
+		return nil, nil
+	case !foundFrom:
+		fromBlock = 0
+	case !foundTo:
+		toBlock = math.MaxInt
+	}
+
+	return &blocks.Range{From: fromBlock, To: toBlock}, nil
+}
+
+// resolveBlockBound resolves a block bound given either as a block number or as a time, which is resolved
+// to a block number with a given function. It returns false if neither query param is given.
+func (h *BlockHandler) resolveBlockBound(
+	ctx context.Context,
+	query url.Values,
+	blockParam, timeParam string,
+	blockAtTime func(ctx context.Context, t time.Time) (int, error),
+) (int, bool, error) {
+	switch {
+	case query.Has(blockParam) && query.Has(timeParam):
+		return -1, false, fmt.Errorf("query params '%s' and '%s' are mutually exclusive", blockParam, timeParam)
+	case query.Has(blockParam):
+		blockNum, err := parseIntParam(query, blockParam)
+
+		return blockNum, true, err
+	case query.Has(timeParam):
+		t, err := parseTimeParam(query, timeParam)
+		if err != nil {
+			return -1, false, err
+		}
+
+		blockNum, err := blockAtTime(ctx, t)
+
+		return blockNum, true, err
+	default:
+		return -1, false, nil
 	}
 }
 
 // GetTransactionsPerSubscriber godoc
 // @Summary Get all transactions for a subscribed address.
-// @Description Get all transactions for a subscribed address.
+// @Description Get all transactions for a subscribed address ordered by block number and transaction index
+// @Description one page at a time. The next page is requested with the nextCursor of the previous page.
+// @Description Internal transfers are filtered by their own sender, recipient and value.
+// @Description The response is an object holding the transactions, the nextCursor and the limit of the page.
+// @Description The limit defaults to 50.
 // @Tags blocks
 // @Accept  json
 // @Produce  json
 // @Param address path string true "Address"
+// @Param fromBlock query int false "From Block"
+// @Param toBlock query int false "To Block"
+// @Param fromTime query string false "From Time"
+// @Param toTime query string false "To Time"
+// @Param direction query string false "Direction" Enums(inbound, outbound)
+// @Param minValue query string false "Minimum value in wei, decimal or 0x prefixed hex"
+// @Param maxValue query string false "Maximum value in wei, decimal or 0x prefixed hex"
+// @Param counterparty query string false "Counterparty Address"
+// @Param cursor query string false "Cursor"
+// @Param limit query int false "Limit" default(50)
 // @Router /api/v1/subscription/{address}/transactions [get]
 func (h *BlockHandler) GetTransactionsPerSubscriber() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
@@ -97,9 +258,75 @@ func (h *BlockHandler) GetTransactionsPerSubscriber() http.HandlerFunc {
 			return
 		}
 
-		txs := h.Parser.GetTransactionsPerSubscriber(address)
+		query := r.URL.Query()
 
-		handleResponse(rw, txs)
+		txQuery, err := parseTransactionQuery(query)
+		if err != nil {
+			badRequestError(rw, err)
+
+			return
+		}
+
+		if txQuery.BlockRange, err = h.resolveBlockWindow(r.Context(), query); err != nil {
+			badRequestError(rw, err)
+
+			return
+		}
+
+		txs, next, err := h.Parser.GetTransactionsPerSubscriber(address, txQuery)
+		if err != nil {
+			internalServerError(
+				rw,
+				r,
+				pkgErrors.Wrapf(err, "could not get observed transactions for address %s", address),
+			)
+
+			return
+		}
+
+		handleResponse(rw, transactionsPage[blocks.ObservedTransaction]{
+			Transactions: txs,
+			NextCursor:   cursorString(next),
+			Limit:        txQuery.Limit,
+		})
+	}
+}
+
+// GetTokenTransfersPerSubscriber godoc
+// @Summary Get all ERC-20 token transfers for a subscribed address.
+// @Description Get all ERC-20 token transfers sent from or to a subscribed address.
+// @Description Amounts are raw hex encoded token units not adjusted by the token decimals.
+// @Tags blocks
+// @Accept  json
+// @Produce  json
+// @Param address path string true "Address"
+// @Router /api/v1/subscription/{address}/token-transfers [get]
+func (h *BlockHandler) GetTokenTransfersPerSubscriber() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		address, ok := vars["address"]
+		if !ok {
+			badRequestError(
+				rw,
+				errors.New("required path param 'address' is missing"),
+			)
+
+			return
+		}
+
+		transfers, err := h.Parser.GetTokenTransfersPerSubscriber(address)
+		if err != nil {
+			internalServerError(
+				rw,
+				r,
+				pkgErrors.Wrapf(err, "could not get token transfers for address %s", address),
+			)
+
+			return
+		}
+
+		handleResponse(rw, transfers)
 	}
 }
 
@@ -163,7 +390,22 @@ func (h *BlockHandler) SubscribeAddress() http.HandlerFunc {
 			return
 		}
 
-		subscribed := h.Parser.Subscribe(reqBody.Address)
+		if _, err := blocks.ParseAddress(reqBody.Address); err != nil {
+			badRequestError(rw, err)
+
+			return
+		}
+
+		subscribed, err := h.Parser.Subscribe(reqBody.Address)
+		if err != nil {
+			internalServerError(
+				rw,
+				r,
+				pkgErrors.Wrapf(err, "could not subscribe address %s", reqBody.Address),
+			)
+
+			return
+		}
 
 		handleResponse(rw, subscribed)
 	}
@@ -179,18 +421,349 @@ func handleResponse(rw http.ResponseWriter, resp any) {
 	}
 }
 
+// GetSubscriptions godoc
+// @Summary List all subscribed addresses.
+// @Description List the subscribed addresses ordered by address one page at a time.
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param offset query int false "Offset" default(0)
+// @Param limit query int false "Limit" default(50)
+// @Router /api/v1/subscriptions [get]
+func (h *BlockHandler) GetSubscriptions() http.HandlerFunc {
+	type response struct {
+		Subscriptions []blocks.Subscription `json:"subscriptions"`
+		Total         int                   `json:"total"`
+		Offset        int                   `json:"offset"`
+		Limit         int                   `json:"limit"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		offset, limit, err := parsePageParams(r.URL.Query())
+		if err != nil {
+			badRequestError(rw, err)
+
+			return
+		}
+
+		subscriptions, total, err := h.Parser.GetSubscriptions(offset, limit)
+		if err != nil {
+			internalServerError(
+				rw,
+				r,
+				pkgErrors.Wrap(err, "could not list subscriptions"),
+			)
+
+			return
+		}
+
+		handleResponse(rw, response{
+			Subscriptions: subscriptions,
+			Total:         total,
+			Offset:        offset,
+			Limit:         limit,
+		})
+	}
+}
+
+// GetSubscription godoc
+// @Summary Get the details of a subscribed address.
+// @Description Get the creation time, the last checked block and the number of observed transactions
+// @Description of a subscribed address.
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param address path string true "Address"
+// @Router /api/v1/subscription/{address} [get]
+func (h *BlockHandler) GetSubscription() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		address, ok := vars["address"]
+		if !ok {
+			badRequestError(
+				rw,
+				errors.New("required path param 'address' is missing"),
+			)
+
+			return
+		}
+
+		subscription, err := h.Parser.GetSubscription(address)
+		if err != nil {
+			internalServerError(
+				rw,
+				r,
+				pkgErrors.Wrapf(err, "could not get subscription for address %s", address),
+			)
+
+			return
+		}
+
+		if subscription == nil {
+			notFoundError(rw, fmt.Errorf("address %s is not subscribed", address))
+
+			return
+		}
+
+		handleResponse(rw, subscription)
+	}
+}
+
+// UnsubscribeAddress godoc
+// @Summary Unsubscribe an address from the observer.
+// @Description Stop observing an address for new transactions and remove its webhooks. Its observed transactions,
+// @Description token transfers and webhook deliveries are kept unless purge is set.
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param address path string true "Address"
+// @Param purge query bool false "Purge observed transactions" default(false)
+// @Router /api/v1/subscription/{address} [delete]
+func (h *BlockHandler) UnsubscribeAddress() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		address, ok := vars["address"]
+		if !ok {
+			badRequestError(
+				rw,
+				errors.New("required path param 'address' is missing"),
+			)
+
+			return
+		}
+
+		purge := false
+
+		if query := r.URL.Query(); query.Has("purge") {
+			var err error
+
+			if purge, err = strconv.ParseBool(query.Get("purge")); err != nil {
+				badRequestError(rw, pkgErrors.Wrap(err, "invalid query param 'purge':"))
+
+				return
+			}
+		}
+
+		unsubscribed, err := h.Parser.Unsubscribe(address, purge)
+		if err != nil {
+			internalServerError(
+				rw,
+				r,
+				pkgErrors.Wrapf(err, "could not unsubscribe address %s", address),
+			)
+
+			return
+		}
+
+		if !unsubscribed {
+			notFoundError(rw, fmt.Errorf("address %s is not subscribed", address))
+
+			return
+		}
+
+		if err = h.Webhooks.RemoveWebhooks(address, purge); err != nil {
+			internalServerError(
+				rw,
+				r,
+				pkgErrors.Wrapf(err, "could not remove webhooks for address %s", address),
+			)
+
+			return
+		}
+
+		handleResponse(rw, unsubscribed)
+	}
+}
+
 func badRequestError(rw http.ResponseWriter, err error) {
+	errorResponse(rw, http.StatusBadRequest, err)
+}
+
+func notFoundError(rw http.ResponseWriter, err error) {
+	errorResponse(rw, http.StatusNotFound, err)
+}
+
+// internalServerError logs the error with the context of the request, e.g. its ID, since it is not the fault
+// of the client.
+func internalServerError(rw http.ResponseWriter, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), "request failed", "error", err)
+
+	errorResponse(rw, http.StatusInternalServerError, err)
+}
+
+func errorResponse(rw http.ResponseWriter, status int, err error) {
 	errBytes, err := json.Marshal(struct {
 		Status int    `json:"status"`
 		Error  string `json:"error"`
 	}{
-		Status: http.StatusBadRequest,
+		Status: status,
 		Error:  err.Error(),
 	})
 
 	if err == nil {
-		http.Error(rw, string(errBytes), http.StatusBadRequest)
+		http.Error(rw, string(errBytes), status)
 	} else {
 		http.Error(rw, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+func parseIntParam(query url.Values, name string) (int, error) {
+	value, err := strconv.Atoi(query.Get(name))
+	if err != nil {
+		return 0, pkgErrors.Wrapf(err, "invalid query param '%s':", name)
+	}
+
+	return value, nil
+}
+
+// parsePageParams parses the offset and limit query params of a paginated list.
+func parsePageParams(query url.Values) (int, int, error) {
+	offset := 0
+
+	var err error
+
+	if query.Has("offset") {
+		if offset, err = parseIntParam(query, "offset"); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if offset < 0 {
+		return 0, 0, errors.New("query param 'offset' must not be negative")
+	}
+
+	limit, err := parseLimitParam(query)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return offset, limit, nil
+}
+
+// parseLimitParam parses the limit query param of a paginated list.
+func parseLimitParam(query url.Values) (int, error) {
+	if !query.Has("limit") {
+		return _defaultPageLimit, nil
+	}
+
+	limit, err := parseIntParam(query, "limit")
+	if err != nil {
+		return 0, err
+	}
+
+	if limit < 1 || limit > _maxPageLimit {
+		return 0, fmt.Errorf("query param 'limit' must be between 1 and %d", _maxPageLimit)
+	}
+
+	return limit, nil
+}
+
+// transactionsPage represents a page of a transaction listing.
+type transactionsPage[T any] struct {
+	Transactions []T `json:"transactions"`
+	// NextCursor is omitted on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+	Limit      int    `json:"limit"`
+}
+
+// parseTransactionQuery parses the cursor, limit and filter query params of a transaction listing.
+// Block bounds are resolved separately, since they may have to be looked up on the node.
+func parseTransactionQuery(query url.Values) (blocks.TransactionQuery, error) {
+	var (
+		txQuery blocks.TransactionQuery
+		err     error
+	)
+
+	if txQuery.Limit, err = parseLimitParam(query); err != nil {
+		return txQuery, err
+	}
+
+	if query.Has("cursor") {
+		cursor, errCursor := blocks.ParseTransactionCursor(query.Get("cursor"))
+		if errCursor != nil {
+			return txQuery, pkgErrors.Wrap(errCursor, "invalid query param 'cursor':")
+		}
+
+		txQuery.After = &cursor
+	}
+
+	switch direction := blocks.Direction(query.Get("direction")); direction {
+	case "", blocks.DirectionInbound, blocks.DirectionOutbound:
+		txQuery.Direction = direction
+	default:
+		return txQuery, fmt.Errorf("invalid query param 'direction': %q", direction)
+	}
+
+	if txQuery.MinValue, err = parseValueParam(query, "minValue"); err != nil {
+		return txQuery, err
+	}
+
+	if txQuery.MaxValue, err = parseValueParam(query, "maxValue"); err != nil {
+		return txQuery, err
+	}
+
+	if query.Has("counterparty") {
+		counterparty, errAddress := blocks.ParseAddress(query.Get("counterparty"))
+		if errAddress != nil {
+			return txQuery, pkgErrors.Wrap(errAddress, "invalid query param 'counterparty':")
+		}
+
+		txQuery.Counterparty = &counterparty
+	}
+
+	return txQuery, nil
+}
+
+// parseValueParam parses an optional wei amount query param given either in decimal or as 0x prefixed hex.
+func parseValueParam(query url.Values, name string) (*blocks.Quantity, error) {
+	if !query.Has(name) {
+		return nil, nil
+	}
+
+	value := query.Get(name)
+
+	if strings.HasPrefix(value, "0x") {
+		q, err := blocks.ParseQuantity(value)
+		if err != nil {
+			return nil, pkgErrors.Wrapf(err, "invalid query param '%s':", name)
+		}
+
+		return &q, nil
+	}
+
+	i, ok := new(big.Int).SetString(value, 10)
+	if !ok || i.Sign() < 0 || strings.HasPrefix(value, "+") {
+		return nil, fmt.Errorf("invalid query param '%s': %q is not a non-negative wei amount", name, value)
+	}
+
+	q := blocks.NewQuantityFromBig(i)
+
+	return &q, nil
+}
+
+// cursorString encodes the cursor of the next page or returns an empty string on the last page.
+func cursorString(cursor *blocks.TransactionCursor) string {
+	if cursor == nil {
+		return ""
+	}
+
+	return cursor.String()
+}
+
+// parseTimeParam parses a time query param given either as an RFC3339 string or as a unix timestamp in seconds.
+func parseTimeParam(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+
+	if unixSeconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unixSeconds, 0).UTC(), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, pkgErrors.Wrapf(err, "invalid query param '%s':", name)
+	}
+
+	return t, nil
+}
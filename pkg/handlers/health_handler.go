@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/sdk"
+)
+
+// HealthHandler represents an HTTP handler for the liveness and readiness probes of the scanner.
+type HealthHandler struct {
+	Health sdk.HealthReporter
+}
+
+// NewHealthHandler initializes a new instance of HealthHandler.
+func NewHealthHandler(health sdk.HealthReporter) *HealthHandler {
+	return &HealthHandler{
+		Health: health,
+	}
+}
+
+// GetLiveness godoc
+// @Summary Check if the scanner is alive.
+// @Description Succeeds as long as the HTTP server is serving requests, regardless of the state of the node.
+// @Tags health
+// @Produce  json
+// @Router /healthz [get]
+func (h *HealthHandler) GetLiveness() http.HandlerFunc {
+	type response struct {
+		Status blocks.CheckStatus `json:"status"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		handleResponse(rw, response{Status: blocks.CheckPassing})
+	}
+}
+
+// GetReadiness godoc
+// @Summary Check if the scanner is ready.
+// @Description Report the state and the last error of every readiness check. Responds with 503 unless all checks
+// @Description pass, i.e. the node is synced, connected to the configured chain and its head is advancing
+// @Description and the block observer has not failed repeatedly.
+// @Tags health
+// @Produce  json
+// @Router /readyz [get]
+func (h *HealthHandler) GetReadiness() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		report := h.Health.Readiness()
+
+		if report.Status != blocks.CheckPassing {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		handleResponse(rw, report)
+	}
+}
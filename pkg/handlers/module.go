@@ -11,10 +11,18 @@ func InitializeHandlers(
 	config *configs.Config,
 	router *mux.Router,
 	parser sdk.Parser,
+	usageReporter sdk.RPCUsageReporter,
+	webhooks sdk.WebhookManager,
+	streamer sdk.TransactionStreamer,
+	health sdk.HealthReporter,
 ) *mux.Router {
-	handler := NewBlockHandler(parser)
+	handler := NewBlockHandler(parser, webhooks)
+	rpcHandler := NewRPCHandler(usageReporter)
+	webhookHandler := NewWebhookHandler(webhooks)
+	streamHandler := NewStreamHandler(parser, streamer)
+	healthHandler := NewHealthHandler(health)
 
-	registerHTTPRoutes(config, router, handler)
+	registerHTTPRoutes(config, router, handler, rpcHandler, webhookHandler, streamHandler, healthHandler)
 
 	return router
 }
@@ -10,7 +10,14 @@ import (
 )
 
 func registerHTTPRoutes(
-	config *configs.Config, muxer *mux.Router, handler *BlockHandler) *mux.Router {
+	config *configs.Config,
+	muxer *mux.Router,
+	handler *BlockHandler,
+	rpcHandler *RPCHandler,
+	webhookHandler *WebhookHandler,
+	streamHandler *StreamHandler,
+	healthHandler *HealthHandler,
+) *mux.Router {
 	muxer.HandleFunc(
 		"/api/v1/block/current",
 		handler.GetCurrentBlock()).Methods("GET")
@@ -20,9 +27,48 @@ func registerHTTPRoutes(
 	muxer.HandleFunc(
 		"/api/v1/subscription/{address}/transactions",
 		handler.GetTransactionsPerSubscriber()).Methods("GET")
+	muxer.HandleFunc(
+		"/api/v1/subscription/{address}/token-transfers",
+		handler.GetTokenTransfersPerSubscriber()).Methods("GET")
 	muxer.HandleFunc(
 		"/api/v1/address/subscribe",
 		handler.SubscribeAddress()).Methods("POST")
+	muxer.HandleFunc(
+		"/api/v1/subscriptions",
+		handler.GetSubscriptions()).Methods("GET")
+	muxer.HandleFunc(
+		"/api/v1/subscription/{address}",
+		handler.GetSubscription()).Methods("GET")
+	muxer.HandleFunc(
+		"/api/v1/subscription/{address}",
+		handler.UnsubscribeAddress()).Methods("DELETE")
+	muxer.HandleFunc(
+		"/api/v1/subscription/{address}/webhooks",
+		webhookHandler.RegisterWebhook()).Methods("POST")
+	muxer.HandleFunc(
+		"/api/v1/subscription/{address}/webhooks",
+		webhookHandler.GetWebhooks()).Methods("GET")
+	muxer.HandleFunc(
+		"/api/v1/subscription/{address}/webhooks/{id}",
+		webhookHandler.RemoveWebhook()).Methods("DELETE")
+	muxer.HandleFunc(
+		"/api/v1/subscription/{address}/deliveries",
+		webhookHandler.GetDeliveries()).Methods("GET")
+	muxer.HandleFunc(
+		"/api/v1/subscription/{address}/stream",
+		streamHandler.StreamTransactionsPerSubscriber()).Methods("GET")
+	muxer.HandleFunc(
+		"/api/v1/subscriptions/stream",
+		streamHandler.StreamTransactions()).Methods("GET")
+	muxer.HandleFunc(
+		"/api/v1/rpc/usage",
+		rpcHandler.GetUsage()).Methods("GET")
+	muxer.HandleFunc(
+		"/healthz",
+		healthHandler.GetLiveness()).Methods("GET")
+	muxer.HandleFunc(
+		"/readyz",
+		healthHandler.GetReadiness()).Methods("GET")
 
 	swaggerJsonURL := fmt.Sprintf("http://%s:%d/swagger/doc.json", config.Host, config.Port)
 
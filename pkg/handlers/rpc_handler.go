@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/sdk"
+)
+
+// RPCHandler represents an HTTP handler for inspecting the usage of the Ethereum node.
+type RPCHandler struct {
+	UsageReporter sdk.RPCUsageReporter
+}
+
+// NewRPCHandler initializes a new instance of RPCHandler.
+func NewRPCHandler(usageReporter sdk.RPCUsageReporter) *RPCHandler {
+	return &RPCHandler{
+		UsageReporter: usageReporter,
+	}
+}
+
+// GetUsage godoc
+// @Summary Get the compute units spent on RPC calls.
+// @Description Get the compute units spent on RPC calls in the current day and month against the configured budget.
+// @Description Usage per method covers the current month.
+// @Description The usage is stored, so with the bolt storage backend the limits still hold after a restart.
+// @Tags rpc
+// @Accept  json
+// @Produce  json
+// @Router /api/v1/rpc/usage [get]
+func (h *RPCHandler) GetUsage() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		handleResponse(rw, h.UsageReporter.Usage())
+	}
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	pkgErrors "github.com/pkg/errors"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/sdk"
+)
+
+const (
+	_streamReplayPageSize = 100
+	_streamKeepAlive      = 15 * time.Second
+)
+
+// StreamHandler represents an HTTP handler streaming observed transactions as Server-Sent Events.
+type StreamHandler struct {
+	Parser   sdk.Parser
+	Streamer sdk.TransactionStreamer
+}
+
+// NewStreamHandler initializes a new instance of StreamHandler.
+func NewStreamHandler(parser sdk.Parser, streamer sdk.TransactionStreamer) *StreamHandler {
+	return &StreamHandler{
+		Parser:   parser,
+		Streamer: streamer,
+	}
+}
+
+// StreamTransactionsPerSubscriber godoc
+// @Summary Stream the transactions observed for a subscribed address.
+// @Description Push every transaction observed for a subscribed address as a Server-Sent Event of type
+// @Description "transaction" with the sequence of the transaction as event ID. A client reconnecting with
+// @Description the Last-Event-ID header first receives all transactions observed since that event.
+// @Description A transaction removed since its block was orphaned by a chain reorganization is pushed as an event
+// @Description of type "retraction" without an event ID. Retractions are only pushed to connected clients.
+// @Description The chain reorganization itself is pushed afterwards as an event of type "reorg" without an event ID,
+// @Description holding the depth, the common ancestor and the orphaned and new block hashes.
+// @Tags streams
+// @Produce  text/event-stream
+// @Param address path string true "Address"
+// @Param Last-Event-ID header int false "Sequence of the last received event"
+// @Router /api/v1/subscription/{address}/stream [get]
+func (h *StreamHandler) StreamTransactionsPerSubscriber() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		address, ok := mux.Vars(r)["address"]
+		if !ok {
+			badRequestError(
+				rw,
+				errors.New("required path param 'address' is missing"),
+			)
+
+			return
+		}
+
+		subscription, err := h.Parser.GetSubscription(address)
+		if err != nil {
+			internalServerError(
+				rw,
+				r,
+				pkgErrors.Wrapf(err, "could not get subscription for address %s", address),
+			)
+
+			return
+		}
+
+		if subscription == nil {
+			notFoundError(rw, fmt.Errorf("address %s is not subscribed", address))
+
+			return
+		}
+
+		h.stream(rw, r, subscription.Address)
+	}
+}
+
+// StreamTransactions godoc
+// @Summary Stream the transactions observed for all subscribed addresses.
+// @Description Push every transaction observed for any subscribed address as a Server-Sent Event of type
+// @Description "transaction" with the sequence of the transaction as event ID. A client reconnecting with
+// @Description the Last-Event-ID header first receives all transactions observed since that event.
+// @Description A transaction removed since its block was orphaned by a chain reorganization is pushed as an event
+// @Description of type "retraction" without an event ID. Retractions are only pushed to connected clients.
+// @Description The chain reorganization itself is pushed afterwards as an event of type "reorg" without an event ID,
+// @Description holding the depth, the common ancestor and the orphaned and new block hashes.
+// @Tags streams
+// @Produce  text/event-stream
+// @Param Last-Event-ID header int false "Sequence of the last received event"
+// @Router /api/v1/subscriptions/stream [get]
+func (h *StreamHandler) StreamTransactions() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		h.stream(rw, r, "")
+	}
+}
+
+// stream replays the stored transactions after the Last-Event-ID of the request and then pushes
+// the published transactions until the client disconnects or falls behind.
+func (h *StreamHandler) stream(rw http.ResponseWriter, r *http.Request, address string) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		internalServerError(rw, r, errors.New("streaming is not supported by the connection"))
+
+		return
+	}
+
+	var lastSequence uint64
+
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		var err error
+
+		if lastSequence, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			badRequestError(rw, pkgErrors.Wrap(err, "invalid header 'Last-Event-ID':"))
+
+			return
+		}
+	}
+
+	// Subscribe before replaying, so that no transaction published in between is missed.
+	// Transactions received both ways are told apart by their sequence.
+	events, cancel := h.Streamer.SubscribeTransactions(address)
+	defer cancel()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		replayed, err := h.Streamer.GetTransactionEventsAfter(address, lastSequence, _streamReplayPageSize)
+		if err != nil {
+			// The stream has been started already, so the error can only be logged.
+			slog.ErrorContext(r.Context(), "could not replay transaction events", "address", address, "error", err)
+
+			return
+		}
+
+		for _, event := range replayed {
+			if err = writeEvent(rw, event); err != nil {
+				return
+			}
+
+			lastSequence = event.Sequence
+		}
+
+		flusher.Flush()
+
+		if len(replayed) < _streamReplayPageSize {
+			break
+		}
+	}
+
+	keepAlive := time.NewTicker(_streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(rw, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event, open := <-events:
+			// A closed channel means the client fell behind, it resumes from the last event after reconnecting.
+			if !open {
+				slog.WarnContext(r.Context(), "event stream fell behind, closing it", "address", address)
+
+				return
+			}
+
+			if event.Retracted || event.Reorg != nil {
+				if err := writeEvent(rw, event); err != nil {
+					return
+				}
+
+				break
+			}
+
+			if event.Sequence <= lastSequence {
+				continue
+			}
+
+			if err := writeEvent(rw, event); err != nil {
+				return
+			}
+
+			lastSequence = event.Sequence
+		}
+
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes a transaction event. Retractions and reorgs are not stored, so they are written without
+// an event ID in order not to move the Last-Event-ID of the client.
+func writeEvent(rw http.ResponseWriter, event blocks.TransactionEvent) error {
+	if event.Reorg != nil {
+		data, err := json.Marshal(event.Reorg)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(rw, "event: reorg\ndata: %s\n\n", data)
+
+		return err
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if event.Retracted {
+		_, err = fmt.Fprintf(rw, "event: retraction\ndata: %s\n\n", data)
+
+		return err
+	}
+
+	_, err = fmt.Fprintf(rw, "id: %d\nevent: transaction\ndata: %s\n\n", event.Sequence, data)
+
+	return err
+}
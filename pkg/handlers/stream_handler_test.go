@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/sdk"
+	"github.com/powerslider/ethereum-block-scanner/pkg/storage/memory"
+)
+
+func TestStreamTransactionsResume(t *testing.T) {
+	const (
+		stored       = 2*_streamReplayPageSize + 10
+		lastEventID  = 20
+		wantReplayed = stored - lastEventID
+	)
+
+	bus := sdk.NewTransactionBus(memory.NewSubscriptionsRepository())
+	addresses := []string{
+		"0x00000000000000000000000000000000000000aa",
+		"0x00000000000000000000000000000000000000bb",
+	}
+
+	for i := 0; i < stored; i++ {
+		if err := bus.PublishObservedTransaction(addresses[i%2], testObservedTransaction(i)); err != nil {
+			t.Fatalf("PublishObservedTransaction() error = %v", err)
+		}
+	}
+
+	server := httptest.NewServer(NewStreamHandler(nil, bus).StreamTransactions())
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequestWithContext() error = %v", err)
+	}
+
+	req.Header.Set("Last-Event-ID", strconv.Itoa(lastEventID))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	ids := make([]int, 0)
+	scanner := bufio.NewScanner(resp.Body)
+
+	for len(ids) < wantReplayed+1 && scanner.Scan() {
+		idLine, found := strings.CutPrefix(scanner.Text(), "id: ")
+		if !found {
+			continue
+		}
+
+		id, errID := strconv.Atoi(idLine)
+		if errID != nil {
+			t.Fatalf("Atoi() error = %v", errID)
+		}
+
+		ids = append(ids, id)
+
+		// Publish a transaction once the stored ones have been replayed, so that it is pushed live.
+		if len(ids) == wantReplayed {
+			if err = bus.PublishObservedTransaction(addresses[0], testObservedTransaction(stored)); err != nil {
+				t.Fatalf("PublishObservedTransaction() error = %v", err)
+			}
+		}
+	}
+
+	if len(ids) != wantReplayed+1 {
+		t.Fatalf("received %d events, want %d: %v", len(ids), wantReplayed+1, scanner.Err())
+	}
+
+	for i, id := range ids {
+		if want := lastEventID + 1 + i; id != want {
+			t.Fatalf("event #%d has ID %d, want %d", i, id, want)
+		}
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	tx := testObservedTransaction(1)
+
+	tests := []struct {
+		name       string
+		event      blocks.TransactionEvent
+		wantPrefix string
+	}{
+		{
+			name:       "transaction",
+			event:      blocks.TransactionEvent{Sequence: 7, Transaction: tx},
+			wantPrefix: "id: 7\nevent: transaction\ndata: {",
+		},
+		{
+			name:       "retraction",
+			event:      blocks.TransactionEvent{Sequence: 7, Transaction: tx, Retracted: true},
+			wantPrefix: "event: retraction\ndata: {",
+		},
+		{
+			name:       "reorg",
+			event:      blocks.TransactionEvent{Reorg: &blocks.ReorgEvent{Depth: 1, CommonAncestor: 9}},
+			wantPrefix: `event: reorg` + "\n" + `data: {"depth":1,"commonAncestor":9,`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+
+			if err := writeEvent(rw, tt.event); err != nil {
+				t.Fatalf("writeEvent() error = %v", err)
+			}
+
+			if got := rw.Body.String(); !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("writeEvent() wrote %q, want it to start with %q", got, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+// testObservedTransaction returns a transaction positioned at the first index of a block derived from a seed.
+func testObservedTransaction(seed int) blocks.ObservedTransaction {
+	var hash blocks.Hash
+
+	copy(hash[:], fmt.Sprintf("tx-%d", seed))
+
+	return blocks.ObservedTransaction{
+		Transaction: blocks.Transaction{
+			Hash:             hash,
+			BlockNumber:      blocks.NewQuantity(seed),
+			TransactionIndex: blocks.NewQuantity(0),
+		},
+		Status: blocks.StatusPendingConfirmation,
+	}
+}
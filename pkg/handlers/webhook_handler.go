@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	pkgErrors "github.com/pkg/errors"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/sdk"
+)
+
+// WebhookHandler represents an HTTP handler for managing the webhooks of subscribed addresses.
+type WebhookHandler struct {
+	Webhooks sdk.WebhookManager
+}
+
+// NewWebhookHandler initializes a new instance of WebhookHandler.
+func NewWebhookHandler(webhooks sdk.WebhookManager) *WebhookHandler {
+	return &WebhookHandler{
+		Webhooks: webhooks,
+	}
+}
+
+// RegisterWebhook godoc
+// @Summary Register a webhook for a subscribed address.
+// @Description Register a URL which every transaction observed for the address is POSTed to as JSON.
+// @Description Payloads are signed with the returned secret, which is shared by all webhooks of the address.
+// @Description The X-Webhook-Signature header holds "sha256=" followed by the hex encoded HMAC-SHA256 of the body.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param address path string true "Address"
+// @Param request body handlers.RegisterWebhook.request true "Webhook"
+// @Router /api/v1/subscription/{address}/webhooks [post]
+func (h *WebhookHandler) RegisterWebhook() http.HandlerFunc {
+	type request struct {
+		URL string `json:"url"`
+	}
+
+	type response struct {
+		Webhook blocks.Webhook `json:"webhook"`
+		Secret  string         `json:"secret"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		address, ok := mux.Vars(r)["address"]
+		if !ok {
+			badRequestError(
+				rw,
+				errors.New("required path param 'address' is missing"),
+			)
+
+			return
+		}
+
+		var reqBody request
+
+		reqBytes, errReqBytes := io.ReadAll(r.Body)
+		errReqUnmarshal := json.Unmarshal(reqBytes, &reqBody)
+
+		errReq := errors.Join(errReqBytes, errReqUnmarshal)
+		if errReq != nil {
+			badRequestError(
+				rw,
+				pkgErrors.Wrap(errReq, "could not unmarshal request params:"),
+			)
+
+			return
+		}
+
+		webhook, secret, err := h.Webhooks.RegisterWebhook(address, reqBody.URL)
+		if err != nil {
+			webhookError(rw, r, pkgErrors.Wrapf(err, "could not register webhook for address %s", address))
+
+			return
+		}
+
+		handleResponse(rw, response{
+			Webhook: webhook,
+			Secret:  secret,
+		})
+	}
+}
+
+// GetWebhooks godoc
+// @Summary List the webhooks of a subscribed address.
+// @Description List all webhooks registered for a subscribed address.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param address path string true "Address"
+// @Router /api/v1/subscription/{address}/webhooks [get]
+func (h *WebhookHandler) GetWebhooks() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		address, ok := mux.Vars(r)["address"]
+		if !ok {
+			badRequestError(
+				rw,
+				errors.New("required path param 'address' is missing"),
+			)
+
+			return
+		}
+
+		webhooks, err := h.Webhooks.GetWebhooks(address)
+		if err != nil {
+			webhookError(rw, r, pkgErrors.Wrapf(err, "could not get webhooks for address %s", address))
+
+			return
+		}
+
+		handleResponse(rw, webhooks)
+	}
+}
+
+// RemoveWebhook godoc
+// @Summary Remove a webhook of a subscribed address.
+// @Description Remove a webhook of a subscribed address. Its pending deliveries are dead-lettered.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param address path string true "Address"
+// @Param id path string true "Webhook ID"
+// @Router /api/v1/subscription/{address}/webhooks/{id} [delete]
+func (h *WebhookHandler) RemoveWebhook() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		address, okAddress := vars["address"]
+		id, okID := vars["id"]
+
+		if !okAddress || !okID {
+			badRequestError(
+				rw,
+				errors.New("required path params 'address' and 'id' are missing"),
+			)
+
+			return
+		}
+
+		removed, err := h.Webhooks.RemoveWebhook(address, id)
+		if err != nil {
+			webhookError(rw, r, pkgErrors.Wrapf(err, "could not remove webhook %s for address %s", id, address))
+
+			return
+		}
+
+		if !removed {
+			notFoundError(rw, fmt.Errorf("webhook %s is not registered for address %s", id, address))
+
+			return
+		}
+
+		handleResponse(rw, removed)
+	}
+}
+
+// GetDeliveries godoc
+// @Summary Get the webhook delivery log of a subscribed address.
+// @Description Get the deliveries of observed transactions to the webhooks of a subscribed address
+// @Description in the order they were enqueued, optionally filtered by status.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param address path string true "Address"
+// @Param status query string false "Delivery Status" Enums(pending, delivered, dead-letter)
+// @Param offset query int false "Offset" default(0)
+// @Param limit query int false "Limit" default(50)
+// @Router /api/v1/subscription/{address}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries() http.HandlerFunc {
+	type response struct {
+		Deliveries []blocks.WebhookDelivery `json:"deliveries"`
+		Total      int                      `json:"total"`
+		Offset     int                      `json:"offset"`
+		Limit      int                      `json:"limit"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		address, ok := mux.Vars(r)["address"]
+		if !ok {
+			badRequestError(
+				rw,
+				errors.New("required path param 'address' is missing"),
+			)
+
+			return
+		}
+
+		query := r.URL.Query()
+
+		offset, limit, err := parsePageParams(query)
+		if err != nil {
+			badRequestError(rw, err)
+
+			return
+		}
+
+		status := blocks.DeliveryStatus(query.Get("status"))
+
+		switch status {
+		case "", blocks.DeliveryPending, blocks.DeliveryDelivered, blocks.DeliveryDeadLetter:
+		default:
+			badRequestError(rw, fmt.Errorf("invalid query param 'status': %q", status))
+
+			return
+		}
+
+		deliveries, total, err := h.Webhooks.GetDeliveries(address, status, offset, limit)
+		if err != nil {
+			webhookError(rw, r, pkgErrors.Wrapf(err, "could not get webhook deliveries for address %s", address))
+
+			return
+		}
+
+		handleResponse(rw, response{
+			Deliveries: deliveries,
+			Total:      total,
+			Offset:     offset,
+			Limit:      limit,
+		})
+	}
+}
+
+// webhookError maps the errors of the webhook manager to HTTP statuses.
+func webhookError(rw http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, sdk.ErrNotSubscribed):
+		notFoundError(rw, err)
+	case errors.Is(err, sdk.ErrInvalidWebhookURL):
+		badRequestError(rw, err)
+	default:
+		internalServerError(rw, r, err)
+	}
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+)
+
+// RequestIDHeader is the HTTP header carrying the ID of a request.
+const RequestIDHeader = "X-Request-ID"
+
+const _maxRequestIDLength = 128
+
+type attrsKey struct{}
+
+type requestIDKey struct{}
+
+// With returns a context whose log records get the given attributes in addition to those of the parent context.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	parent := contextAttrs(ctx)
+
+	// The attributes of the parent are copied, so that sibling contexts do not share them.
+	merged := make([]slog.Attr, 0, len(parent)+len(attrs))
+	merged = append(merged, parent...)
+	merged = append(merged, attrs...)
+
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+// WithRequestID returns a context carrying the ID of a request, which is added to its log records
+// and passed on to the Ethereum node.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = With(ctx, slog.String("request_id", requestID))
+
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the ID of the request a context belongs to or "" if it does not belong to a request.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+
+	return requestID
+}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	b := make([]byte, 16)
+
+	// crypto/rand.Read does not fail on supported platforms.
+	//nolint:errcheck
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// ValidRequestID reports whether a request ID passed by a client can be adopted, i.e. it is not too long
+// and consists of printable ASCII characters only, so that it cannot forge log records or headers.
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > _maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func contextAttrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+
+	return attrs
+}
@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// FormatText writes log records as logfmt key=value pairs.
+	FormatText = "text"
+	// FormatJSON writes log records as JSON objects, one per line.
+	FormatJSON = "json"
+)
+
+// New creates a logger writing records at or above a given level ("debug", "info", "warn" or "error")
+// in a given format ("text" or "json"). The attributes added to a context with With are added to every record
+// logged with that context.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       lvl,
+		ReplaceAttr: replaceErrors,
+	}
+
+	var handler slog.Handler
+
+	switch strings.ToLower(format) {
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q, expected %q or %q", format, FormatText, FormatJSON)
+	}
+
+	return slog.New(contextHandler{Handler: handler}), nil
+}
+
+// replaceErrors logs errors by their message in both formats. The text format would print the stack trace
+// of errors created with github.com/pkg/errors otherwise.
+func replaceErrors(_ []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+
+	if err, ok := a.Value.Any().(error); ok {
+		return slog.String(a.Key, err.Error())
+	}
+
+	return a
+}
+
+// contextHandler adds the attributes carried by the context of a record to it together with the IDs
+// of the trace and the span the record belongs to, if any.
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle implements slog.Handler.
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(contextAttrs(ctx)...)
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler.
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
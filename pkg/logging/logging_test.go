@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	pkgErrors "github.com/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "text",
+			level:  "info",
+			format: FormatText,
+			want:   `level=INFO msg="block processed" block=7 error="node is down" request_id=abc`,
+		},
+		{
+			name:   "json",
+			level:  "INFO",
+			format: FormatJSON,
+			want:   `"level":"INFO","msg":"block processed","block":7,"error":"node is down","request_id":"abc"}`,
+		},
+		{name: "level below the records", level: "warn", format: FormatText},
+		{name: "invalid level", level: "verbose", format: FormatText, wantErr: true},
+		{name: "invalid format", level: "info", format: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			logger, err := New(&out, tt.level, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			ctx := WithRequestID(context.Background(), "abc")
+			logger.InfoContext(ctx, "block processed", "block", 7, "error", pkgErrors.New("node is down"))
+
+			if got := strings.TrimSpace(out.String()); !strings.HasSuffix(got, tt.want) || (tt.want == "") != (got == "") {
+				t.Errorf("logged %q, want a record ending with %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWith(t *testing.T) {
+	parent := With(context.Background(), slog.Int("block", 7))
+	first := With(parent, slog.String("tx", "0x1"))
+	second := With(parent, slog.String("tx", "0x2"))
+
+	for _, tt := range []struct {
+		ctx  context.Context
+		want string
+	}{
+		{ctx: parent, want: "[block=7]"},
+		{ctx: first, want: "[block=7 tx=0x1]"},
+		{ctx: second, want: "[block=7 tx=0x2]"},
+	} {
+		if got := attrsString(contextAttrs(tt.ctx)); got != tt.want {
+			t.Errorf("contextAttrs() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		requestID string
+		want      bool
+	}{
+		{requestID: "0af7651916cd43dd8448eb211c80319c", want: true},
+		{requestID: ""},
+		{requestID: "abc def"},
+		{requestID: "abc\n"},
+		{requestID: strings.Repeat("a", _maxRequestIDLength), want: true},
+		{requestID: strings.Repeat("a", _maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		if got := ValidRequestID(tt.requestID); got != tt.want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v", tt.requestID, got, tt.want)
+		}
+	}
+
+	if requestID := NewRequestID(); !ValidRequestID(requestID) {
+		t.Errorf("NewRequestID() = %q is not valid", requestID)
+	}
+}
+
+func attrsString(attrs []slog.Attr) string {
+	parts := make([]string, len(attrs))
+
+	for i, attr := range attrs {
+		parts[i] = attr.String()
+	}
+
+	return "[" + strings.Join(parts, " ") + "]"
+}
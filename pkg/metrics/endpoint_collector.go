@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/rpcpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EndpointReporter reports the health of the upstream endpoints of an RPC pool.
+type EndpointReporter interface {
+	Endpoints() []rpcpool.EndpointStatus
+}
+
+// endpointCollector reports the health of every upstream endpoint on every scrape.
+type endpointCollector struct {
+	reporter     EndpointReporter
+	healthy      *prometheus.Desc
+	headBlock    *prometheus.Desc
+	latency      *prometheus.Desc
+	errorRate    *prometheus.Desc
+	lagging      *prometheus.Desc
+	failureCount *prometheus.Desc
+}
+
+func newEndpointCollector(reporter EndpointReporter) *endpointCollector {
+	newDesc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(_namespace, "rpc_endpoint", name), help, []string{"endpoint"}, nil)
+	}
+
+	return &endpointCollector{
+		reporter:     reporter,
+		healthy:      newDesc("healthy", "Whether calls are routed to the endpoint."),
+		headBlock:    newDesc("head_block", "Number of the head block last reported by the endpoint."),
+		latency:      newDesc("latency_seconds", "Moving average of the latency of successful calls to the endpoint."),
+		errorRate:    newDesc("error_rate", "Moving average of the share of failed calls to the endpoint."),
+		lagging:      newDesc("lagging", "Whether the head of the endpoint lags too far behind the best head of the pool."),
+		failureCount: newDesc("consecutive_failures", "Number of calls to the endpoint failed in a row."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *endpointCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.healthy
+	ch <- c.headBlock
+	ch <- c.latency
+	ch <- c.errorRate
+	ch <- c.lagging
+	ch <- c.failureCount
+}
+
+// Collect implements prometheus.Collector.
+func (c *endpointCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, status := range c.reporter.Endpoints() {
+		ch <- prometheus.MustNewConstMetric(c.healthy, prometheus.GaugeValue, boolValue(status.Healthy), status.URL)
+		ch <- prometheus.MustNewConstMetric(c.headBlock, prometheus.GaugeValue, float64(status.HeadBlock), status.URL)
+		ch <- prometheus.MustNewConstMetric(c.latency, prometheus.GaugeValue, status.Latency.Seconds(), status.URL)
+		ch <- prometheus.MustNewConstMetric(c.errorRate, prometheus.GaugeValue, status.ErrorRate, status.URL)
+		ch <- prometheus.MustNewConstMetric(c.lagging, prometheus.GaugeValue, boolValue(status.Lagging), status.URL)
+		ch <- prometheus.MustNewConstMetric(
+			c.failureCount, prometheus.GaugeValue, float64(status.ConsecutiveFailures), status.URL)
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
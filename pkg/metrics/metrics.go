@@ -0,0 +1,210 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const _namespace = "ethereum_block_scanner"
+
+// Sizer reports the number of stored entries per kind, e.g. observed transactions or webhook deliveries.
+type Sizer interface {
+	Sizes() (map[string]int, error)
+}
+
+// Metrics holds the Prometheus metrics of the block observer, the JSON-RPC client and the HTTP API.
+//
+// It records the progress of the observer as sdk.ObserverMetrics, the calls to the Ethereum node
+// as jsonrpc.CallObserver and the requests to the API as server.Instrumentation.
+// The sizes of the stores are read on every scrape.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	mu                 sync.Mutex
+	headBlock          int
+	processedBlock     int
+	processedBlockTime time.Time
+
+	blocksProcessed prometheus.Counter
+	matches         prometheus.Counter
+	rpcDuration     *prometheus.HistogramVec
+	rpcErrors       *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+}
+
+// New creates all metrics and registers them in a dedicated registry together with the Go runtime
+// and process metrics.
+func New(sizer Sizer) *Metrics {
+	m := &Metrics{
+		registry:       prometheus.NewRegistry(),
+		headBlock:      -1,
+		processedBlock: -1,
+		blocksProcessed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Subsystem: "observer",
+			Name:      "blocks_processed_total",
+			Help:      "Number of blocks matched against the subscribed addresses.",
+		}),
+		matches: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Subsystem: "observer",
+			Name:      "matches_total",
+			Help:      "Number of transactions, internal transfers and token transfers matched for the subscribed addresses.",
+		}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Subsystem: "rpc",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of JSON-RPC calls to the Ethereum node by method and HTTP status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "status"}),
+		rpcErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Subsystem: "rpc",
+			Name:      "errors_total",
+			Help:      "Number of failed JSON-RPC calls to the Ethereum node by method and HTTP status code.",
+		}, []string{"method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of API requests by route, HTTP method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.blocksProcessed,
+		m.matches,
+		m.rpcDuration,
+		m.rpcErrors,
+		m.httpDuration,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Subsystem: "observer",
+			Name:      "head_block",
+			Help:      "Number of the current head of the chain.",
+		}, func() float64 {
+			head, _, _ := m.progress()
+
+			return float64(head)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Subsystem: "observer",
+			Name:      "processed_block",
+			Help:      "Number of the last processed block.",
+		}, func() float64 {
+			_, processed, _ := m.progress()
+
+			return float64(processed)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Subsystem: "observer",
+			Name:      "head_lag_blocks",
+			Help:      "Number of blocks the last processed block is behind the head of the chain.",
+		}, m.headLagBlocks),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Subsystem: "observer",
+			Name:      "head_lag_seconds",
+			Help:      "Seconds since the timestamp of the last processed block, 0 while the observer is idle.",
+		}, m.headLagSeconds),
+		newStoreCollector(sizer),
+	)
+
+	return m
+}
+
+// WatchEndpoints reports the health of the upstream endpoints of an RPC pool on every scrape.
+func (m *Metrics) WatchEndpoints(reporter EndpointReporter) {
+	m.registry.MustRegister(newEndpointCollector(reporter))
+}
+
+// Handler returns the HTTP handler exposing the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records the duration of an API request by the path template of the matched route.
+func (m *Metrics) ObserveRequest(route, method string, status int, duration time.Duration) {
+	m.httpDuration.WithLabelValues(route, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveCall records the duration of a JSON-RPC call and counts it as failed if it returned an error.
+func (m *Metrics) ObserveCall(method string, statusCode int, duration time.Duration, err error) {
+	status := strconv.Itoa(statusCode)
+
+	m.rpcDuration.WithLabelValues(method, status).Observe(duration.Seconds())
+
+	if err != nil {
+		m.rpcErrors.WithLabelValues(method, status).Inc()
+	}
+}
+
+// ObserveHead records the number of the current head of the chain.
+func (m *Metrics) ObserveHead(blockNum int) {
+	m.mu.Lock()
+	m.headBlock = blockNum
+	m.mu.Unlock()
+}
+
+// ObserveProcessedBlock records a processed block together with the number of transfers matched
+// per subscribed address.
+func (m *Metrics) ObserveProcessedBlock(blockNum int, timestamp time.Time, matches map[string]int) {
+	m.mu.Lock()
+	m.processedBlock = blockNum
+	m.processedBlockTime = timestamp
+	m.mu.Unlock()
+
+	m.blocksProcessed.Inc()
+
+	// The matches are not labeled by address, since the number of subscribed addresses is unbounded.
+	for _, n := range matches {
+		m.matches.Add(float64(n))
+	}
+}
+
+// ObserveSkippedBlocks records that all blocks up to a given one were skipped, because nobody is subscribed.
+func (m *Metrics) ObserveSkippedBlocks(blockNum int) {
+	m.mu.Lock()
+	m.processedBlock = blockNum
+	m.processedBlockTime = time.Time{}
+	m.mu.Unlock()
+}
+
+func (m *Metrics) progress() (int, int, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.headBlock, m.processedBlock, m.processedBlockTime
+}
+
+func (m *Metrics) headLagBlocks() float64 {
+	head, processed, _ := m.progress()
+	if head < 0 || processed < 0 || processed > head {
+		return 0
+	}
+
+	return float64(head - processed)
+}
+
+func (m *Metrics) headLagSeconds() float64 {
+	_, _, processedTime := m.progress()
+
+	// Blocks timestamped ahead of the local clock count as caught up.
+	if processedTime.IsZero() || processedTime.After(time.Now()) {
+		return 0
+	}
+
+	return time.Since(processedTime).Seconds()
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeSizer reports fixed store sizes or an error.
+type fakeSizer struct {
+	sizes map[string]int
+	err   error
+}
+
+func (s fakeSizer) Sizes() (map[string]int, error) {
+	return s.sizes, s.err
+}
+
+func TestHeadLagBlocks(t *testing.T) {
+	tests := []struct {
+		name      string
+		head      int
+		processed int
+		want      float64
+	}{
+		{name: "nothing observed yet", head: -1, processed: -1},
+		{name: "head not observed yet", head: -1, processed: 10},
+		{name: "behind the head", head: 15, processed: 10, want: 5},
+		{name: "caught up", head: 10, processed: 10},
+		{name: "ahead of a stale head", head: 10, processed: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(fakeSizer{})
+
+			m.ObserveHead(tt.head)
+
+			if tt.processed >= 0 {
+				m.ObserveProcessedBlock(tt.processed, time.Now(), nil)
+			}
+
+			if got := m.headLagBlocks(); got != tt.want {
+				t.Errorf("headLagBlocks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeadLagSeconds(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		wantLag   bool
+	}{
+		{name: "processed block in the past", timestamp: time.Now().Add(-time.Minute), wantLag: true},
+		{name: "processed block ahead of the clock", timestamp: time.Now().Add(time.Minute)},
+		{name: "idle observer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(fakeSizer{})
+
+			if tt.timestamp.IsZero() {
+				m.ObserveSkippedBlocks(10)
+			} else {
+				m.ObserveProcessedBlock(10, tt.timestamp, nil)
+			}
+
+			if got := m.headLagSeconds(); (got > 0) != tt.wantLag {
+				t.Errorf("headLagSeconds() = %v, want a lag %v", got, tt.wantLag)
+			}
+		})
+	}
+}
+
+func TestObserveCall(t *testing.T) {
+	m := New(fakeSizer{})
+
+	m.ObserveCall("eth_blockNumber", http.StatusOK, time.Millisecond, nil)
+	m.ObserveCall("eth_blockNumber", http.StatusTooManyRequests, time.Millisecond, errors.New("rate limited"))
+	m.ObserveCall("eth_blockNumber", http.StatusTooManyRequests, time.Millisecond, errors.New("rate limited"))
+
+	if got := testutil.CollectAndCount(m.rpcDuration); got != 2 {
+		t.Errorf("recorded durations for %d status codes, want 2", got)
+	}
+
+	if got := testutil.ToFloat64(m.rpcErrors.WithLabelValues("eth_blockNumber", "429")); got != 2 {
+		t.Errorf("counted %v errors, want 2", got)
+	}
+}
+
+func TestHandlerReportsStoreSizes(t *testing.T) {
+	tests := []struct {
+		name     string
+		sizer    fakeSizer
+		wantCode int
+		wantLine string
+	}{
+		{
+			name:     "sizes",
+			sizer:    fakeSizer{sizes: map[string]int{"deliveries": 3}},
+			wantCode: http.StatusOK,
+			wantLine: `ethereum_block_scanner_store_entries{kind="deliveries"} 3`,
+		},
+		{
+			name:     "store fails",
+			sizer:    fakeSizer{err: errors.New("store is closed")},
+			wantCode: http.StatusInternalServerError,
+			wantLine: "store is closed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			New(tt.sizer).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+
+			if !strings.Contains(rec.Body.String(), tt.wantLine) {
+				t.Errorf("body does not contain %q:\n%s", tt.wantLine, rec.Body.String())
+			}
+		})
+	}
+}
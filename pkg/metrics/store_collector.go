@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// storeCollector reports the number of stored entries per kind on every scrape.
+type storeCollector struct {
+	sizer Sizer
+	desc  *prometheus.Desc
+}
+
+func newStoreCollector(sizer Sizer) *storeCollector {
+	return &storeCollector{
+		sizer: sizer,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(_namespace, "store", "entries"),
+			"Number of stored entries per kind, e.g. observed transactions or webhook deliveries.",
+			[]string{"kind"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	sizes, err := c.sizer.Sizes()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+
+		return
+	}
+
+	for kind, n := range sizes {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), kind)
+	}
+}
@@ -1,22 +1,21 @@
 package numbers
 
 import (
-	"fmt"
-	"strconv"
-	"strings"
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
 )
 
-// HexToInt parse hex string value to int
+// HexToInt parse hex quantity to int, values which overflow an int are rejected.
+// Wei values and other unbounded quantities should be decoded into blocks.Quantity instead.
 func HexToInt(value string) (int, error) {
-	i, err := strconv.ParseInt(strings.TrimPrefix(value, "0x"), 16, 64)
+	q, err := blocks.ParseQuantity(value)
 	if err != nil {
 		return 0, err
 	}
 
-	return int(i), nil
+	return q.Int()
 }
 
-// IntToHex convert int to hexadecimal representation
+// IntToHex convert int to hexadecimal quantity representation
 func IntToHex(i int) string {
-	return fmt.Sprintf("0x%x", i)
+	return blocks.NewQuantity(i).Hex()
 }
@@ -2,56 +2,660 @@ package sdk
 
 import (
 	"context"
-	"strings"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
 	"time"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/logging"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
 )
 
+const _pollInterval = 5 * time.Second
+
 // BlockObserver implements SDK operations on the Ethereum blockchain.
 type BlockObserver struct {
 	BlockParser Parser
 	SubsStore   SubscriptionsStore
+	TxStore     TransactionHistoryStore
+	Publisher   TransactionPublisher
+	config      *observerConfig
+	window      *blockWindow
+	reorgCh     chan blocks.ReorgEvent
+	heads       <-chan json.RawMessage
 }
 
 // NewBlockObserver is a constructor function for BlockObserver.
 func NewBlockObserver(
 	blockParser Parser,
 	subsStore SubscriptionsStore,
+	txStore TransactionHistoryStore,
+	publisher TransactionPublisher,
+	opts ...ObserverOption,
 ) *BlockObserver {
+	config := newObserverDefaultConfig()
+
+	config.applyOptions(opts...)
+
 	return &BlockObserver{
 		BlockParser: blockParser,
 		SubsStore:   subsStore,
+		TxStore:     txStore,
+		Publisher:   publisher,
+		config:      config,
+		window:      newBlockWindow(_reorgWindowSize),
+		reorgCh:     make(chan blocks.ReorgEvent, _reorgEventsChannelSize),
+	}
+}
+
+// ReorgEvents returns a channel of chain reorganizations detected by the observer.
+// Events are dropped if the channel is not drained fast enough.
+func (p *BlockObserver) ReorgEvents() <-chan blocks.ReorgEvent {
+	return p.reorgCh
+}
+
+// ListenForNewTransactions implements polling for new blocks and matching if the subscribed addresses
+// have inbound or outbound transactions contained in them. Every block between the processed block cursor
+// and the current head is visited exactly once, so no block is skipped between two polls.
+// If a heads subscriber is configured, blocks are processed as soon as a new head is pushed
+// and polling is only used while the subscriber is not connected.
+//
+// It returns nil when the context is canceled. A block being processed at that moment is completed first,
+// so that stopping the observer does not abandon a block half way. It returns the error of the first failed
+// iteration, so that the observer is restarted with backoff rather than hammering a failing node. The
+// processed block cursor is stored together with the hashes of the most recently processed blocks, so a restarted
+// observer resumes at the block which failed and still detects a reorg of the blocks processed before.
+func (p *BlockObserver) ListenForNewTransactions(ctx context.Context) error {
+	hashes, err := p.SubsStore.GetProcessedBlockHashes()
+	if err != nil {
+		return fmt.Errorf("could not load processed block hashes: %w", err)
+	}
+
+	p.window.load(hashes)
+
+	if err = p.subscribeNewHeads(ctx); err != nil {
+		slog.WarnContext(ctx, "could not subscribe for new heads, falling back to polling", "error", err)
+	}
+
+	for latestBlockNum := -1; ctx.Err() == nil; latestBlockNum = p.waitForNewHead(ctx) {
+		if latestBlockNum < 0 {
+			err = p.processNewBlocks(ctx)
+		} else {
+			err = p.processBlocksUpTo(ctx, latestBlockNum)
+		}
+
+		if ctx.Err() != nil {
+			// The iteration was interrupted by a shutdown rather than failed.
+			return nil
+		}
+
+		if p.config.iterationReporter != nil {
+			p.config.iterationReporter.ReportIteration(err)
+		}
+
+		if err != nil {
+			return err
+		}
 	}
+
+	return nil
+}
+
+// subscribeNewHeads subscribes for new heads if a heads subscriber is configured. The subscription is kept
+// when the observer is restarted, since the subscriber renews it on every reconnect.
+func (p *BlockObserver) subscribeNewHeads(ctx context.Context) error {
+	if p.config.headsSubscriber == nil || p.heads != nil {
+		return nil
+	}
+
+	heads, err := p.config.headsSubscriber.SubscribeNewHeads(ctx)
+	if err != nil {
+		return fmt.Errorf("could not subscribe for new heads: %w", err)
+	}
+
+	p.heads = heads
+
+	return nil
 }
 
-// ListenForNewTransactions implements polling for the latest block and matching if the subscribed addresses
-// have inbound or outbound transactions contained in it.
-func (p *BlockObserver) ListenForNewTransactions(ctx context.Context, errCh chan error) {
+// waitForNewHead waits until a new head is pushed and returns its block number. While no new heads
+// are pushed, it returns -1 after the poll interval, so that the current head is polled instead.
+// It returns -1 right away when the context is canceled.
+func (p *BlockObserver) waitForNewHead(ctx context.Context) int {
+	ticker := time.NewTicker(_pollInterval)
+	defer ticker.Stop()
+
+	heads := p.heads
+
 	for {
-		addresses := p.SubsStore.GetAllSubscriptions()
-		if len(addresses) == 0 {
-			time.Sleep(5 * time.Second)
+		select {
+		case <-ctx.Done():
+			return -1
+		case head, ok := <-heads:
+			if !ok {
+				heads = nil
+
+				continue
+			}
 
-			continue
+			// Only the most recent of the heads pushed in the meantime is relevant.
+			for drained := false; !drained; {
+				select {
+				case head = <-heads:
+				default:
+					drained = true
+				}
+			}
+
+			var header struct {
+				Number blocks.Quantity `json:"number"`
+			}
+
+			if err := json.Unmarshal(head, &header); err != nil {
+				return -1
+			}
+
+			blockNum, err := header.Number.Int()
+			if err != nil {
+				return -1
+			}
+
+			return blockNum
+		case <-ticker.C:
+			if heads == nil || !p.config.headsSubscriber.Connected() {
+				return -1
+			}
 		}
+	}
+}
+
+// processNewBlocks walks all blocks from the processed block cursor + 1 up to the current head.
+func (p *BlockObserver) processNewBlocks(ctx context.Context) error {
+	latestBlockNum, err := p.BlockParser.GetCurrentBlock(ctx)
+	if err != nil {
+		return err
+	}
+
+	return p.processBlocksUpTo(ctx, latestBlockNum)
+}
+
+// processBlocksUpTo walks all blocks from the processed block cursor + 1 up to a given head.
+// The cursor is advanced after each block, so a failure resumes from the first unprocessed block.
+func (p *BlockObserver) processBlocksUpTo(ctx context.Context, latestBlockNum int) error {
+	ctx = logging.With(ctx, slog.Int("head_block", latestBlockNum))
+
+	if p.config.metrics != nil {
+		p.config.metrics.ObserveHead(latestBlockNum)
+	}
 
-		latestBlockNum, err := p.BlockParser.GetCurrentBlock(ctx)
+	lastProcessedBlockNum, err := p.SubsStore.GetLastProcessedBlockNumber()
+	if err != nil {
+		return err
+	}
+
+	if lastProcessedBlockNum < 0 {
+		// Nothing has been processed yet, so start observing from the current head.
+		lastProcessedBlockNum = latestBlockNum - 1
+	}
+
+	addresses, err := p.SubsStore.GetAllSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	if len(addresses) == 0 {
+		if latestBlockNum <= lastProcessedBlockNum {
+			return nil
+		}
+
+		// There is nobody to match transactions for, so just fast-forward the cursor. The hash of the head
+		// is recorded, so that a reorg of it is still detected once an address is subscribed.
+		latestBlockHash, errHash := p.BlockParser.GetBlockHash(ctx, latestBlockNum)
+		if errHash != nil {
+			return errHash
+		}
+
+		if err = p.SubsStore.UpdateLastProcessedBlock(latestBlockNum, latestBlockHash, _reorgWindowSize); err != nil {
+			return err
+		}
+
+		p.window.add(latestBlockNum, latestBlockHash)
+
+		if p.config.metrics != nil {
+			p.config.metrics.ObserveSkippedBlocks(latestBlockNum)
+		}
+
+		slog.DebugContext(ctx, "no subscribed addresses, skipped blocks")
+
+		return nil
+	}
+
+	// Blocks are processed with a context which is not canceled on shutdown, so that the block being processed
+	// is completed. The remaining blocks are left to the next start.
+	processCtx := context.WithoutCancel(ctx)
+
+	for blockNum := lastProcessedBlockNum + 1; blockNum <= latestBlockNum && ctx.Err() == nil; {
+		blockNum, err = p.processBlock(processCtx, blockNum, addresses)
 		if err != nil {
-			errCh <- err
+			return err
 		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
-		blockTransactions, err := p.BlockParser.GetBlockTransactions(ctx, latestBlockNum)
+	return p.updateConfirmations(ctx, latestBlockNum)
+}
+
+// updateConfirmations advances the confirmation status of the observed transactions which are not finalized yet.
+// Only the pending transactions are loaded, together with the confirmed ones if the "finalized" block is known,
+// and all changed statuses are stored at once.
+func (p *BlockObserver) updateConfirmations(ctx context.Context, latestBlockNum int) error {
+	safeBlockNum, finalizedBlockNum, err := p.getFinalityBlockNumbers(ctx)
+	if err != nil {
+		return err
+	}
+
+	statuses := []blocks.ConfirmationStatus{blocks.StatusPendingConfirmation}
+
+	if finalizedBlockNum >= 0 {
+		statuses = append(statuses, blocks.StatusConfirmed)
+	}
+
+	txs, err := p.SubsStore.GetObservedTransactionsPerStatus(statuses...)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[string][]blocks.ObservedTransaction)
+
+	for a, addressTxs := range txs {
+		for _, tx := range addressTxs {
+			blockNum, errBlockNum := tx.BlockNumber.Int()
+			if errBlockNum != nil {
+				return errBlockNum
+			}
+
+			status := confirmationStatus(
+				blockNum, latestBlockNum, safeBlockNum, finalizedBlockNum, p.config.confirmationDepth)
+			if status == tx.Status {
+				continue
+			}
+
+			tx.Status = status
+			updates[a] = append(updates[a], tx)
+		}
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+
+	return p.SubsStore.UpdateObservedTransactions(updates)
+}
+
+// getFinalityBlockNumbers returns the numbers of the "safe" and "finalized" blocks or -1 if block tags are disabled.
+// If the node does not support the block tags, they are disabled and only the confirmation depth is taken
+// into account. Any other failure is returned, so that the block tags are retried on the next iteration.
+func (p *BlockObserver) getFinalityBlockNumbers(ctx context.Context) (int, int, error) {
+	if !p.config.useFinalityTags {
+		return -1, -1, nil
+	}
+
+	safeBlockNum, errSafe := p.BlockParser.GetBlockNumberByTag(ctx, BlockTagSafe)
+	finalizedBlockNum, errFinalized := p.BlockParser.GetBlockNumberByTag(ctx, BlockTagFinalized)
+
+	err := errors.Join(errSafe, errFinalized)
+	if err != nil {
+		if jsonrpc.IsUnsupportedParams(err) {
+			p.config.useFinalityTags = false
+
+			slog.WarnContext(ctx, "block tags are not supported, falling back to confirmation depth", "error", err)
+
+			return -1, -1, nil
+		}
+
+		return -1, -1, err
+	}
+
+	return safeBlockNum, finalizedBlockNum, nil
+}
+
+// processBlock matches the transactions, internal transfers and token transfers of a single block
+// against the subscribed addresses and returns the number of the next block to be processed.
+// If the block does not extend the previously processed one, the chain is rolled back
+// to the common ancestor first.
+//
+// Everything logged while processing the block is tagged with its number and hash and the calls made for it
+// are traced as children of its span. A failure is returned as a BlockError.
+func (p *BlockObserver) processBlock(
+	ctx context.Context, blockNum int, addresses []string) (next int, err error) {
+	var blockHash blocks.Hash
+
+	defer func() {
 		if err != nil {
-			errCh <- err
+			err = &BlockError{Number: blockNum, Hash: blockHash, Err: err}
 		}
+	}()
 
-		for _, a := range addresses {
-			for _, tx := range blockTransactions {
-				if a == strings.ToLower(tx.To) || a == strings.ToLower(tx.From) {
-					p.SubsStore.InsertObservedTransaction(a, tx)
-				}
+	ctx = logging.With(ctx, slog.Int("block_number", blockNum))
+
+	ctx, span := startSpan(ctx, "BlockObserver.processBlock", _attrBlockNumber.Int(blockNum))
+	defer endSpan(span, &err)
+
+	block, err := p.BlockParser.GetBlock(ctx, blockNum)
+	if err != nil {
+		return blockNum, err
+	}
+
+	blockHash = block.Hash
+	ctx = logging.With(ctx, slog.String("block_hash", block.Hash.Hex()))
+
+	span.SetAttributes(_attrBlockHash.String(block.Hash.Hex()))
+
+	if parentHash, found := p.window.get(blockNum - 1); found && parentHash != block.ParentHash {
+		commonAncestor, errRollback := p.rollback(ctx, blockNum-1, addresses)
+		if errRollback != nil {
+			return blockNum, errRollback
+		}
+
+		return commonAncestor + 1, nil
+	}
+
+	timestamp, err := block.Timestamp.Int()
+	if err != nil {
+		return blockNum, err
+	}
+
+	subscribed := make(map[string]bool, len(addresses))
+	subscribedAddresses := make([]blocks.Address, 0, len(addresses))
+
+	for _, a := range addresses {
+		subscribed[a] = true
+
+		// Addresses subscribed before they were validated may not parse, they can only match by string.
+		if address, errAddress := blocks.ParseAddress(a); errAddress == nil {
+			subscribedAddresses = append(subscribedAddresses, address)
+		}
+	}
+
+	// Everything is fetched before anything is stored, so that a failed block is retried without duplicates.
+	tokenTransfers, err := p.BlockParser.GetTokenTransfers(ctx, block.Hash, subscribedAddresses)
+	if err != nil {
+		return blockNum, err
+	}
+
+	internalTransfers, err := p.BlockParser.GetInternalTransfers(ctx, block)
+	if err != nil {
+		return blockNum, err
+	}
+
+	matchedTxHashes := make(map[blocks.Hash]bool)
+
+	for _, tx := range block.Transactions {
+		if len(matchedAddresses(subscribed, tx.Parties()...)) > 0 {
+			matchedTxHashes[tx.Hash] = true
+		}
+	}
+
+	for _, transfer := range internalTransfers {
+		if len(matchedAddresses(subscribed, transfer.From, transfer.To)) > 0 {
+			matchedTxHashes[transfer.TransactionHash] = true
+		}
+	}
+
+	matchedTxs := make([]blocks.Transaction, 0, len(matchedTxHashes))
+
+	for _, tx := range block.Transactions {
+		if matchedTxHashes[tx.Hash] {
+			matchedTxs = append(matchedTxs, tx)
+		}
+	}
+
+	if err = p.BlockParser.AttachReceipts(ctx, block, matchedTxs); err != nil {
+		return blockNum, err
+	}
+
+	txsByHash := make(map[blocks.Hash]blocks.Transaction, len(matchedTxs))
+	matches := make(map[string]int)
+
+	for _, tx := range matchedTxs {
+		txsByHash[tx.Hash] = tx
+
+		for _, a := range matchedAddresses(subscribed, tx.Parties()...) {
+			err = p.Publisher.PublishObservedTransaction(a, blocks.ObservedTransaction{
+				Transaction: tx,
+				Status:      blocks.StatusPendingConfirmation,
+			})
+			if err != nil {
+				return blockNum, err
+			}
+
+			matches[a]++
+		}
+	}
+
+	for _, transfer := range internalTransfers {
+		for _, a := range matchedAddresses(subscribed, transfer.From, transfer.To) {
+			trace := transfer
+
+			err = p.Publisher.PublishObservedTransaction(a, blocks.ObservedTransaction{
+				Transaction: txsByHash[transfer.TransactionHash],
+				Status:      blocks.StatusPendingConfirmation,
+				Internal:    true,
+				Trace:       &trace,
+			})
+			if err != nil {
+				return blockNum, err
+			}
+
+			matches[a]++
+		}
+	}
+
+	for _, transfer := range tokenTransfers {
+		for _, a := range matchedAddresses(subscribed, transfer.From, transfer.To) {
+			if err = p.SubsStore.InsertTokenTransfer(a, transfer); err != nil {
+				return blockNum, err
 			}
+
+			matches[a]++
 		}
+	}
+
+	for _, a := range addresses {
+		if err = p.SubsStore.UpdateLastCheckedBlockNumberPerAddress(a, blockNum); err != nil {
+			return blockNum, err
+		}
+	}
+
+	if err = p.SubsStore.UpdateLastProcessedBlock(blockNum, block.Hash, _reorgWindowSize); err != nil {
+		return blockNum, err
+	}
+
+	p.window.add(blockNum, block.Hash)
 
-		time.Sleep(5 * time.Second)
+	if p.config.metrics != nil {
+		p.config.metrics.ObserveProcessedBlock(blockNum, time.Unix(int64(timestamp), 0), matches)
 	}
+
+	for a, n := range matches {
+		slog.InfoContext(ctx, "observed transfers for subscribed address", "address", a, "transfers", n)
+	}
+
+	slog.DebugContext(ctx, "processed block", "transactions", len(block.Transactions))
+
+	return blockNum + 1, nil
+}
+
+// rollback walks back from a given orphaned block until it finds a block whose hash matches the canonical chain,
+// retracts and removes the observed transactions and token transfers of all orphaned blocks, removes the
+// transaction history of the given addresses from the first orphaned block on and moves the cursor back to
+// the common ancestor. If the walk runs out of the window of known block hashes before it finds the common
+// ancestor, nothing is rolled back and ErrReorgTooDeep is returned.
+func (p *BlockObserver) rollback(ctx context.Context, orphanedBlockNum int, addresses []string) (int, error) {
+	var oldHashes, newHashes []blocks.Hash
+
+	oldBlockNums := make([]int, 0)
+
+	commonAncestor := orphanedBlockNum
+
+	for ; ; commonAncestor-- {
+		oldHash, found := p.window.get(commonAncestor)
+		if !found {
+			if len(oldHashes) > 0 {
+				return orphanedBlockNum, fmt.Errorf("%w: block %d is orphaned and block %d is not known",
+					ErrReorgTooDeep, commonAncestor+1, commonAncestor)
+			}
+
+			break
+		}
+
+		canonicalBlock, err := p.BlockParser.GetBlock(ctx, commonAncestor)
+		if err != nil {
+			return orphanedBlockNum, err
+		}
+
+		if oldHash == canonicalBlock.Hash {
+			break
+		}
+
+		oldHashes = append(oldHashes, oldHash)
+		oldBlockNums = append(oldBlockNums, commonAncestor)
+		newHashes = append(newHashes, canonicalBlock.Hash)
+	}
+
+	if len(oldHashes) == 0 {
+		return orphanedBlockNum, fmt.Errorf(
+			"parent hash of block %d does not match the canonical chain", orphanedBlockNum+1)
+	}
+
+	for i, hash := range oldHashes {
+		// The consumers are told about the orphaned transactions before they are removed, so that a retried
+		// rollback retracts them again rather than losing them.
+		if err := p.retractObservedTransactions(oldBlockNums[i], hash, addresses); err != nil {
+			return orphanedBlockNum, err
+		}
+
+		if err := p.SubsStore.RemoveObservedTransactionsPerBlockHash(hash); err != nil {
+			return orphanedBlockNum, err
+		}
+
+		if err := p.SubsStore.RemoveTokenTransfersPerBlockHash(hash); err != nil {
+			return orphanedBlockNum, err
+		}
+	}
+
+	for _, a := range addresses {
+		if err := p.SubsStore.UpdateLastCheckedBlockNumberPerAddress(a, commonAncestor); err != nil {
+			return orphanedBlockNum, err
+		}
+
+		if err := p.TxStore.RemoveTransactionsFromBlock(a, commonAncestor+1); err != nil {
+			return orphanedBlockNum, err
+		}
+	}
+
+	p.window.truncate(commonAncestor)
+
+	commonAncestorHash, _ := p.window.get(commonAncestor)
+
+	err := p.SubsStore.UpdateLastProcessedBlock(commonAncestor, commonAncestorHash, _reorgWindowSize)
+	if err != nil {
+		return orphanedBlockNum, err
+	}
+
+	event := blocks.ReorgEvent{
+		Depth:          len(oldHashes),
+		CommonAncestor: commonAncestor,
+		OldHashes:      reverse(oldHashes),
+		NewHashes:      reverse(newHashes),
+	}
+
+	select {
+	case p.reorgCh <- event:
+	default:
+	}
+
+	return commonAncestor, nil
+}
+
+// retractObservedTransactions publishes the retraction of the transactions observed for the subscribed addresses
+// in an orphaned block.
+func (p *BlockObserver) retractObservedTransactions(blockNum int, blockHash blocks.Hash, addresses []string) error {
+	query := blocks.TransactionQuery{BlockRange: &blocks.Range{From: blockNum, To: blockNum}}
+
+	for _, a := range addresses {
+		txs, _, err := p.SubsStore.QueryObservedTransactionsPerAddress(a, query)
+		if err != nil {
+			return err
+		}
+
+		for _, tx := range txs {
+			if tx.BlockHash != blockHash {
+				continue
+			}
+
+			if err = p.Publisher.RetractObservedTransaction(a, tx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchedAddresses returns the distinct subscribed addresses among the parties of a transfer.
+func matchedAddresses(subscribed map[string]bool, parties ...blocks.Address) []string {
+	matched := make([]string, 0, len(parties))
+
+	for _, party := range parties {
+		address := party.Lower()
+
+		if subscribed[address] && !slices.Contains(matched, address) {
+			matched = append(matched, address)
+		}
+	}
+
+	return matched
+}
+
+// BlockError is returned when the block observer fails to process a block. When logged, it is tagged
+// with the number and the hash of the block.
+type BlockError struct {
+	// Number is the number of the block.
+	Number int
+	// Hash is the hash of the block or the zero hash if the block could not be fetched.
+	Hash blocks.Hash
+	// Err is the cause of the failure.
+	Err error
+}
+
+// Error implements error.
+func (e *BlockError) Error() string {
+	if e.Hash.IsZero() {
+		return fmt.Sprintf("could not process block %d: %v", e.Number, e.Err)
+	}
+
+	return fmt.Sprintf("could not process block %d (%s): %v", e.Number, e.Hash.Hex(), e.Err)
+}
+
+// Unwrap returns the cause of the failure.
+func (e *BlockError) Unwrap() error {
+	return e.Err
+}
+
+// LogValue implements slog.LogValuer.
+func (e *BlockError) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.Int("block_number", e.Number)}
+
+	if !e.Hash.IsZero() {
+		attrs = append(attrs, slog.String("block_hash", e.Hash.Hex()))
+	}
+
+	return slog.GroupValue(append(attrs, slog.String("message", e.Err.Error()))...)
 }
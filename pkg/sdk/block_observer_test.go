@@ -0,0 +1,151 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/numbers"
+	"github.com/powerslider/ethereum-block-scanner/pkg/storage/memory"
+)
+
+const _testAddress = "0x00000000000000000000000000000000000000aa"
+
+func TestProcessNewBlocks(t *testing.T) {
+	tests := []struct {
+		name                  string
+		lastProcessedBlockNum int
+		subscribed            bool
+		heads                 []int
+		wantFetched           string
+		wantObserved          int
+	}{
+		{
+			name:                  "first poll starts at the head",
+			lastProcessedBlockNum: -1,
+			subscribed:            true,
+			heads:                 []int{10},
+			wantFetched:           "[0xa]",
+			wantObserved:          1,
+		},
+		{
+			name:                  "blocks between polls are walked once",
+			lastProcessedBlockNum: 5,
+			subscribed:            true,
+			heads:                 []int{8, 8, 9},
+			wantFetched:           "[0x6 0x7 0x8 0x9]",
+			wantObserved:          4,
+		},
+		{
+			name:                  "cursor is fast-forwarded without subscriptions",
+			lastProcessedBlockNum: 5,
+			heads:                 []int{8},
+			wantFetched:           "[0x8]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subsStore := memory.NewSubscriptionsRepository()
+
+			lastProcessedBlock := testBlock(tt.lastProcessedBlockNum, 1)
+
+			err := subsStore.UpdateLastProcessedBlock(tt.lastProcessedBlockNum, lastProcessedBlock.Hash, _reorgWindowSize)
+			if err != nil {
+				t.Fatalf("UpdateLastProcessedBlock() error = %v", err)
+			}
+
+			if tt.subscribed {
+				if err := subsStore.InsertSubscriberAddress(_testAddress); err != nil {
+					t.Fatalf("InsertSubscriberAddress() error = %v", err)
+				}
+			}
+
+			head := 0
+			fetched := make([]any, 0)
+			client := newFakeRPCClient()
+
+			client.handle("eth_blockNumber", func(params []any) (any, error) {
+				return numbers.IntToHex(head), nil
+			})
+			client.handle("eth_getBlockReceipts", noReceipts)
+			client.handle("eth_getLogs", noLogs)
+			client.handle("eth_getBlockByNumber", func(params []any) (any, error) {
+				fetched = append(fetched, params[0])
+
+				blockNum, err := numbers.HexToInt(fmt.Sprint(params[0]))
+				if err != nil {
+					return nil, err
+				}
+
+				return testBlock(blockNum, 1), nil
+			})
+
+			txStore := memory.NewTransactionsRepository()
+			parser := NewBlockParser(client, txStore, subsStore)
+			observer := NewBlockObserver(parser, subsStore, txStore, NewTransactionBus(subsStore), WithFinalityTags(false))
+
+			for _, head = range tt.heads {
+				if err := observer.processNewBlocks(context.Background()); err != nil {
+					t.Fatalf("processNewBlocks() error = %v", err)
+				}
+			}
+
+			if got := fmt.Sprint(fetched); got != tt.wantFetched {
+				t.Errorf("fetched blocks = %s, want %s", got, tt.wantFetched)
+			}
+
+			observedTxs, err := subsStore.GetObservedTransactionsPerAddress(_testAddress)
+			if err != nil {
+				t.Fatalf("GetObservedTransactionsPerAddress() error = %v", err)
+			}
+
+			if len(observedTxs) != tt.wantObserved {
+				t.Errorf("observed %d transactions, want %d", len(observedTxs), tt.wantObserved)
+			}
+
+			lastProcessedBlockNum, err := subsStore.GetLastProcessedBlockNumber()
+			if err != nil {
+				t.Fatalf("GetLastProcessedBlockNumber() error = %v", err)
+			}
+
+			if want := tt.heads[len(tt.heads)-1]; lastProcessedBlockNum != want {
+				t.Errorf("last processed block = %d, want %d", lastProcessedBlockNum, want)
+			}
+		})
+	}
+}
+
+// testBlock returns a block with a given number of transactions sent to the test address, whose hashes are
+// derived from the block number. The block extends the test block preceding it.
+func testBlock(blockNum, txCount int) *blocks.Block {
+	block := &blocks.Block{
+		Number:     blocks.NewQuantity(blockNum),
+		Hash:       testHash(fmt.Sprintf("block-%d", blockNum)),
+		ParentHash: testHash(fmt.Sprintf("block-%d", blockNum-1)),
+	}
+
+	to, _ := blocks.ParseAddress(_testAddress)
+
+	for i := 0; i < txCount; i++ {
+		block.Transactions = append(block.Transactions, blocks.Transaction{
+			Hash:             testHash(fmt.Sprintf("tx-%d-%d", blockNum, i)),
+			BlockHash:        block.Hash,
+			BlockNumber:      block.Number,
+			TransactionIndex: blocks.NewQuantity(i),
+			To:               &to,
+		})
+	}
+
+	return block
+}
+
+// testHash returns a hash derived from a seed, so that tests can refer to the same hash by its seed.
+func testHash(seed string) blocks.Hash {
+	var hash blocks.Hash
+
+	copy(hash[:], seed)
+
+	return hash
+}
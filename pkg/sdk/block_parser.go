@@ -2,18 +2,28 @@ package sdk
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
 	"github.com/powerslider/ethereum-block-scanner/pkg/numbers"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
 )
 
 // BlockParser implements SDK operations on the Ethereum blockchain.
 type BlockParser struct {
-	EthClient RPCClient
-	TxStore   TransactionHistoryStore
-	SubsStore SubscriptionsStore
+	EthClient         RPCClient
+	TxStore           TransactionHistoryStore
+	SubsStore         SubscriptionsStore
+	config            *parserConfig
+	rangeScanner      *BlockRangeScanner
+	timestampResolver *BlockTimestampResolver
+
+	blockReceiptsUnsupported atomic.Bool
+	tracingUnsupported       atomic.Bool
 }
 
 var _ Parser = (*BlockParser)(nil)
@@ -23,92 +33,347 @@ func NewBlockParser(
 	ethClient RPCClient,
 	txStore TransactionHistoryStore,
 	subsStore SubscriptionsStore,
+	opts ...ParserOption,
 ) *BlockParser {
-	return &BlockParser{
+	config := newParserDefaultConfig()
+
+	config.applyOptions(opts...)
+
+	blockParser := &BlockParser{
 		EthClient: ethClient,
 		TxStore:   txStore,
 		SubsStore: subsStore,
+		config:    config,
 	}
+
+	blockParser.rangeScanner = NewBlockRangeScanner(blockParser, config.historyScanWorkers)
+	blockParser.timestampResolver = NewBlockTimestampResolver(blockParser)
+
+	return blockParser
 }
 
 // GetCurrentBlock implements getting the latest parsed block of transactions.
-func (p *BlockParser) GetCurrentBlock(ctx context.Context) (int, error) {
-	resp, err := p.EthClient.Call(ctx, "eth_blockNumber")
-	if err != nil {
+func (p *BlockParser) GetCurrentBlock(ctx context.Context) (_ int, err error) {
+	ctx, span := startSpan(ctx, "BlockParser.GetCurrentBlock")
+	defer endSpan(span, &err)
+
+	var blockNumber blocks.Quantity
+
+	if err = p.EthClient.CallFor(ctx, &blockNumber, "eth_blockNumber"); err != nil {
+		return -1, err
+	}
+
+	return blockNumber.Int()
+}
+
+// GetChainID returns the ID of the chain the node is connected to.
+func (p *BlockParser) GetChainID(ctx context.Context) (_ int, err error) {
+	ctx, span := startSpan(ctx, "BlockParser.GetChainID")
+	defer endSpan(span, &err)
+
+	var chainID blocks.Quantity
+
+	if err = p.EthClient.CallFor(ctx, &chainID, "eth_chainId"); err != nil {
 		return -1, err
 	}
 
-	hexStr := fmt.Sprintf("%v", resp.Result)
+	return chainID.Int()
+}
+
+// IsSyncing reports if the node is still syncing with the chain. The node replies with false once it is synced
+// and with an object describing the progress otherwise.
+func (p *BlockParser) IsSyncing(ctx context.Context) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "BlockParser.IsSyncing")
+	defer endSpan(span, &err)
+
+	var status json.RawMessage
+
+	if err = p.EthClient.CallFor(ctx, &status, "eth_syncing"); err != nil {
+		return false, err
+	}
+
+	return string(status) != "false", nil
+}
+
+// GetBlockNumberByTag returns the number of the block a given block tag currently refers to.
+func (p *BlockParser) GetBlockNumberByTag(ctx context.Context, tag BlockTag) (_ int, err error) {
+	ctx, span := startSpan(ctx, "BlockParser.GetBlockNumberByTag", _attrBlockTag.String(string(tag)))
+	defer endSpan(span, &err)
 
-	blockNumberInt, err := numbers.HexToInt(hexStr)
+	var header *struct {
+		Number blocks.Quantity `json:"number"`
+	}
+
+	err = p.EthClient.CallFor(ctx, &header, "eth_getBlockByNumber", string(tag), false)
 	if err != nil {
 		return -1, err
 	}
 
-	return blockNumberInt, nil
+	if header == nil {
+		return -1, fmt.Errorf("no block found for block tag %q", tag)
+	}
+
+	return header.Number.Int()
+}
+
+// GetBlockTimestamp returns the time at which a block was produced.
+func (p *BlockParser) GetBlockTimestamp(ctx context.Context, blockNum int) (_ time.Time, err error) {
+	ctx, span := startSpan(ctx, "BlockParser.GetBlockTimestamp", _attrBlockNumber.Int(blockNum))
+	defer endSpan(span, &err)
+
+	var header *struct {
+		Timestamp blocks.Quantity `json:"timestamp"`
+	}
+
+	err = p.EthClient.CallFor(ctx, &header, "eth_getBlockByNumber", numbers.IntToHex(blockNum), false)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if header == nil {
+		return time.Time{}, fmt.Errorf("block %d not found", blockNum)
+	}
+
+	timestamp, err := header.Timestamp.Int()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(int64(timestamp), 0).UTC(), nil
+}
+
+// GetBlockHash returns the hash of a block.
+func (p *BlockParser) GetBlockHash(ctx context.Context, blockNum int) (_ blocks.Hash, err error) {
+	ctx, span := startSpan(ctx, "BlockParser.GetBlockHash", _attrBlockNumber.Int(blockNum))
+	defer endSpan(span, &err)
+
+	var header *struct {
+		Hash blocks.Hash `json:"hash"`
+	}
+
+	err = p.EthClient.CallFor(ctx, &header, "eth_getBlockByNumber", numbers.IntToHex(blockNum), false)
+	if err != nil {
+		return blocks.Hash{}, err
+	}
+
+	if header == nil {
+		return blocks.Hash{}, fmt.Errorf("block %d not found", blockNum)
+	}
+
+	return header.Hash, nil
+}
+
+// GetFirstBlockNumberAtOrAfter returns the number of the first block produced at or after a given time.
+func (p *BlockParser) GetFirstBlockNumberAtOrAfter(ctx context.Context, t time.Time) (_ int, err error) {
+	ctx, span := startSpan(ctx, "BlockParser.GetFirstBlockNumberAtOrAfter",
+		_attrTime.String(t.UTC().Format(time.RFC3339)))
+	defer endSpan(span, &err)
+
+	return p.timestampResolver.FirstBlockAtOrAfter(ctx, t)
+}
+
+// GetLastBlockNumberAtOrBefore returns the number of the last block produced at or before a given time.
+func (p *BlockParser) GetLastBlockNumberAtOrBefore(ctx context.Context, t time.Time) (_ int, err error) {
+	ctx, span := startSpan(ctx, "BlockParser.GetLastBlockNumberAtOrBefore",
+		_attrTime.String(t.UTC().Format(time.RFC3339)))
+	defer endSpan(span, &err)
+
+	return p.timestampResolver.LastBlockAtOrBefore(ctx, t)
 }
 
 // Subscribe implements adding an address to an observer.
-func (p *BlockParser) Subscribe(address string) bool {
-	p.SubsStore.InsertSubscriberAddress(strings.ToLower(address))
+func (p *BlockParser) Subscribe(address string) (bool, error) {
+	err := p.SubsStore.InsertSubscriberAddress(strings.ToLower(address))
+	if err != nil {
+		return false, err
+	}
 
-	return true
+	return true, nil
 }
 
-// GetBlockTransactions returns all transactions contained is a block.
-func (p *BlockParser) GetBlockTransactions(ctx context.Context, blockNum int) ([]blocks.Transaction, error) {
-	var block blocks.Block
+// Unsubscribe stops observing an address and optionally purges its observed transactions and token transfers.
+func (p *BlockParser) Unsubscribe(address string, purge bool) (bool, error) {
+	return p.SubsStore.RemoveSubscriberAddress(strings.ToLower(address), purge)
+}
+
+// GetSubscription returns the details of a subscribed address or nil if the address is not subscribed.
+func (p *BlockParser) GetSubscription(address string) (*blocks.Subscription, error) {
+	return p.SubsStore.GetSubscription(strings.ToLower(address))
+}
+
+// GetSubscriptions returns a page of subscriptions ordered by address together with the total number of them.
+func (p *BlockParser) GetSubscriptions(offset, limit int) ([]blocks.Subscription, int, error) {
+	return p.SubsStore.GetSubscriptions(offset, limit)
+}
+
+// GetBlock returns a block together with all transactions contained in it.
+func (p *BlockParser) GetBlock(ctx context.Context, blockNum int) (_ *blocks.Block, err error) {
+	ctx, span := startSpan(ctx, "BlockParser.GetBlock", _attrBlockNumber.Int(blockNum))
+	defer endSpan(span, &err)
 
-	err := p.EthClient.CallFor(
+	var block *blocks.Block
+
+	err = p.EthClient.CallFor(
 		ctx, &block, "eth_getBlockByNumber", numbers.IntToHex(blockNum), true)
 	if err != nil {
 		return nil, err
 	}
 
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", blockNum)
+	}
+
+	return block, nil
+}
+
+// GetBlockTransactions returns all transactions contained is a block.
+func (p *BlockParser) GetBlockTransactions(ctx context.Context, blockNum int) ([]blocks.Transaction, error) {
+	block, err := p.GetBlock(ctx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+
 	return block.Transactions, nil
 }
 
 // GetTransactionsForBlockRange implements getting the transaction history for inbound and outbound transactions
-// given an address.
+// given an address for the blocks from latest - blockRange up to the latest one.
 func (p *BlockParser) GetTransactionsForBlockRange(
 	ctx context.Context, address string, blockRange int) ([]blocks.Transaction, error) {
+	if blockRange < 0 {
+		return nil, fmt.Errorf("block range must not be negative, got %d", blockRange)
+	}
+
 	latestBlockNum, err := p.GetCurrentBlock(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	currentBlockRange := latestBlockNum - blockRange
+	fromBlockNum := latestBlockNum - blockRange
+	if fromBlockNum < 0 {
+		fromBlockNum = 0
+	}
 
-	blockTransactions, err := p.GetBlockTransactions(ctx, latestBlockNum)
-	if err != nil {
-		return nil, err
+	txs, _, err := p.GetTransactionsForBlocks(ctx, address, fromBlockNum, latestBlockNum, blocks.TransactionQuery{})
+
+	return txs, err
+}
+
+// GetTransactionsForBlocks implements getting a page of the transaction history for inbound and outbound
+// transactions matching a query given an address for the blocks from fromBlockNum to toBlockNum inclusive.
+// Only the blocks which have not been scanned for the address yet are fetched, so that repeated calls do not
+// store duplicates. The scan is a low priority backfill, so it is paused once the RPC compute unit budget
+// is exhausted.
+func (p *BlockParser) GetTransactionsForBlocks(
+	ctx context.Context, address string, fromBlockNum, toBlockNum int, query blocks.TransactionQuery,
+) (_ []blocks.Transaction, _ *blocks.TransactionCursor, err error) {
+	ctx, span := startSpan(ctx, "BlockParser.GetTransactionsForBlocks",
+		_attrAddress.String(address), _attrFromBlock.Int(fromBlockNum), _attrToBlock.Int(toBlockNum))
+	defer endSpan(span, &err)
+
+	blockRange := blocks.Range{From: fromBlockNum, To: toBlockNum}
+
+	switch {
+	case fromBlockNum < 0 || toBlockNum < fromBlockNum:
+		return nil, nil, fmt.Errorf("invalid block range from %d to %d", fromBlockNum, toBlockNum)
+	case p.config.maxBlockRange > 0 && blockRange.Len() > p.config.maxBlockRange:
+		return nil, nil, fmt.Errorf(
+			"block range of %d blocks exceeds the limit of %d blocks", blockRange.Len(), p.config.maxBlockRange)
 	}
 
 	address = strings.ToLower(address)
-	lastStoredBlockNum := p.TxStore.GetLatestBlockNumberPerAddress(address)
 
-	if lastStoredBlockNum > 0 {
-		currentBlockRange = lastStoredBlockNum
+	scannedRanges, err := p.TxStore.GetScannedBlockRangesPerAddress(address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The blocks following the cursor of a page have been scanned already while fetching the first page.
+	scanRange := blockRange
+	if query.After != nil && query.After.BlockNumber > scanRange.From {
+		scanRange.From = query.After.BlockNumber
 	}
 
-	for i := latestBlockNum; i >= currentBlockRange; i-- {
-		for _, tx := range blockTransactions {
-			//log.Println(tx.To)
-			//log.Println(tx.From)
-			if address == strings.ToLower(tx.To) {
-				p.TxStore.Insert(address, latestBlockNum, tx, true)
-			} else if address == strings.ToLower(tx.From) {
-				p.TxStore.Insert(address, latestBlockNum, tx, false)
-			}
+	ctx = jsonrpc.WithLowPriority(ctx)
+
+	for _, missingRange := range blocks.SubtractRanges(scanRange, scannedRanges) {
+		err = p.rangeScanner.Scan(ctx, missingRange.From, missingRange.To, func(block *blocks.Block) error {
+			return p.storeAddressTransactions(ctx, address, missingRange.From, block)
+		})
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
-	return p.TxStore.GetAllTransactionsPerAddress(address), nil
+	query.BlockRange = &blockRange
+
+	return p.TxStore.QueryTransactionsPerAddress(address, query)
 }
 
-// GetTransactionsPerSubscriber implements listing of observed transactions given a registered subscriber address.
-func (p *BlockParser) GetTransactionsPerSubscriber(address string) []blocks.Transaction {
+// storeAddressTransactions stores the inbound and outbound transactions of an address contained in a block
+// and marks all blocks from the start of the scan up to this one as scanned for the address.
+// Blocks are handled in ascending order, so a failed scan can be resumed after the last stored block.
+func (p *BlockParser) storeAddressTransactions(
+	ctx context.Context, address string, scanFromBlockNum int, block *blocks.Block) error {
+	blockNum, err := block.Number.Int()
+	if err != nil {
+		return err
+	}
+
+	addressTxs := make([]blocks.Transaction, 0)
+
+	for _, tx := range block.Transactions {
+		if isRecipient(tx, address) || tx.From.Lower() == address {
+			addressTxs = append(addressTxs, tx)
+		}
+	}
+
+	if err = p.AttachReceipts(ctx, block, addressTxs); err != nil {
+		return err
+	}
+
+	for _, tx := range addressTxs {
+		if err = p.TxStore.Insert(address, blockNum, tx, isRecipient(tx, address)); err != nil {
+			return err
+		}
+	}
+
+	return p.TxStore.InsertScannedBlockRange(address, blocks.Range{From: scanFromBlockNum, To: blockNum})
+}
+
+// isRecipient reports if a transaction was sent to a given lowercase address.
+func isRecipient(tx blocks.Transaction, address string) bool {
+	return tx.To != nil && tx.To.Lower() == address
+}
+
+// GetTransactionsPerSubscriber implements listing a page of the observed transactions matching a query
+// given a registered subscriber address. The confirmation count of each transaction is calculated against
+// the last block processed by the observer.
+func (p *BlockParser) GetTransactionsPerSubscriber(
+	address string, query blocks.TransactionQuery) ([]blocks.ObservedTransaction, *blocks.TransactionCursor, error) {
 	address = strings.ToLower(address)
 
-	return p.SubsStore.GetObservedTransactionsPerAddress(address)
+	lastProcessedBlockNum, err := p.SubsStore.GetLastProcessedBlockNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txs, next, err := p.SubsStore.QueryObservedTransactionsPerAddress(address, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	observedTxs := make([]blocks.ObservedTransaction, len(txs))
+
+	for i, tx := range txs {
+		blockNum, errBlockNum := tx.BlockNumber.Int()
+		if errBlockNum != nil {
+			blockNum = -1
+		}
+
+		tx.Confirmations = confirmationCount(blockNum, lastProcessedBlockNum)
+		observedTxs[i] = tx
+	}
+
+	return observedTxs, next, nil
 }
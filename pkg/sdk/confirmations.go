@@ -0,0 +1,40 @@
+package sdk
+
+import "github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+
+// BlockTag represents a named block parameter accepted by eth_getBlockByNumber.
+type BlockTag string
+
+const (
+	// BlockTagLatest refers to the most recent block proposed on the chain.
+	BlockTagLatest BlockTag = "latest"
+	// BlockTagSafe refers to the most recent block that is unlikely to be reorganized.
+	BlockTagSafe BlockTag = "safe"
+	// BlockTagFinalized refers to the most recent block accepted as canonical by the consensus layer.
+	BlockTagFinalized BlockTag = "finalized"
+)
+
+// confirmationCount returns the number of blocks on top of and including a given block.
+func confirmationCount(blockNum, latestBlockNum int) int {
+	if blockNum < 0 || latestBlockNum < blockNum {
+		return 0
+	}
+
+	return latestBlockNum - blockNum + 1
+}
+
+// confirmationStatus derives the status of a transaction contained in a given block.
+// A negative safe or finalized block number means that the respective block tag is not available.
+func confirmationStatus(
+	blockNum, latestBlockNum, safeBlockNum, finalizedBlockNum, confirmationDepth int) blocks.ConfirmationStatus {
+	switch {
+	case finalizedBlockNum >= 0 && blockNum <= finalizedBlockNum:
+		return blocks.StatusFinalized
+	case safeBlockNum >= 0 && blockNum <= safeBlockNum:
+		return blocks.StatusConfirmed
+	case confirmationCount(blockNum, latestBlockNum) >= confirmationDepth:
+		return blocks.StatusConfirmed
+	default:
+		return blocks.StatusPendingConfirmation
+	}
+}
@@ -0,0 +1,181 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/storage/memory"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+func TestConfirmationStatus(t *testing.T) {
+	tests := []struct {
+		name              string
+		blockNum          int
+		safeBlockNum      int
+		finalizedBlockNum int
+		want              blocks.ConfirmationStatus
+	}{
+		{name: "finalized block", blockNum: 10, safeBlockNum: 16, finalizedBlockNum: 12, want: blocks.StatusFinalized},
+		{name: "safe block", blockNum: 15, safeBlockNum: 16, finalizedBlockNum: 12, want: blocks.StatusConfirmed},
+		{name: "confirmation depth", blockNum: 18, safeBlockNum: 16, finalizedBlockNum: 12, want: blocks.StatusConfirmed},
+		{
+			name:              "below confirmation depth",
+			blockNum:          19,
+			safeBlockNum:      16,
+			finalizedBlockNum: 12,
+			want:              blocks.StatusPendingConfirmation,
+		},
+		{name: "no block tags", blockNum: 10, safeBlockNum: -1, finalizedBlockNum: -1, want: blocks.StatusConfirmed},
+		{
+			name:              "no block tags below confirmation depth",
+			blockNum:          19,
+			safeBlockNum:      -1,
+			finalizedBlockNum: -1,
+			want:              blocks.StatusPendingConfirmation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := confirmationStatus(tt.blockNum, 20, tt.safeBlockNum, tt.finalizedBlockNum, 3)
+			if got != tt.want {
+				t.Errorf("confirmationStatus() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateConfirmations(t *testing.T) {
+	stored := []blocks.ObservedTransaction{
+		testObservedTransactionWithStatus(5, blocks.StatusFinalized),
+		testObservedTransactionWithStatus(10, blocks.StatusPendingConfirmation),
+		testObservedTransactionWithStatus(11, blocks.StatusConfirmed),
+		testObservedTransactionWithStatus(15, blocks.StatusPendingConfirmation),
+		testObservedTransactionWithStatus(18, blocks.StatusPendingConfirmation),
+		testObservedTransactionWithStatus(19, blocks.StatusPendingConfirmation),
+	}
+
+	byDepth := []blocks.ConfirmationStatus{
+		blocks.StatusFinalized,
+		blocks.StatusConfirmed,
+		blocks.StatusConfirmed,
+		blocks.StatusConfirmed,
+		blocks.StatusConfirmed,
+		blocks.StatusPendingConfirmation,
+	}
+
+	tests := []struct {
+		name            string
+		useFinalityTags bool
+		tagErr          error
+		wantErr         bool
+		wantStatuses    []blocks.ConfirmationStatus
+		wantTags        bool
+	}{
+		{
+			name:            "block tags",
+			useFinalityTags: true,
+			wantStatuses: []blocks.ConfirmationStatus{
+				blocks.StatusFinalized,
+				blocks.StatusFinalized,
+				blocks.StatusFinalized,
+				blocks.StatusConfirmed,
+				blocks.StatusConfirmed,
+				blocks.StatusPendingConfirmation,
+			},
+			wantTags: true,
+		},
+		{
+			name:         "block tags disabled",
+			wantStatuses: byDepth,
+		},
+		{
+			name:            "unsupported block tags fall back to confirmation depth",
+			useFinalityTags: true,
+			tagErr:          &jsonrpc.RPCError{Code: jsonrpc.InvalidParamsCode, Message: "invalid block tag"},
+			wantStatuses:    byDepth,
+		},
+		{
+			name:            "rate limit keeps block tags enabled",
+			useFinalityTags: true,
+			tagErr:          &jsonrpc.RPCError{Code: 429, Message: "too many requests"},
+			wantErr:         true,
+			wantStatuses: []blocks.ConfirmationStatus{
+				blocks.StatusFinalized,
+				blocks.StatusPendingConfirmation,
+				blocks.StatusConfirmed,
+				blocks.StatusPendingConfirmation,
+				blocks.StatusPendingConfirmation,
+				blocks.StatusPendingConfirmation,
+			},
+			wantTags: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subsStore := memory.NewSubscriptionsRepository()
+
+			for _, tx := range stored {
+				if _, _, err := subsStore.InsertObservedTransaction(_testAddress, tx); err != nil {
+					t.Fatalf("InsertObservedTransaction() error = %v", err)
+				}
+			}
+
+			client := newFakeRPCClient()
+			client.handle("eth_getBlockByNumber", func(params []any) (any, error) {
+				if tt.tagErr != nil {
+					return nil, tt.tagErr
+				}
+
+				numbers := map[any]blocks.Quantity{
+					string(BlockTagSafe):      blocks.NewQuantity(16),
+					string(BlockTagFinalized): blocks.NewQuantity(12),
+				}
+
+				return map[string]any{"number": numbers[params[0]]}, nil
+			})
+
+			parser := NewBlockParser(client, memory.NewTransactionsRepository(), subsStore)
+			observer := NewBlockObserver(parser, subsStore, memory.NewTransactionsRepository(), NewTransactionBus(subsStore),
+				WithConfirmationDepth(3), WithFinalityTags(tt.useFinalityTags))
+
+			err := observer.updateConfirmations(context.Background(), 20)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("updateConfirmations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			txs, err := subsStore.GetObservedTransactionsPerAddress(_testAddress)
+			if err != nil {
+				t.Fatalf("GetObservedTransactionsPerAddress() error = %v", err)
+			}
+
+			for i, tx := range txs {
+				if tx.Status != tt.wantStatuses[i] {
+					t.Errorf("transaction in block %s has status %s, want %s", tx.BlockNumber, tx.Status, tt.wantStatuses[i])
+				}
+			}
+
+			if observer.config.useFinalityTags != tt.wantTags {
+				t.Errorf("useFinalityTags = %v, want %v", observer.config.useFinalityTags, tt.wantTags)
+			}
+		})
+	}
+}
+
+// testObservedTransactionWithStatus returns the first transaction of a block observed with a given status.
+func testObservedTransactionWithStatus(blockNum int, status blocks.ConfirmationStatus) blocks.ObservedTransaction {
+	tx := testObservedTransaction(blockNum, 0)
+	tx.Status = status
+
+	return tx
+}
+
+// testObservedTransaction returns the i-th transaction of a test block observed for the test address.
+func testObservedTransaction(blockNum, i int) blocks.ObservedTransaction {
+	tx := testBlock(blockNum, i+1).Transactions[i]
+
+	return blocks.ObservedTransaction{Transaction: tx, Status: blocks.StatusPendingConfirmation}
+}
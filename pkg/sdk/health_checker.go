@@ -0,0 +1,231 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+const (
+	// CheckNodeSync fails while the node reports that it is syncing with the chain.
+	CheckNodeSync = "node_sync"
+	// CheckChainID fails when the node is connected to another chain than the configured one.
+	CheckChainID = "chain_id"
+	// CheckHeadProgress fails when the head of the chain has not advanced for the configured period.
+	CheckHeadProgress = "head_progress"
+	// CheckObserver fails when the iterations of the block observer have failed repeatedly.
+	CheckObserver = "observer"
+)
+
+// _readinessChecks lists the readiness checks in the order they are reported.
+var _readinessChecks = []string{CheckNodeSync, CheckChainID, CheckHeadProgress, CheckObserver}
+
+// HealthChecker tracks the readiness of the scanner, i.e. whether the upstream node is synced, connected
+// to the configured chain and producing new blocks and whether the block observer keeps up with it.
+//
+// The node is checked periodically, the block observer reports the outcome of each of its iterations.
+type HealthChecker struct {
+	BlockParser Parser
+	config      *healthConfig
+
+	mu                  sync.RWMutex
+	checks              map[string]*blocks.HealthCheck
+	head                int
+	headAdvancedAt      time.Time
+	consecutiveFailures int
+}
+
+// NewHealthChecker is a constructor function for HealthChecker.
+func NewHealthChecker(blockParser Parser, opts ...HealthOption) *HealthChecker {
+	config := newHealthDefaultConfig()
+
+	config.applyOptions(opts...)
+
+	checks := make(map[string]*blocks.HealthCheck)
+
+	for _, name := range _readinessChecks {
+		checks[name] = &blocks.HealthCheck{
+			Name:   name,
+			Status: blocks.CheckPending,
+		}
+	}
+
+	return &HealthChecker{
+		BlockParser: blockParser,
+		config:      config,
+		checks:      checks,
+		head:        -1,
+	}
+}
+
+// Run checks the node periodically until the context is canceled. Failed checks are recorded in the
+// readiness report rather than returned, so it always returns nil.
+func (h *HealthChecker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(h.config.interval)
+	defer ticker.Stop()
+
+	for {
+		h.CheckNode(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckNode checks whether the node is synced, connected to the configured chain and its head is advancing.
+func (h *HealthChecker) CheckNode(ctx context.Context) {
+	h.record(CheckNodeSync, h.checkNodeSync(ctx))
+	h.record(CheckChainID, h.checkChainID(ctx))
+	h.record(CheckHeadProgress, h.checkHeadProgress(ctx))
+}
+
+// ReportIteration records the outcome of an iteration of the block observer. The observer check fails once
+// the configured number of iterations in a row have failed and passes again after the next successful one.
+func (h *HealthChecker) ReportIteration(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err == nil {
+		h.consecutiveFailures = 0
+		h.recordLocked(CheckObserver, nil)
+
+		return
+	}
+
+	h.consecutiveFailures++
+
+	if h.consecutiveFailures < h.config.maxObserverFailures {
+		// Keep the check passing, but remember the error until the observer recovers or gives up.
+		check := h.checks[CheckObserver]
+		now := time.Now().UTC()
+
+		check.CheckedAt = &now
+		check.LastError = err.Error()
+		check.LastErrorAt = &now
+
+		return
+	}
+
+	h.recordLocked(CheckObserver, fmt.Errorf("%d consecutive iterations failed: %w", h.consecutiveFailures, err))
+}
+
+// Readiness returns the state of all readiness checks. The report is passing only if all checks are passing.
+func (h *HealthChecker) Readiness() blocks.HealthReport {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	report := blocks.HealthReport{
+		Status: blocks.CheckPassing,
+		Checks: make([]blocks.HealthCheck, 0, len(_readinessChecks)),
+	}
+
+	for _, name := range _readinessChecks {
+		check := *h.checks[name]
+
+		switch {
+		case check.Status == blocks.CheckFailing:
+			report.Status = blocks.CheckFailing
+		case check.Status == blocks.CheckPending && report.Status == blocks.CheckPassing:
+			report.Status = blocks.CheckPending
+		}
+
+		report.Checks = append(report.Checks, check)
+	}
+
+	return report
+}
+
+func (h *HealthChecker) checkNodeSync(ctx context.Context) error {
+	syncing, err := h.BlockParser.IsSyncing(ctx)
+	if err != nil {
+		return err
+	}
+
+	if syncing {
+		return errors.New("node is syncing")
+	}
+
+	return nil
+}
+
+func (h *HealthChecker) checkChainID(ctx context.Context) error {
+	if h.config.chainID <= 0 {
+		return nil
+	}
+
+	chainID, err := h.BlockParser.GetChainID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if chainID != h.config.chainID {
+		return fmt.Errorf("node is connected to chain %d instead of chain %d", chainID, h.config.chainID)
+	}
+
+	return nil
+}
+
+func (h *HealthChecker) checkHeadProgress(ctx context.Context) error {
+	head, err := h.BlockParser.GetCurrentBlock(ctx)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+
+	if head > h.head {
+		h.head = head
+		h.headAdvancedAt = now
+	}
+
+	if stalled := now.Sub(h.headAdvancedAt); stalled > h.config.headStallTimeout {
+		return fmt.Errorf("head %d has not advanced for %s", h.head, stalled.Round(time.Second))
+	}
+
+	return nil
+}
+
+func (h *HealthChecker) record(name string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.recordLocked(name, err)
+}
+
+// recordLocked records the outcome of a run of a check and logs when the check starts or stops failing.
+// The caller must hold the lock.
+func (h *HealthChecker) recordLocked(name string, err error) {
+	check := h.checks[name]
+	now := time.Now().UTC()
+
+	check.CheckedAt = &now
+
+	if err == nil {
+		if check.Status == blocks.CheckFailing {
+			slog.Info("readiness check is passing again", "check", name)
+		}
+
+		check.Status = blocks.CheckPassing
+
+		return
+	}
+
+	if check.Status != blocks.CheckFailing {
+		slog.Warn("readiness check is failing", "check", name, "error", err)
+	}
+
+	check.Status = blocks.CheckFailing
+	check.LastError = err.Error()
+	check.LastErrorAt = &now
+}
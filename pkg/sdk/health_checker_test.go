@@ -0,0 +1,147 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+func TestCheckNode(t *testing.T) {
+	tests := []struct {
+		name       string
+		syncing    any
+		chainID    string
+		stallAfter time.Duration
+		want       map[string]blocks.CheckStatus
+		wantReport blocks.CheckStatus
+	}{
+		{
+			name:       "node is healthy",
+			syncing:    false,
+			chainID:    "0x1",
+			stallAfter: time.Hour,
+			want: map[string]blocks.CheckStatus{
+				CheckNodeSync:     blocks.CheckPassing,
+				CheckChainID:      blocks.CheckPassing,
+				CheckHeadProgress: blocks.CheckPassing,
+				CheckObserver:     blocks.CheckPending,
+			},
+			wantReport: blocks.CheckPending,
+		},
+		{
+			name:       "node is syncing",
+			syncing:    map[string]string{"currentBlock": "0x1", "highestBlock": "0x10"},
+			chainID:    "0x1",
+			stallAfter: time.Hour,
+			want: map[string]blocks.CheckStatus{
+				CheckNodeSync:     blocks.CheckFailing,
+				CheckChainID:      blocks.CheckPassing,
+				CheckHeadProgress: blocks.CheckPassing,
+				CheckObserver:     blocks.CheckPending,
+			},
+			wantReport: blocks.CheckFailing,
+		},
+		{
+			name:       "node is connected to another chain",
+			syncing:    false,
+			chainID:    "0xaa36a7",
+			stallAfter: time.Hour,
+			want: map[string]blocks.CheckStatus{
+				CheckNodeSync:     blocks.CheckPassing,
+				CheckChainID:      blocks.CheckFailing,
+				CheckHeadProgress: blocks.CheckPassing,
+				CheckObserver:     blocks.CheckPending,
+			},
+			wantReport: blocks.CheckFailing,
+		},
+		{
+			name:       "head is stalled",
+			syncing:    false,
+			chainID:    "0x1",
+			stallAfter: time.Nanosecond,
+			want: map[string]blocks.CheckStatus{
+				CheckNodeSync:     blocks.CheckPassing,
+				CheckChainID:      blocks.CheckPassing,
+				CheckHeadProgress: blocks.CheckFailing,
+				CheckObserver:     blocks.CheckPending,
+			},
+			wantReport: blocks.CheckFailing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeRPCClient()
+			client.handle("eth_syncing", func(_ []any) (any, error) {
+				return tt.syncing, nil
+			})
+			client.handle("eth_chainId", func(_ []any) (any, error) {
+				return tt.chainID, nil
+			})
+			client.handle("eth_blockNumber", func(_ []any) (any, error) {
+				return "0x10", nil
+			})
+
+			health := NewHealthChecker(NewBlockParser(client, nil, nil),
+				WithExpectedChainID(1), WithHeadStallTimeout(tt.stallAfter))
+
+			// The head only counts as stalled once it has been seen before.
+			health.CheckNode(context.Background())
+			time.Sleep(time.Millisecond)
+			health.CheckNode(context.Background())
+
+			report := health.Readiness()
+
+			if report.Status != tt.wantReport {
+				t.Errorf("Readiness() status = %s, want %s", report.Status, tt.wantReport)
+			}
+
+			for _, check := range report.Checks {
+				if check.Status != tt.want[check.Name] {
+					t.Errorf("check %s = %s (%s), want %s", check.Name, check.Status, check.LastError, tt.want[check.Name])
+				}
+			}
+		})
+	}
+}
+
+func TestReportIteration(t *testing.T) {
+	health := NewHealthChecker(NewBlockParser(newFakeRPCClient(), nil, nil), WithMaxObserverFailures(2))
+	errIteration := errors.New("node is down")
+
+	for i, tt := range []struct {
+		err           error
+		want          blocks.CheckStatus
+		wantLastError bool
+	}{
+		{err: errIteration, want: blocks.CheckPending, wantLastError: true},
+		{err: errIteration, want: blocks.CheckFailing, wantLastError: true},
+		{want: blocks.CheckPassing, wantLastError: true},
+		{err: errIteration, want: blocks.CheckPassing, wantLastError: true},
+	} {
+		health.ReportIteration(tt.err)
+
+		check := observerCheck(health.Readiness())
+
+		if check.Status != tt.want {
+			t.Errorf("iteration %d: observer check = %s, want %s", i, check.Status, tt.want)
+		}
+
+		if (check.LastError != "") != tt.wantLastError {
+			t.Errorf("iteration %d: last error = %q, want one %v", i, check.LastError, tt.wantLastError)
+		}
+	}
+}
+
+func observerCheck(report blocks.HealthReport) blocks.HealthCheck {
+	for _, check := range report.Checks {
+		if check.Name == CheckObserver {
+			return check
+		}
+	}
+
+	return blocks.HealthCheck{}
+}
@@ -0,0 +1,230 @@
+package sdk
+
+import "time"
+
+const (
+	_defaultConfirmationDepth  = 12
+	_defaultHistoryScanWorkers = 8
+	_defaultMaxBlockRange      = 10000
+
+	_defaultDeliveryMaxAttempts    = 8
+	_defaultDeliveryInitialBackoff = 10 * time.Second
+	_defaultDeliveryMaxBackoff     = time.Hour
+	_defaultDeliveryTimeout        = 10 * time.Second
+	_defaultDeliveryPollInterval   = time.Second
+
+	_defaultHealthCheckInterval = 15 * time.Second
+	_defaultHeadStallTimeout    = 2 * time.Minute
+	_defaultMaxObserverFailures = 3
+)
+
+type observerConfig struct {
+	confirmationDepth int
+	useFinalityTags   bool
+	headsSubscriber   HeadsSubscriber
+	metrics           ObserverMetrics
+	iterationReporter IterationReporter
+}
+
+func newObserverDefaultConfig() *observerConfig {
+	return &observerConfig{
+		confirmationDepth: _defaultConfirmationDepth,
+		useFinalityTags:   true,
+	}
+}
+
+func (o *observerConfig) applyOptions(opts ...ObserverOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// ObserverOption specifies a BlockObserver setting.
+type ObserverOption func(config *observerConfig)
+
+// WithConfirmationDepth specifies the number of blocks on top of and including the block of a transaction
+// required for the transaction to be considered confirmed.
+func WithConfirmationDepth(depth int) ObserverOption {
+	return func(o *observerConfig) {
+		o.confirmationDepth = depth
+	}
+}
+
+// WithFinalityTags specifies if the "safe" and "finalized" block tags should be used
+// to confirm and finalize observed transactions.
+func WithFinalityTags(useFinalityTags bool) ObserverOption {
+	return func(o *observerConfig) {
+		o.useFinalityTags = useFinalityTags
+	}
+}
+
+// WithHeadsSubscriber specifies a client pushing new heads, so that new blocks are processed as soon as
+// they are added to the chain. Polling is used as a fallback while the client is not connected.
+func WithHeadsSubscriber(headsSubscriber HeadsSubscriber) ObserverOption {
+	return func(o *observerConfig) {
+		o.headsSubscriber = headsSubscriber
+	}
+}
+
+// WithIterationReporter specifies an IterationReporter notified about the outcome of every iteration.
+func WithIterationReporter(reporter IterationReporter) ObserverOption {
+	return func(o *observerConfig) {
+		o.iterationReporter = reporter
+	}
+}
+
+// WithObserverMetrics specifies ObserverMetrics recording the head of the chain and every processed block.
+func WithObserverMetrics(metrics ObserverMetrics) ObserverOption {
+	return func(o *observerConfig) {
+		o.metrics = metrics
+	}
+}
+
+type parserConfig struct {
+	historyScanWorkers int
+	maxBlockRange      int
+	tracer             Tracer
+}
+
+func newParserDefaultConfig() *parserConfig {
+	return &parserConfig{
+		historyScanWorkers: _defaultHistoryScanWorkers,
+		maxBlockRange:      _defaultMaxBlockRange,
+		tracer:             TracerNone,
+	}
+}
+
+func (o *parserConfig) applyOptions(opts ...ParserOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// ParserOption specifies a BlockParser setting.
+type ParserOption func(config *parserConfig)
+
+// WithHistoryScanWorkers specifies the number of blocks fetched concurrently while scanning the transaction history.
+func WithHistoryScanWorkers(workers int) ParserOption {
+	return func(o *parserConfig) {
+		o.historyScanWorkers = workers
+	}
+}
+
+// WithMaxBlockRange specifies the maximum number of blocks a single transaction history query may span.
+// A non-positive value disables the limit.
+func WithMaxBlockRange(maxBlockRange int) ParserOption {
+	return func(o *parserConfig) {
+		o.maxBlockRange = maxBlockRange
+	}
+}
+
+// WithTracer specifies the node API used to trace internal calls of transactions for internal transfers.
+func WithTracer(tracer Tracer) ParserOption {
+	return func(o *parserConfig) {
+		o.tracer = tracer
+	}
+}
+
+type webhookConfig struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	timeout        time.Duration
+	pollInterval   time.Duration
+}
+
+func newWebhookDefaultConfig() *webhookConfig {
+	return &webhookConfig{
+		maxAttempts:    _defaultDeliveryMaxAttempts,
+		initialBackoff: _defaultDeliveryInitialBackoff,
+		maxBackoff:     _defaultDeliveryMaxBackoff,
+		timeout:        _defaultDeliveryTimeout,
+		pollInterval:   _defaultDeliveryPollInterval,
+	}
+}
+
+func (o *webhookConfig) applyOptions(opts ...WebhookOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WebhookOption specifies a WebhookDispatcher setting.
+type WebhookOption func(config *webhookConfig)
+
+// WithDeliveryMaxAttempts specifies the number of failed attempts after which a delivery is dead-lettered.
+func WithDeliveryMaxAttempts(maxAttempts int) WebhookOption {
+	return func(o *webhookConfig) {
+		o.maxAttempts = maxAttempts
+	}
+}
+
+// WithDeliveryBackoff specifies the delay before the first retry of a failed delivery, which doubles
+// with every further failure up to a maximum delay.
+func WithDeliveryBackoff(initialBackoff, maxBackoff time.Duration) WebhookOption {
+	return func(o *webhookConfig) {
+		o.initialBackoff = initialBackoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithDeliveryTimeout specifies how long a webhook may take to respond to a single delivery attempt.
+func WithDeliveryTimeout(timeout time.Duration) WebhookOption {
+	return func(o *webhookConfig) {
+		o.timeout = timeout
+	}
+}
+
+type healthConfig struct {
+	interval            time.Duration
+	chainID             int
+	headStallTimeout    time.Duration
+	maxObserverFailures int
+}
+
+func newHealthDefaultConfig() *healthConfig {
+	return &healthConfig{
+		interval:            _defaultHealthCheckInterval,
+		headStallTimeout:    _defaultHeadStallTimeout,
+		maxObserverFailures: _defaultMaxObserverFailures,
+	}
+}
+
+func (o *healthConfig) applyOptions(opts ...HealthOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// HealthOption specifies a HealthChecker setting.
+type HealthOption func(config *healthConfig)
+
+// WithHealthCheckInterval specifies how often the node is checked.
+func WithHealthCheckInterval(interval time.Duration) HealthOption {
+	return func(o *healthConfig) {
+		o.interval = interval
+	}
+}
+
+// WithExpectedChainID specifies the ID of the chain the node must be connected to. A chain ID of 0 accepts any chain.
+func WithExpectedChainID(chainID int) HealthOption {
+	return func(o *healthConfig) {
+		o.chainID = chainID
+	}
+}
+
+// WithHeadStallTimeout specifies how long the head of the chain may stay the same
+// before the node is considered stalled.
+func WithHeadStallTimeout(timeout time.Duration) HealthOption {
+	return func(o *healthConfig) {
+		o.headStallTimeout = timeout
+	}
+}
+
+// WithMaxObserverFailures specifies the number of consecutive failed iterations of the block observer
+// after which the scanner is not ready anymore.
+func WithMaxObserverFailures(maxFailures int) HealthOption {
+	return func(o *healthConfig) {
+		o.maxObserverFailures = maxFailures
+	}
+}
@@ -2,6 +2,8 @@ package sdk
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
 
@@ -13,45 +15,222 @@ type Parser interface {
 	// GetCurrentBlock last parsed block.
 	GetCurrentBlock(ctx context.Context) (int, error)
 
+	// GetChainID returns the ID of the chain the node is connected to.
+	GetChainID(ctx context.Context) (int, error)
+
+	// IsSyncing reports if the node is still syncing with the chain.
+	IsSyncing(ctx context.Context) (bool, error)
+
+	// GetBlockNumberByTag returns the number of the block a given block tag currently refers to.
+	GetBlockNumberByTag(ctx context.Context, tag BlockTag) (int, error)
+
 	// Subscribe adds an address to be observed for new transactions.
-	Subscribe(address string) bool
+	Subscribe(address string) (bool, error)
+
+	// Unsubscribe stops observing an address and optionally purges its observed transactions and token transfers.
+	// It returns false if the address was not subscribed.
+	Unsubscribe(address string, purge bool) (bool, error)
+
+	// GetSubscription returns the details of a subscribed address or nil if the address is not subscribed.
+	GetSubscription(address string) (*blocks.Subscription, error)
+
+	// GetSubscriptions returns a page of subscriptions ordered by address together with the total number of them.
+	GetSubscriptions(offset, limit int) ([]blocks.Subscription, int, error)
+
+	// GetBlock returns a block together with all transactions contained in it.
+	GetBlock(ctx context.Context, blockNum int) (*blocks.Block, error)
 
 	// GetBlockTransactions returns all transactions contained is a block.
 	GetBlockTransactions(ctx context.Context, blockNum int) ([]blocks.Transaction, error)
 
-	// GetTransactionsPerSubscriber lists observed transactions given a registered subscriber address.
-	GetTransactionsPerSubscriber(address string) []blocks.Transaction
+	// GetTransactionReceipts returns the receipts of given transactions contained in a block keyed by transaction hash.
+	GetTransactionReceipts(
+		ctx context.Context, block *blocks.Block, txHashes []blocks.Hash) (map[blocks.Hash]*blocks.Receipt, error)
+
+	// AttachReceipts attaches their receipts to given transactions contained in a block.
+	AttachReceipts(ctx context.Context, block *blocks.Block, txs []blocks.Transaction) error
+
+	// GetTokenTransfers returns the ERC-20 token transfers contained in a block sent from or to any of given addresses.
+	GetTokenTransfers(
+		ctx context.Context, blockHash blocks.Hash, addresses []blocks.Address) ([]blocks.TokenTransfer, error)
+
+	// GetInternalTransfers returns the value transfers made by internal calls of the transactions contained in a block.
+	GetInternalTransfers(ctx context.Context, block *blocks.Block) ([]blocks.InternalTransfer, error)
+
+	// GetTransactionsPerSubscriber lists a page of the observed transactions matching a query given a registered
+	// subscriber address. If further transactions match, the cursor of the page is returned as well.
+	GetTransactionsPerSubscriber(
+		address string, query blocks.TransactionQuery) ([]blocks.ObservedTransaction, *blocks.TransactionCursor, error)
+
+	// GetTokenTransfersPerSubscriber lists observed ERC-20 token transfers given a registered subscriber address.
+	GetTokenTransfersPerSubscriber(address string) ([]blocks.TokenTransfer, error)
 
 	// GetTransactionsForBlockRange lists inbound or outbound transactions for an address for a given block range
 	// from latest to a specified one.
 	GetTransactionsForBlockRange(ctx context.Context, address string, blockRange int) ([]blocks.Transaction, error)
+
+	// GetTransactionsForBlocks lists a page of the inbound or outbound transactions matching a query
+	// for an address contained in the blocks from fromBlockNum to toBlockNum inclusive.
+	// If further transactions match, the cursor of the page is returned as well.
+	GetTransactionsForBlocks(
+		ctx context.Context, address string, fromBlockNum, toBlockNum int, query blocks.TransactionQuery,
+	) ([]blocks.Transaction, *blocks.TransactionCursor, error)
+
+	// GetBlockTimestamp returns the time at which a block was produced.
+	GetBlockTimestamp(ctx context.Context, blockNum int) (time.Time, error)
+
+	// GetBlockHash returns the hash of a block.
+	GetBlockHash(ctx context.Context, blockNum int) (blocks.Hash, error)
+
+	// GetFirstBlockNumberAtOrAfter returns the number of the first block produced at or after a given time.
+	GetFirstBlockNumberAtOrAfter(ctx context.Context, t time.Time) (int, error)
+
+	// GetLastBlockNumberAtOrBefore returns the number of the last block produced at or before a given time.
+	GetLastBlockNumberAtOrBefore(ctx context.Context, t time.Time) (int, error)
 }
 
 // SubscriptionsStore is a port interface for storage operations related to address subscriptions.
 type SubscriptionsStore interface {
 	// GetAllSubscriptions returns all address subscriptions.
-	GetAllSubscriptions() []string
+	GetAllSubscriptions() ([]string, error)
+
+	// InsertObservedTransaction inserts a new transaction that involves a subscribed address and returns it
+	// with its assigned sequence. Transactions are keyed by ID and direction, so inserting a transaction which
+	// is stored already returns the stored one and false.
+	InsertObservedTransaction(
+		address string, tx blocks.ObservedTransaction) (blocks.ObservedTransaction, bool, error)
+
+	// GetTransactionEventsAfter returns up to limit observed transactions with a sequence greater than a given one
+	// in sequence order. An empty address matches the observed transactions of all subscribed addresses.
+	GetTransactionEventsAfter(address string, sequence uint64, limit int) ([]blocks.TransactionEvent, error)
+
+	// GetObservedTransactionsPerStatus returns the observed transactions with any of the given statuses
+	// per subscribed address.
+	GetObservedTransactionsPerStatus(
+		statuses ...blocks.ConfirmationStatus) (map[string][]blocks.ObservedTransaction, error)
 
-	// InsertObservedTransaction inserts a new transaction that involves a subscribed address.
-	InsertObservedTransaction(address string, tx blocks.Transaction)
+	// UpdateObservedTransactions replaces already observed transactions at the same positions per subscribed
+	// address. All transactions are replaced at once.
+	UpdateObservedTransactions(txs map[string][]blocks.ObservedTransaction) error
 
 	// InsertSubscriberAddress inserts a new address as a subscriber to be observed for new transactions.
-	InsertSubscriberAddress(address string)
+	InsertSubscriberAddress(address string) error
+
+	// RemoveSubscriberAddress removes a subscribed address. If purge is set, its observed transactions
+	// and token transfers are removed as well. It returns false if the address was not subscribed.
+	RemoveSubscriberAddress(address string, purge bool) (bool, error)
+
+	// GetSubscription returns the details of a subscribed address or nil if the address is not subscribed.
+	GetSubscription(address string) (*blocks.Subscription, error)
+
+	// GetSubscriptions returns a page of subscriptions ordered by address together with the total number of them.
+	GetSubscriptions(offset, limit int) ([]blocks.Subscription, int, error)
 
 	// GetObservedTransactionsPerAddress returns all observed transactions per subscribed address.
-	GetObservedTransactionsPerAddress(address string) []blocks.Transaction
+	GetObservedTransactionsPerAddress(address string) ([]blocks.ObservedTransaction, error)
+
+	// QueryObservedTransactionsPerAddress returns a page of the observed transactions per subscribed address
+	// matching a query. If further transactions match, the cursor of the page is returned as well.
+	QueryObservedTransactionsPerAddress(
+		address string, query blocks.TransactionQuery) ([]blocks.ObservedTransaction, *blocks.TransactionCursor, error)
+
+	// RemoveObservedTransactionsPerBlockHash removes all observed transactions contained in a given block.
+	// It is used to roll back transactions from blocks orphaned by a chain reorganization.
+	RemoveObservedTransactionsPerBlockHash(blockHash blocks.Hash) error
+
+	// InsertTokenTransfer inserts a new ERC-20 token transfer that involves a subscribed address.
+	// Inserting a token transfer which is stored already is a no-op.
+	InsertTokenTransfer(address string, transfer blocks.TokenTransfer) error
+
+	// GetTokenTransfersPerAddress returns all observed ERC-20 token transfers per subscribed address.
+	GetTokenTransfersPerAddress(address string) ([]blocks.TokenTransfer, error)
+
+	// RemoveTokenTransfersPerBlockHash removes all observed ERC-20 token transfers contained in a given block.
+	// It is used to roll back token transfers from blocks orphaned by a chain reorganization.
+	RemoveTokenTransfersPerBlockHash(blockHash blocks.Hash) error
+
+	// GetLastCheckedBlockNumberPerAddress returns the last checked block number for a given subscribed address.
+	GetLastCheckedBlockNumberPerAddress(address string) (int, error)
+
+	// UpdateLastCheckedBlockNumberPerAddress updates the last checked block number for a given subscribed address.
+	UpdateLastCheckedBlockNumberPerAddress(address string, blockNum int) error
+
+	// GetLastProcessedBlockNumber returns the block number up to which the observer has processed the chain
+	// or -1 if no block has been processed yet.
+	GetLastProcessedBlockNumber() (int, error)
+
+	// UpdateLastProcessedBlock moves the processed block cursor of the observer and records the hash of the block,
+	// unless it is zero. The hashes of the blocks after it and the ones of the blocks which fell out of a window
+	// of the most recent blocks are dropped.
+	UpdateLastProcessedBlock(blockNum int, blockHash blocks.Hash, window int) error
+
+	// GetProcessedBlockHashes returns the recorded hashes of the most recently processed blocks keyed by block number.
+	GetProcessedBlockHashes() (map[int]blocks.Hash, error)
+}
+
+// WebhookStore is a port interface for storage operations related to webhooks and their deliveries.
+type WebhookStore interface {
+	// InsertWebhook registers a new webhook for a subscribed address.
+	InsertWebhook(webhook blocks.Webhook) error
+
+	// GetWebhooksPerAddress returns all webhooks registered for a subscribed address.
+	GetWebhooksPerAddress(address string) ([]blocks.Webhook, error)
+
+	// RemoveWebhook removes a webhook of a subscribed address. It returns false if the webhook does not exist.
+	RemoveWebhook(address, id string) (bool, error)
+
+	// RemoveWebhooksPerAddress removes all webhooks and the signing secret of a subscribed address.
+	// If purge is set, the delivery log of the address is removed as well.
+	RemoveWebhooksPerAddress(address string, purge bool) error
+
+	// GetOrCreateSecret returns the webhook signing secret of a subscribed address.
+	// If the address has no secret yet, the given one is stored and returned.
+	GetOrCreateSecret(address, secret string) (string, error)
+
+	// InsertDelivery stores a new delivery and returns it with its assigned ID. Deliveries are keyed by webhook
+	// and transaction, so inserting a delivery which is stored already returns the stored one and false.
+	InsertDelivery(delivery blocks.WebhookDelivery) (blocks.WebhookDelivery, bool, error)
+
+	// UpdateDelivery replaces a stored delivery with the same ID.
+	UpdateDelivery(delivery blocks.WebhookDelivery) error
+
+	// GetDueDeliveries returns up to limit pending deliveries whose next attempt is due at a given time.
+	GetDueDeliveries(now time.Time, limit int) ([]blocks.WebhookDelivery, error)
+
+	// GetDeliveriesPerAddress returns a page of the delivery log of a subscribed address in delivery order
+	// together with the total number of deliveries. An empty status matches deliveries of any status.
+	GetDeliveriesPerAddress(
+		address string, status blocks.DeliveryStatus, offset, limit int) ([]blocks.WebhookDelivery, int, error)
 }
 
 // TransactionHistoryStore is a port interface for storage operations on transaction history for a given address.
 type TransactionHistoryStore interface {
-	// Insert inserts a new blocks.Transaction entity.
-	Insert(address string, blockNumber int, tx blocks.Transaction, isInbound bool)
+	// Insert inserts a new blocks.Transaction entity. Transactions are keyed by hash and direction,
+	// so inserting a transaction which is stored already is a no-op.
+	Insert(address string, blockNumber int, tx blocks.Transaction, isInbound bool) error
+
+	// GetLatestBlockNumberPerAddress returns the latest block scanned for transactions to/from a given address
+	// or -1 if no block has been scanned yet.
+	GetLatestBlockNumberPerAddress(address string) (int, error)
+
+	// GetScannedBlockRangesPerAddress returns the sorted block ranges already scanned for a given address.
+	GetScannedBlockRangesPerAddress(address string) ([]blocks.Range, error)
 
-	// GetLatestBlockNumberPerAddress returns the latest block containing transactions to/from a given address.
-	GetLatestBlockNumberPerAddress(address string) int
+	// InsertScannedBlockRange marks a block range as scanned for a given address.
+	InsertScannedBlockRange(address string, blockRange blocks.Range) error
 
-	// GetAllTransactionsPerAddress returns all inbound transactions per a given address.
-	GetAllTransactionsPerAddress(address string) []blocks.Transaction
+	// GetAllTransactionsPerAddress returns all inbound and outbound transactions per a given address.
+	GetAllTransactionsPerAddress(address string) ([]blocks.Transaction, error)
+
+	// QueryTransactionsPerAddress returns a page of the inbound and outbound transactions per a given address
+	// matching a query. If further transactions match, the cursor of the page is returned as well.
+	QueryTransactionsPerAddress(
+		address string, query blocks.TransactionQuery) ([]blocks.Transaction, *blocks.TransactionCursor, error)
+
+	// RemoveTransactionsFromBlock removes the transactions of a given address contained in the blocks from a given
+	// one on and marks these blocks as not scanned for the address. It is used to roll back blocks orphaned
+	// by a chain reorganization.
+	RemoveTransactionsFromBlock(address string, blockNum int) error
 }
 
 // RPCClient is a port interface defining JSON-RPC methods.
@@ -61,4 +240,118 @@ type RPCClient interface {
 
 	// CallFor calls a JSON-RPC method and deserializes the response in a specified response object.
 	CallFor(ctx context.Context, out any, method string, params ...any) error
+
+	// CallBatch calls multiple JSON-RPC methods in a single request and returns the responses in request order.
+	CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error)
+
+	// CallBatchFor calls multiple JSON-RPC methods in a single request and deserializes each result
+	// in the response object with the same index.
+	CallBatchFor(ctx context.Context, out []any, requests jsonrpc.RPCRequests) error
+}
+
+// HeadsSubscriber is a port interface for clients pushing the headers of new blocks added to the chain.
+type HeadsSubscriber interface {
+	// Connected reports if new heads are currently being pushed.
+	Connected() bool
+
+	// SubscribeNewHeads subscribes for the headers of new blocks added to the chain.
+	SubscribeNewHeads(ctx context.Context) (<-chan json.RawMessage, error)
+}
+
+// TransactionPublisher is a port interface for publishing the transactions observed for subscribed addresses.
+type TransactionPublisher interface {
+	// PublishObservedTransaction stores a transaction observed for a subscribed address and passes it on
+	// to its consumers.
+	PublishObservedTransaction(address string, tx blocks.ObservedTransaction) error
+
+	// RetractObservedTransaction tells the consumers that a transaction observed for a subscribed address
+	// is about to be removed, since its block was orphaned by a chain reorganization.
+	RetractObservedTransaction(address string, tx blocks.ObservedTransaction) error
+}
+
+// TransactionStreamer is a port interface for streaming the transactions observed for subscribed addresses.
+type TransactionStreamer interface {
+	// SubscribeTransactions returns a channel of the transactions published for an address from now on
+	// together with a function canceling the subscription. An empty address subscribes for the transactions
+	// of all subscribed addresses. The channel is closed when the subscription is canceled or falls behind.
+	SubscribeTransactions(address string) (<-chan blocks.TransactionEvent, func())
+
+	// GetTransactionEventsAfter returns up to limit stored transactions with a sequence greater than a given one
+	// in sequence order. An empty address matches the observed transactions of all subscribed addresses.
+	GetTransactionEventsAfter(address string, sequence uint64, limit int) ([]blocks.TransactionEvent, error)
+}
+
+// TransactionNotifier is a port interface for consumers notified of every transaction observed
+// for a subscribed address, e.g. to push it to webhooks.
+type TransactionNotifier interface {
+	// NotifyObservedTransaction is called after a transaction involving a subscribed address has been stored.
+	// It is called again for the same transaction if a previous notification failed, so it must be idempotent.
+	NotifyObservedTransaction(address string, tx blocks.ObservedTransaction) error
+
+	// NotifyRetractedTransaction is called before a transaction involving a subscribed address is removed, since
+	// its block was orphaned by a chain reorganization. It is called again for the same transaction if the removal
+	// is retried, so it must be idempotent.
+	NotifyRetractedTransaction(address string, tx blocks.ObservedTransaction) error
+}
+
+// WebhookManager is a port interface for managing the webhooks of subscribed addresses and their deliveries.
+type WebhookManager interface {
+	// RegisterWebhook registers a webhook URL for a subscribed address. It returns the webhook together with
+	// the secret used to sign all payloads delivered for the address.
+	RegisterWebhook(address, webhookURL string) (blocks.Webhook, string, error)
+
+	// GetWebhooks returns all webhooks registered for a subscribed address.
+	GetWebhooks(address string) ([]blocks.Webhook, error)
+
+	// RemoveWebhook removes a webhook of a subscribed address. It returns false if the webhook does not exist.
+	RemoveWebhook(address, id string) (bool, error)
+
+	// RemoveWebhooks removes all webhooks of an address and optionally its delivery log.
+	RemoveWebhooks(address string, purge bool) error
+
+	// GetDeliveries returns a page of the delivery log of a subscribed address together with the total number
+	// of deliveries. An empty status matches deliveries of any status.
+	GetDeliveries(
+		address string, status blocks.DeliveryStatus, offset, limit int) ([]blocks.WebhookDelivery, int, error)
+}
+
+// RPCUsageReporter is a port interface for inspecting the compute units spent on RPC calls.
+type RPCUsageReporter interface {
+	// Usage returns the compute units spent in the current day and month.
+	Usage() jsonrpc.BudgetUsage
+}
+
+// RPCUsageStore is a port interface for storage operations on the compute units spent on RPC calls.
+type RPCUsageStore interface {
+	// GetBudgetUsage returns the stored usage of the compute unit budget or nil if none has been stored yet.
+	GetBudgetUsage() (*jsonrpc.BudgetUsage, error)
+
+	// UpdateBudgetUsage stores the usage of the compute unit budget.
+	UpdateBudgetUsage(usage jsonrpc.BudgetUsage) error
+}
+
+// ObserverMetrics is a port interface for recording the progress of the block observer, e.g. to export metrics.
+type ObserverMetrics interface {
+	// ObserveHead records the number of the current head of the chain.
+	ObserveHead(blockNum int)
+
+	// ObserveProcessedBlock records a processed block together with the number of transfers matched
+	// per subscribed address.
+	ObserveProcessedBlock(blockNum int, timestamp time.Time, matches map[string]int)
+
+	// ObserveSkippedBlocks records that all blocks up to a given one were skipped, because nobody is subscribed.
+	ObserveSkippedBlocks(blockNum int)
+}
+
+// IterationReporter is a port interface for consumers notified about the outcome of every iteration
+// of the block observer, e.g. to track its health.
+type IterationReporter interface {
+	// ReportIteration is called after every iteration of the block observer with its error, if any.
+	ReportIteration(err error)
+}
+
+// HealthReporter is a port interface for inspecting the readiness of the scanner.
+type HealthReporter interface {
+	// Readiness returns the state of all readiness checks.
+	Readiness() blocks.HealthReport
 }
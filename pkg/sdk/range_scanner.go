@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"context"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+// BlockRangeScanner fetches a range of blocks concurrently with a bounded number of workers
+// and delivers them in ascending block order.
+type BlockRangeScanner struct {
+	BlockParser Parser
+	workers     int
+}
+
+type blockResult struct {
+	block *blocks.Block
+	err   error
+}
+
+// NewBlockRangeScanner is a constructor function for BlockRangeScanner.
+func NewBlockRangeScanner(blockParser Parser, workers int) *BlockRangeScanner {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &BlockRangeScanner{
+		BlockParser: blockParser,
+		workers:     workers,
+	}
+}
+
+// Scan fetches all blocks from fromBlockNum to toBlockNum inclusive and calls handle for each of them
+// in ascending block order. At most as many blocks as there are workers are fetched or buffered at a time.
+// Scanning stops at the first error returned either by fetching a block or by handle.
+func (s *BlockRangeScanner) Scan(
+	ctx context.Context, fromBlockNum, toBlockNum int, handle func(block *blocks.Block) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workerSlots := make(chan struct{}, s.workers)
+	// Each fetched block gets its own result channel, queued in block order, so results are
+	// consumed in order no matter which worker finishes first.
+	results := make(chan chan blockResult, s.workers)
+
+	go func() {
+		defer close(results)
+
+		for blockNum := fromBlockNum; blockNum <= toBlockNum; blockNum++ {
+			select {
+			case workerSlots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			resultCh := make(chan blockResult, 1)
+
+			select {
+			case results <- resultCh:
+			case <-ctx.Done():
+				return
+			}
+
+			go func(blockNum int) {
+				defer func() { <-workerSlots }()
+
+				block, err := s.BlockParser.GetBlock(ctx, blockNum)
+				resultCh <- blockResult{block: block, err: err}
+			}(blockNum)
+		}
+	}()
+
+	for resultCh := range results {
+		result := <-resultCh
+		if result.err != nil {
+			return result.err
+		}
+
+		if err := handle(result.block); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,135 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/numbers"
+	"github.com/powerslider/ethereum-block-scanner/pkg/storage/memory"
+)
+
+func TestBlockRangeScannerScan(t *testing.T) {
+	tests := []struct {
+		name        string
+		workers     int
+		failedBlock int
+		wantHandled string
+		wantErr     bool
+	}{
+		{name: "single worker", workers: 1, wantHandled: "[3 4 5 6 7 8]"},
+		{name: "blocks are handled in order", workers: 4, wantHandled: "[3 4 5 6 7 8]"},
+		{name: "failed block stops the scan", workers: 4, failedBlock: 6, wantHandled: "[3 4 5]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu              sync.Mutex
+				inFlight, peak  int
+				handledBlockNum []int
+			)
+
+			client := newFakeRPCClient()
+			client.handle("eth_getBlockByNumber", func(params []any) (any, error) {
+				blockNum, err := numbers.HexToInt(fmt.Sprint(params[0]))
+				if err != nil {
+					return nil, err
+				}
+
+				mu.Lock()
+				inFlight++
+
+				if inFlight > peak {
+					peak = inFlight
+				}
+				mu.Unlock()
+
+				// Later blocks are fetched faster, so that the workers finish out of order.
+				time.Sleep(time.Duration(10-blockNum) * time.Millisecond)
+
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+
+				if blockNum == tt.failedBlock {
+					return nil, errors.New("block not found")
+				}
+
+				return testBlock(blockNum, 1), nil
+			})
+
+			scanner := NewBlockRangeScanner(NewBlockParser(client, nil, nil), tt.workers)
+
+			err := scanner.Scan(context.Background(), 3, 8, func(block *blocks.Block) error {
+				blockNum, err := block.Number.Int()
+				handledBlockNum = append(handledBlockNum, blockNum)
+
+				return err
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := fmt.Sprint(handledBlockNum); got != tt.wantHandled {
+				t.Errorf("Scan() handled blocks %s, want %s", got, tt.wantHandled)
+			}
+
+			if peak > tt.workers {
+				t.Errorf("Scan() fetched %d blocks at a time, want at most %d", peak, tt.workers)
+			}
+		})
+	}
+}
+
+func TestGetTransactionsForBlockRange(t *testing.T) {
+	head := 10
+	client := newFakeRPCClient()
+
+	client.handle("eth_blockNumber", func(params []any) (any, error) {
+		return numbers.IntToHex(head), nil
+	})
+	client.handle("eth_getBlockReceipts", noReceipts)
+	client.handle("eth_getBlockByNumber", func(params []any) (any, error) {
+		blockNum, err := numbers.HexToInt(fmt.Sprint(params[0]))
+		if err != nil {
+			return nil, err
+		}
+
+		return testBlock(blockNum, 1), nil
+	})
+
+	parser := NewBlockParser(client, memory.NewTransactionsRepository(), memory.NewSubscriptionsRepository(),
+		WithHistoryScanWorkers(2))
+
+	txs, err := parser.GetTransactionsForBlockRange(context.Background(), _testAddress, 3)
+	if err != nil {
+		t.Fatalf("GetTransactionsForBlockRange() error = %v", err)
+	}
+
+	if len(txs) != 4 || client.callCount("eth_getBlockByNumber") != 4 {
+		t.Fatalf("GetTransactionsForBlockRange() = %d transactions from %d blocks, want 4 from blocks 7 to 10",
+			len(txs), client.callCount("eth_getBlockByNumber"))
+	}
+
+	// Blocks already scanned for the address are skipped on the next call.
+	head = 12
+
+	txs, err = parser.GetTransactionsForBlockRange(context.Background(), _testAddress, 3)
+	if err != nil {
+		t.Fatalf("GetTransactionsForBlockRange() error = %v", err)
+	}
+
+	if len(txs) != 4 || client.callCount("eth_getBlockByNumber") != 6 {
+		t.Errorf("GetTransactionsForBlockRange() = %d transactions from %d blocks, want 4 from 6 blocks",
+			len(txs), client.callCount("eth_getBlockByNumber"))
+	}
+
+	if _, err = parser.GetTransactionsForBlockRange(context.Background(), _testAddress, -1); err == nil {
+		t.Error("GetTransactionsForBlockRange() error = nil, want an error for a negative block range")
+	}
+}
@@ -0,0 +1,119 @@
+package sdk
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/numbers"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+// _maxBatchSize is the maximum number of requests sent in a single batch call, most providers reject larger ones.
+const _maxBatchSize = 100
+
+// _blockReceiptsMinShare is the share of the transactions of a block, as one in so many, which have to be requested
+// for all receipts of the block to be fetched at once. Fewer receipts are cheaper to fetch one by one.
+const _blockReceiptsMinShare = 4
+
+// GetTransactionReceipts returns the receipts of given transactions contained in a block keyed by transaction hash.
+//
+// If a large share of the transactions of the block is requested, all receipts of the block are fetched at once
+// with eth_getBlockReceipts. Otherwise, or if the node does not implement it, the receipts of the given
+// transactions are fetched with batched eth_getTransactionReceipt calls instead.
+func (p *BlockParser) GetTransactionReceipts(
+	ctx context.Context, block *blocks.Block, txHashes []blocks.Hash) (_ map[blocks.Hash]*blocks.Receipt, err error) {
+	blockNum, err := block.Number.Int()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := startSpan(ctx, "BlockParser.GetTransactionReceipts",
+		_attrBlockNumber.Int(blockNum), _attrTxCount.Int(len(txHashes)))
+	defer endSpan(span, &err)
+
+	receipts := make(map[blocks.Hash]*blocks.Receipt, len(txHashes))
+
+	if len(txHashes) == 0 {
+		return receipts, nil
+	}
+
+	if len(txHashes)*_blockReceiptsMinShare >= len(block.Transactions) && !p.blockReceiptsUnsupported.Load() {
+		var blockReceipts []*blocks.Receipt
+
+		err = p.EthClient.CallFor(ctx, &blockReceipts, "eth_getBlockReceipts", numbers.IntToHex(blockNum))
+		if err == nil {
+			for _, receipt := range blockReceipts {
+				if receipt != nil {
+					receipts[receipt.TransactionHash] = receipt
+				}
+			}
+
+			return receipts, nil
+		}
+
+		if !jsonrpc.IsMethodNotFound(err) {
+			return nil, err
+		}
+
+		// The node does not implement the method, so do not try it again.
+		slog.WarnContext(ctx, "eth_getBlockReceipts is not supported, fetching receipts per transaction",
+			"error", err)
+		p.blockReceiptsUnsupported.Store(true)
+	}
+
+	for start := 0; start < len(txHashes); start += _maxBatchSize {
+		end := start + _maxBatchSize
+		if end > len(txHashes) {
+			end = len(txHashes)
+		}
+
+		requests := make(jsonrpc.RPCRequests, 0, end-start)
+		batchReceipts := make([]*blocks.Receipt, end-start)
+		out := make([]any, end-start)
+
+		for i, hash := range txHashes[start:end] {
+			requests = append(requests, jsonrpc.NewRequest("eth_getTransactionReceipt", []blocks.Hash{hash}))
+			out[i] = &batchReceipts[i]
+		}
+
+		if err = p.EthClient.CallBatchFor(ctx, out, requests); err != nil {
+			return nil, err
+		}
+
+		for _, receipt := range batchReceipts {
+			if receipt != nil {
+				receipts[receipt.TransactionHash] = receipt
+			}
+		}
+	}
+
+	return receipts, nil
+}
+
+// AttachReceipts attaches their receipts to given transactions contained in a block.
+// Transactions without a known receipt are left unchanged.
+func (p *BlockParser) AttachReceipts(ctx context.Context, block *blocks.Block, txs []blocks.Transaction) error {
+	txHashes := make([]blocks.Hash, 0, len(txs))
+	seen := make(map[blocks.Hash]bool, len(txs))
+
+	for _, tx := range txs {
+		if !seen[tx.Hash] {
+			seen[tx.Hash] = true
+			txHashes = append(txHashes, tx.Hash)
+		}
+	}
+
+	receipts, err := p.GetTransactionReceipts(ctx, block, txHashes)
+	if err != nil {
+		return err
+	}
+
+	for i := range txs {
+		if receipt, found := receipts[txs[i].Hash]; found {
+			txs[i].Receipt = receipt
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+func TestGetTransactionReceipts(t *testing.T) {
+	tests := []struct {
+		name               string
+		blockTxs           int
+		requestedTxs       int
+		blockReceiptsErr   error
+		wantErr            bool
+		wantBlockReceipts  int
+		wantTxReceipts     int
+		wantBlockSupported bool
+	}{
+		{
+			name:               "few matches are fetched per transaction",
+			blockTxs:           100,
+			requestedTxs:       2,
+			wantTxReceipts:     2,
+			wantBlockSupported: true,
+		},
+		{
+			name:               "many matches are fetched per block",
+			blockTxs:           8,
+			requestedTxs:       2,
+			wantBlockReceipts:  1,
+			wantBlockSupported: true,
+		},
+		{
+			name:              "method not found falls back for good",
+			blockTxs:          4,
+			requestedTxs:      4,
+			blockReceiptsErr:  &jsonrpc.RPCError{Code: jsonrpc.MethodNotFoundCode, Message: "method not found"},
+			wantBlockReceipts: 1,
+			wantTxReceipts:    4,
+		},
+		{
+			name:               "rate limit does not fall back",
+			blockTxs:           4,
+			requestedTxs:       4,
+			blockReceiptsErr:   &jsonrpc.RPCError{Code: 429, Message: "too many requests"},
+			wantErr:            true,
+			wantBlockReceipts:  1,
+			wantBlockSupported: true,
+		},
+		{
+			name:               "internal error does not fall back",
+			blockTxs:           4,
+			requestedTxs:       4,
+			blockReceiptsErr:   &jsonrpc.RPCError{Code: -32603, Message: "internal error"},
+			wantErr:            true,
+			wantBlockReceipts:  1,
+			wantBlockSupported: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := testBlock(1, tt.blockTxs)
+			client := newFakeRPCClient()
+
+			client.handle("eth_getBlockReceipts", func(params []any) (any, error) {
+				if tt.blockReceiptsErr != nil {
+					return nil, tt.blockReceiptsErr
+				}
+
+				receipts := make([]map[string]any, 0, len(block.Transactions))
+
+				for _, tx := range block.Transactions {
+					receipts = append(receipts, map[string]any{"transactionHash": tx.Hash.Hex()})
+				}
+
+				return receipts, nil
+			})
+			client.handle("eth_getTransactionReceipt", func(params []any) (any, error) {
+				if len(params) != 1 {
+					return nil, &jsonrpc.RPCError{Code: jsonrpc.InvalidParamsCode, Message: "expected one param"}
+				}
+
+				return map[string]any{"transactionHash": params[0]}, nil
+			})
+
+			parser := NewBlockParser(client, nil, nil)
+			txHashes := make([]blocks.Hash, 0, tt.requestedTxs)
+
+			for _, tx := range block.Transactions[:tt.requestedTxs] {
+				txHashes = append(txHashes, tx.Hash)
+			}
+
+			receipts, err := parser.GetTransactionReceipts(context.Background(), block, txHashes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTransactionReceipts() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr {
+				for _, hash := range txHashes {
+					if receipt := receipts[hash]; receipt == nil || receipt.TransactionHash != hash {
+						t.Errorf("receipt of %s = %+v", hash.Hex(), receipt)
+					}
+				}
+			}
+
+			if got := client.callCount("eth_getBlockReceipts"); got != tt.wantBlockReceipts {
+				t.Errorf("eth_getBlockReceipts calls = %d, want %d", got, tt.wantBlockReceipts)
+			}
+
+			if got := client.callCount("eth_getTransactionReceipt"); got != tt.wantTxReceipts {
+				t.Errorf("eth_getTransactionReceipt calls = %d, want %d", got, tt.wantTxReceipts)
+			}
+
+			if supported := !parser.blockReceiptsUnsupported.Load(); supported != tt.wantBlockSupported {
+				t.Errorf("eth_getBlockReceipts supported = %v, want %v", supported, tt.wantBlockSupported)
+			}
+		})
+	}
+}
+
+func TestAttachReceipts(t *testing.T) {
+	tests := []struct {
+		name               string
+		blockReceiptsErr   error
+		wantErr            bool
+		wantTxReceipts     int
+		wantBlockSupported bool
+	}{
+		{
+			name:               "block receipts",
+			wantBlockSupported: true,
+		},
+		{
+			name:             "rejected block receipts fall back to transaction receipts",
+			blockReceiptsErr: &jsonrpc.RPCError{Code: jsonrpc.MethodNotFoundCode, Message: "the method does not exist"},
+			wantTxReceipts:   3,
+		},
+		{
+			name:               "transport errors do not fall back",
+			blockReceiptsErr:   errors.New("connection refused"),
+			wantErr:            true,
+			wantBlockSupported: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := testBlock(1, 3)
+			client := newFakeRPCClient()
+
+			client.handle("eth_getBlockReceipts", func(params []any) (any, error) {
+				if tt.blockReceiptsErr != nil {
+					return nil, tt.blockReceiptsErr
+				}
+
+				receipts := make([]map[string]any, 0, len(block.Transactions))
+
+				for _, tx := range block.Transactions {
+					receipts = append(receipts, map[string]any{"transactionHash": tx.Hash, "status": "0x1"})
+				}
+
+				return receipts, nil
+			})
+			client.handle("eth_getTransactionReceipt", func(params []any) (any, error) {
+				return map[string]any{"transactionHash": params[0], "status": "0x1"}, nil
+			})
+
+			parser := NewBlockParser(client, nil, nil)
+
+			err := parser.AttachReceipts(context.Background(), block, block.Transactions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AttachReceipts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr {
+				for _, tx := range block.Transactions {
+					if tx.Receipt == nil || tx.Receipt.TransactionHash != tx.Hash {
+						t.Errorf("receipt of %s = %+v", tx.Hash, tx.Receipt)
+					}
+				}
+			}
+
+			if got := client.callCount("eth_getTransactionReceipt"); got != tt.wantTxReceipts {
+				t.Errorf("eth_getTransactionReceipt calls = %d, want %d", got, tt.wantTxReceipts)
+			}
+
+			if supported := !parser.blockReceiptsUnsupported.Load(); supported != tt.wantBlockSupported {
+				t.Errorf("eth_getBlockReceipts supported = %v, want %v", supported, tt.wantBlockSupported)
+			}
+		})
+	}
+}
+
+// noReceipts answers eth_getBlockReceipts for blocks whose receipts do not matter to a test.
+func noReceipts(_ []any) (any, error) {
+	return []any{}, nil
+}
@@ -0,0 +1,88 @@
+package sdk
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+const (
+	_reorgWindowSize        = 64
+	_reorgEventsChannelSize = 16
+)
+
+// ErrReorgTooDeep is returned when the blocks orphaned by a chain reorganization reach further back than the window
+// of known block hashes, so the common ancestor of the old and the new chain cannot be told. Nothing is rolled back
+// then and the observer keeps failing at the first block of the new chain.
+var ErrReorgTooDeep = errors.New("chain reorganization is deeper than the window of known blocks")
+
+// blockWindow keeps the hashes of the most recently processed blocks keyed by block number.
+type blockWindow struct {
+	sync.RWMutex
+	size   int
+	hashes map[int]blocks.Hash
+}
+
+func newBlockWindow(size int) *blockWindow {
+	return &blockWindow{
+		size:   size,
+		hashes: make(map[int]blocks.Hash, size),
+	}
+}
+
+// get returns the hash of a processed block if it is still part of the window.
+func (w *blockWindow) get(blockNum int) (blocks.Hash, bool) {
+	w.RLock()
+	hash, found := w.hashes[blockNum]
+	w.RUnlock()
+
+	return hash, found
+}
+
+// add records the hash of a processed block and evicts the blocks that fell out of the window.
+func (w *blockWindow) add(blockNum int, hash blocks.Hash) {
+	w.Lock()
+	w.hashes[blockNum] = hash
+
+	for n := range w.hashes {
+		if n <= blockNum-w.size {
+			delete(w.hashes, n)
+		}
+	}
+	w.Unlock()
+}
+
+// load replaces the window with the hashes of the blocks processed before, e.g. by a previous run.
+func (w *blockWindow) load(hashes map[int]blocks.Hash) {
+	w.Lock()
+	w.hashes = make(map[int]blocks.Hash, w.size)
+
+	for n, hash := range hashes {
+		w.hashes[n] = hash
+	}
+	w.Unlock()
+}
+
+// truncate removes all blocks after a given block number from the window.
+func (w *blockWindow) truncate(blockNum int) {
+	w.Lock()
+	for n := range w.hashes {
+		if n > blockNum {
+			delete(w.hashes, n)
+		}
+	}
+	w.Unlock()
+}
+
+// reverse returns a copy of a slice of hashes collected while walking the chain backwards
+// in ascending block order.
+func reverse(hashes []blocks.Hash) []blocks.Hash {
+	reversed := make([]blocks.Hash, len(hashes))
+
+	for i, hash := range hashes {
+		reversed[len(hashes)-1-i] = hash
+	}
+
+	return reversed
+}
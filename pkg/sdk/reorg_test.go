@@ -0,0 +1,398 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/numbers"
+	"github.com/powerslider/ethereum-block-scanner/pkg/storage/memory"
+)
+
+func TestProcessNewBlocksRollsBackReorg(t *testing.T) {
+	tests := []struct {
+		name       string
+		forkedFrom int
+		wantDepth  int
+	}{
+		{name: "reorg of the head", forkedFrom: 12, wantDepth: 1},
+		{name: "reorg of two blocks", forkedFrom: 11, wantDepth: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subsStore := memory.NewSubscriptionsRepository()
+
+			if err := subsStore.InsertSubscriberAddress(_testAddress); err != nil {
+				t.Fatalf("InsertSubscriberAddress() error = %v", err)
+			}
+
+			head := 12
+			chain := testChain(head, head+1)
+
+			if err := subsStore.UpdateLastProcessedBlock(9, chain[9].Hash, _reorgWindowSize); err != nil {
+				t.Fatalf("UpdateLastProcessedBlock() error = %v", err)
+			}
+
+			client := newFakeRPCClient()
+
+			client.handle("eth_blockNumber", func(params []any) (any, error) {
+				return numbers.IntToHex(head), nil
+			})
+			client.handle("eth_getBlockReceipts", noReceipts)
+			client.handle("eth_getLogs", noLogs)
+			client.handle("eth_getBlockByNumber", func(params []any) (any, error) {
+				blockNum, err := numbers.HexToInt(fmt.Sprint(params[0]))
+				if err != nil {
+					return nil, err
+				}
+
+				return chain[blockNum], nil
+			})
+
+			txStore := memory.NewTransactionsRepository()
+			parser := NewBlockParser(client, txStore, subsStore)
+			observer := NewBlockObserver(parser, subsStore, txStore, NewTransactionBus(subsStore), WithFinalityTags(false))
+
+			if err := observer.processNewBlocks(context.Background()); err != nil {
+				t.Fatalf("processNewBlocks() error = %v", err)
+			}
+
+			// The chain is reorganized from a given block on and grows by one more block.
+			oldChain := chain
+			head = 13
+			chain = testChain(head, tt.forkedFrom)
+
+			if err := observer.processNewBlocks(context.Background()); err != nil {
+				t.Fatalf("processNewBlocks() error = %v", err)
+			}
+
+			var wantObserved, wantOldHashes, wantNewHashes []blocks.Hash
+
+			for n := 10; n <= head; n++ {
+				wantObserved = append(wantObserved, chain[n].Transactions[0].Hash)
+
+				if n >= tt.forkedFrom && n < head {
+					wantOldHashes = append(wantOldHashes, oldChain[n].Hash)
+					wantNewHashes = append(wantNewHashes, chain[n].Hash)
+				}
+			}
+
+			observedTxs, err := subsStore.GetObservedTransactionsPerAddress(_testAddress)
+			if err != nil {
+				t.Fatalf("GetObservedTransactionsPerAddress() error = %v", err)
+			}
+
+			var observed []blocks.Hash
+
+			for _, tx := range observedTxs {
+				observed = append(observed, tx.Hash)
+			}
+
+			if fmt.Sprint(observed) != fmt.Sprint(wantObserved) {
+				t.Errorf("observed transactions = %v, want %v", observed, wantObserved)
+			}
+
+			lastProcessedBlockNum, err := subsStore.GetLastProcessedBlockNumber()
+			if err != nil {
+				t.Fatalf("GetLastProcessedBlockNumber() error = %v", err)
+			}
+
+			if lastProcessedBlockNum != head {
+				t.Errorf("last processed block = %d, want %d", lastProcessedBlockNum, head)
+			}
+
+			select {
+			case event := <-observer.ReorgEvents():
+				want := blocks.ReorgEvent{
+					Depth:          tt.wantDepth,
+					CommonAncestor: tt.forkedFrom - 1,
+					OldHashes:      wantOldHashes,
+					NewHashes:      wantNewHashes,
+				}
+
+				if fmt.Sprint(event) != fmt.Sprint(want) {
+					t.Errorf("reorg event = %+v, want %+v", event, want)
+				}
+			default:
+				t.Error("no reorg event was emitted")
+			}
+		})
+	}
+}
+
+func TestRollback(t *testing.T) {
+	const headBlockNum = 12
+
+	const otherAddress = "0x00000000000000000000000000000000000000bb"
+
+	tests := []struct {
+		name               string
+		firstKnownBlockNum int
+		orphanedFrom       int
+		wantCommonAncestor int
+		wantErr            error
+	}{
+		{name: "reorg of the head", firstKnownBlockNum: 10, orphanedFrom: 12, wantCommonAncestor: 11},
+		{name: "reorg of two blocks", firstKnownBlockNum: 10, orphanedFrom: 11, wantCommonAncestor: 10},
+		{
+			name:               "reorg deeper than the window is not rolled back",
+			firstKnownBlockNum: 11,
+			orphanedFrom:       11,
+			wantCommonAncestor: headBlockNum,
+			wantErr:            ErrReorgTooDeep,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subsStore := memory.NewSubscriptionsRepository()
+			txStore := memory.NewTransactionsRepository()
+			webhookStore := memory.NewWebhooksRepository()
+			dispatcher := NewWebhookDispatcher(subsStore, webhookStore)
+			bus := NewTransactionBus(subsStore, dispatcher)
+
+			if err := subsStore.InsertSubscriberAddress(_testAddress); err != nil {
+				t.Fatalf("InsertSubscriberAddress() error = %v", err)
+			}
+
+			if _, _, err := dispatcher.RegisterWebhook(_testAddress, "http://localhost/hook"); err != nil {
+				t.Fatalf("RegisterWebhook() error = %v", err)
+			}
+
+			events, cancel := bus.SubscribeTransactions(_testAddress)
+			defer cancel()
+
+			canonical := make(map[any]*blocks.Block)
+
+			for n := 10; n <= headBlockNum; n++ {
+				tx := testObservedTransaction(n, 0)
+
+				if err := bus.PublishObservedTransaction(_testAddress, tx); err != nil {
+					t.Fatalf("PublishObservedTransaction() error = %v", err)
+				}
+
+				for _, address := range []string{_testAddress, otherAddress} {
+					if err := txStore.Insert(address, n, tx.Transaction, true); err != nil {
+						t.Fatalf("Insert() error = %v", err)
+					}
+				}
+
+				if n >= tt.firstKnownBlockNum {
+					err := subsStore.UpdateLastProcessedBlock(n, testBlock(n, 0).Hash, _reorgWindowSize)
+					if err != nil {
+						t.Fatalf("UpdateLastProcessedBlock() error = %v", err)
+					}
+				}
+
+				block := testBlock(n, 0)
+				if n >= tt.orphanedFrom {
+					block.Hash = testHash(fmt.Sprintf("reorg-block-%d", n))
+				}
+
+				canonical[block.Number.Hex()] = block
+			}
+
+			if err := txStore.InsertScannedBlockRange(_testAddress, blocks.Range{From: 0, To: headBlockNum}); err != nil {
+				t.Fatalf("InsertScannedBlockRange() error = %v", err)
+			}
+
+			client := newFakeRPCClient()
+			client.handle("eth_getBlockByNumber", func(params []any) (any, error) {
+				return canonical[params[0]], nil
+			})
+
+			parser := NewBlockParser(client, txStore, subsStore)
+			observer := NewBlockObserver(parser, subsStore, txStore, bus)
+
+			hashes, err := subsStore.GetProcessedBlockHashes()
+			if err != nil {
+				t.Fatalf("GetProcessedBlockHashes() error = %v", err)
+			}
+
+			observer.window.load(hashes)
+
+			commonAncestor, err := observer.rollback(context.Background(), headBlockNum, []string{_testAddress})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("rollback() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if commonAncestor != tt.wantCommonAncestor {
+				t.Errorf("rollback() = %d, want %d", commonAncestor, tt.wantCommonAncestor)
+			}
+
+			kept := commonAncestor - 9
+			retracted := headBlockNum - commonAncestor
+
+			observedTxs, err := subsStore.GetObservedTransactionsPerAddress(_testAddress)
+			if err != nil {
+				t.Fatalf("GetObservedTransactionsPerAddress() error = %v", err)
+			}
+
+			if len(observedTxs) != kept {
+				t.Errorf("kept %d observed transactions, want %d", len(observedTxs), kept)
+			}
+
+			if got := countRetractions(events); got != retracted {
+				t.Errorf("streamed %d retractions, want %d", got, retracted)
+			}
+
+			deliveries, _, err := webhookStore.GetDeliveriesPerAddress(_testAddress, "", 0, 10)
+			if err != nil {
+				t.Fatalf("GetDeliveriesPerAddress() error = %v", err)
+			}
+
+			retractedDeliveries := 0
+
+			for _, delivery := range deliveries {
+				if delivery.Payload.Retracted {
+					retractedDeliveries++
+				}
+			}
+
+			if retractedDeliveries != retracted {
+				t.Errorf("enqueued %d retraction deliveries, want %d", retractedDeliveries, retracted)
+			}
+
+			historyTxs, err := txStore.GetAllTransactionsPerAddress(_testAddress)
+			if err != nil {
+				t.Fatalf("GetAllTransactionsPerAddress() error = %v", err)
+			}
+
+			if len(historyTxs) != kept {
+				t.Errorf("kept %d history transactions, want %d", len(historyTxs), kept)
+			}
+
+			if historyTxs, err = txStore.GetAllTransactionsPerAddress(otherAddress); err != nil {
+				t.Fatalf("GetAllTransactionsPerAddress() error = %v", err)
+			}
+
+			if want := headBlockNum - 9; len(historyTxs) != want {
+				t.Errorf("kept %d history transactions of another address, want %d", len(historyTxs), want)
+			}
+
+			ranges, err := txStore.GetScannedBlockRangesPerAddress(_testAddress)
+			if err != nil {
+				t.Fatalf("GetScannedBlockRangesPerAddress() error = %v", err)
+			}
+
+			if want := []blocks.Range{{From: 0, To: commonAncestor}}; !slices.Equal(ranges, want) {
+				t.Errorf("scanned ranges = %v, want %v", ranges, want)
+			}
+
+			lastProcessedBlockNum, err := subsStore.GetLastProcessedBlockNumber()
+			if err != nil {
+				t.Fatalf("GetLastProcessedBlockNumber() error = %v", err)
+			}
+
+			if lastProcessedBlockNum != commonAncestor {
+				t.Errorf("last processed block = %d, want %d", lastProcessedBlockNum, commonAncestor)
+			}
+
+			wantHashes := make(map[int]blocks.Hash)
+			for n := tt.firstKnownBlockNum; n <= commonAncestor; n++ {
+				wantHashes[n] = testBlock(n, 0).Hash
+			}
+
+			if hashes, err = subsStore.GetProcessedBlockHashes(); err != nil {
+				t.Fatalf("GetProcessedBlockHashes() error = %v", err)
+			}
+
+			if !maps.Equal(hashes, wantHashes) {
+				t.Errorf("processed block hashes = %v, want %v", hashes, wantHashes)
+			}
+		})
+	}
+}
+
+func TestProcessBlocksUpToRecordsSkippedHead(t *testing.T) {
+	subsStore := memory.NewSubscriptionsRepository()
+
+	if err := subsStore.UpdateLastProcessedBlock(15, testBlock(15, 0).Hash, _reorgWindowSize); err != nil {
+		t.Fatalf("UpdateLastProcessedBlock() error = %v", err)
+	}
+
+	client := newFakeRPCClient()
+	client.handle("eth_getBlockByNumber", func(params []any) (any, error) {
+		if params[0] != blocks.NewQuantity(20).Hex() {
+			return nil, nil
+		}
+
+		return map[string]any{"hash": testBlock(20, 0).Hash}, nil
+	})
+
+	txStore := memory.NewTransactionsRepository()
+	observer := NewBlockObserver(
+		NewBlockParser(client, txStore, subsStore), subsStore, txStore, NewTransactionBus(subsStore))
+
+	if err := observer.processBlocksUpTo(context.Background(), 20); err != nil {
+		t.Fatalf("processBlocksUpTo() error = %v", err)
+	}
+
+	lastProcessedBlockNum, err := subsStore.GetLastProcessedBlockNumber()
+	if err != nil {
+		t.Fatalf("GetLastProcessedBlockNumber() error = %v", err)
+	}
+
+	if lastProcessedBlockNum != 20 {
+		t.Errorf("last processed block = %d, want 20", lastProcessedBlockNum)
+	}
+
+	hashes, err := subsStore.GetProcessedBlockHashes()
+	if err != nil {
+		t.Fatalf("GetProcessedBlockHashes() error = %v", err)
+	}
+
+	want := map[int]blocks.Hash{15: testBlock(15, 0).Hash, 20: testBlock(20, 0).Hash}
+	if !maps.Equal(hashes, want) {
+		t.Errorf("processed block hashes = %v, want %v", hashes, want)
+	}
+
+	if hash, _ := observer.window.get(20); hash != want[20] {
+		t.Errorf("window hash of block 20 = %s, want %s", hash.Hex(), want[20].Hex())
+	}
+}
+
+// testChain returns the blocks up to a given head keyed by their number. The blocks from forkedFrom on belong
+// to another branch than the blocks of a chain forked later, so their hashes and transactions differ.
+func testChain(head, forkedFrom int) map[int]*blocks.Block {
+	chain := make(map[int]*blocks.Block, head+1)
+
+	for n := 0; n <= head; n++ {
+		block := testBlock(n, 1)
+
+		if n >= forkedFrom {
+			block.Hash[blocks.HashLength-1] = 0xf
+			block.Transactions[0].Hash[blocks.HashLength-1] = 0xf
+			block.Transactions[0].BlockHash = block.Hash
+		}
+
+		if n > 0 {
+			block.ParentHash = chain[n-1].Hash
+		}
+
+		chain[n] = block
+	}
+
+	return chain
+}
+
+// countRetractions drains the events received so far and returns the number of retractions among them.
+func countRetractions(events <-chan blocks.TransactionEvent) int {
+	n := 0
+
+	for {
+		select {
+		case event := <-events:
+			if event.Retracted {
+				n++
+			}
+		default:
+			return n
+		}
+	}
+}
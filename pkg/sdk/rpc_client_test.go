@@ -0,0 +1,120 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+// fakeRPCClient answers JSON-RPC calls with handlers registered per method. The handlers receive the params
+// as a node would decode them. Calls to methods without a handler fail with the method not found error code.
+type fakeRPCClient struct {
+	mu       sync.Mutex
+	handlers map[string]func(params []any) (any, error)
+	calls    map[string]int
+}
+
+func newFakeRPCClient() *fakeRPCClient {
+	return &fakeRPCClient{
+		handlers: make(map[string]func(params []any) (any, error)),
+		calls:    make(map[string]int),
+	}
+}
+
+func (f *fakeRPCClient) handle(method string, handler func(params []any) (any, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.handlers[method] = handler
+}
+
+func (f *fakeRPCClient) callCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls[method]
+}
+
+func (f *fakeRPCClient) Call(_ context.Context, method string, params ...any) (*jsonrpc.RPCResponse, error) {
+	return f.call(jsonrpc.NewRequest(method, params...))
+}
+
+func (f *fakeRPCClient) CallFor(ctx context.Context, out any, method string, params ...any) error {
+	rpcResponse, err := f.Call(ctx, method, params...)
+	if err != nil {
+		return err
+	}
+
+	if rpcResponse.Error != nil {
+		return rpcResponse.Error
+	}
+
+	return rpcResponse.GetObject(out)
+}
+
+func (f *fakeRPCClient) CallBatch(_ context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	rpcResponses := make(jsonrpc.RPCResponses, len(requests))
+
+	for i, request := range requests {
+		rpcResponse, err := f.call(request)
+		if err != nil {
+			return nil, err
+		}
+
+		rpcResponse.ID = i
+		rpcResponses[i] = rpcResponse
+	}
+
+	return rpcResponses, nil
+}
+
+func (f *fakeRPCClient) CallBatchFor(ctx context.Context, out []any, requests jsonrpc.RPCRequests) error {
+	rpcResponses, err := f.CallBatch(ctx, requests)
+	if err != nil {
+		return err
+	}
+
+	return rpcResponses.GetObjects(out)
+}
+
+func (f *fakeRPCClient) call(request *jsonrpc.RPCRequest) (*jsonrpc.RPCResponse, error) {
+	f.mu.Lock()
+	f.calls[request.Method]++
+	handler := f.handlers[request.Method]
+	f.mu.Unlock()
+
+	if handler == nil {
+		return &jsonrpc.RPCResponse{
+			JSONRPC: "2.0",
+			Error:   &jsonrpc.RPCError{Code: jsonrpc.MethodNotFoundCode, Message: "the method does not exist"},
+		}, nil
+	}
+
+	// A node receives the params as JSON, so they are passed to the handler the way it would decode them.
+	paramsJSON, err := json.Marshal(request.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	var params []any
+
+	if err = json.Unmarshal(paramsJSON, &params); err != nil {
+		return nil, err
+	}
+
+	result, err := handler(params)
+
+	var rpcErr *jsonrpc.RPCError
+
+	switch {
+	case errors.As(err, &rpcErr):
+		return &jsonrpc.RPCResponse{JSONRPC: "2.0", Error: rpcErr}, nil
+	case err != nil:
+		return nil, err
+	}
+
+	return &jsonrpc.RPCResponse{JSONRPC: "2.0", Result: result}, nil
+}
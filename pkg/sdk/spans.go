@@ -0,0 +1,41 @@
+package sdk
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attributes of the spans of the block parser and the block observer.
+const (
+	_attrBlockNumber = attribute.Key("block.number")
+	_attrBlockHash   = attribute.Key("block.hash")
+	_attrBlockTag    = attribute.Key("block.tag")
+	_attrFromBlock   = attribute.Key("block.from")
+	_attrToBlock     = attribute.Key("block.to")
+	_attrAddress     = attribute.Key("address")
+	_attrTxCount     = attribute.Key("tx.count")
+	_attrTime        = attribute.Key("time")
+	_attrTracer      = attribute.Key("tracer")
+)
+
+var _tracer = otel.Tracer("github.com/powerslider/ethereum-block-scanner/pkg/sdk")
+
+// startSpan starts a span of an operation. Spans are only recorded once a tracer provider is installed.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return _tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends the span of an operation, marking it as failed if the operation returned an error.
+// It takes a pointer to the error, so that it can be deferred before the error is known.
+func endSpan(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+
+	span.End()
+}
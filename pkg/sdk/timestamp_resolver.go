@@ -0,0 +1,87 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// BlockTimestampResolver resolves points in time to block numbers by a binary search over block timestamps.
+type BlockTimestampResolver struct {
+	BlockParser Parser
+}
+
+// NewBlockTimestampResolver is a constructor function for BlockTimestampResolver.
+func NewBlockTimestampResolver(blockParser Parser) *BlockTimestampResolver {
+	return &BlockTimestampResolver{
+		BlockParser: blockParser,
+	}
+}
+
+// FirstBlockAtOrAfter returns the number of the first block with a timestamp at or after a given time.
+func (r *BlockTimestampResolver) FirstBlockAtOrAfter(ctx context.Context, t time.Time) (int, error) {
+	latestBlockNum, err := r.BlockParser.GetCurrentBlock(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	blockNum, err := r.search(ctx, latestBlockNum, func(blockTime time.Time) bool {
+		return !blockTime.Before(t)
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	if blockNum > latestBlockNum {
+		return -1, fmt.Errorf("no block found at or after %s", t.Format(time.RFC3339))
+	}
+
+	return blockNum, nil
+}
+
+// LastBlockAtOrBefore returns the number of the last block with a timestamp at or before a given time.
+func (r *BlockTimestampResolver) LastBlockAtOrBefore(ctx context.Context, t time.Time) (int, error) {
+	latestBlockNum, err := r.BlockParser.GetCurrentBlock(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	blockNum, err := r.search(ctx, latestBlockNum, func(blockTime time.Time) bool {
+		return blockTime.After(t)
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	if blockNum == 0 {
+		return -1, fmt.Errorf("no block found at or before %s", t.Format(time.RFC3339))
+	}
+
+	return blockNum - 1, nil
+}
+
+// search returns the smallest block number in [0, latestBlockNum] for which the predicate on the block timestamp
+// is true or latestBlockNum + 1 if there is none. Block timestamps are non-decreasing, so the predicate
+// is expected to be false up to some block and true from then on.
+func (r *BlockTimestampResolver) search(
+	ctx context.Context, latestBlockNum int, predicate func(blockTime time.Time) bool) (int, error) {
+	var searchErr error
+
+	blockNum := sort.Search(latestBlockNum+1, func(blockNum int) bool {
+		if searchErr != nil {
+			return true
+		}
+
+		blockTime, err := r.BlockParser.GetBlockTimestamp(ctx, blockNum)
+		if err != nil {
+			searchErr = err
+
+			return true
+		}
+
+		return predicate(blockTime)
+	})
+
+	return blockNum, searchErr
+}
@@ -0,0 +1,74 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/numbers"
+	"github.com/powerslider/ethereum-block-scanner/pkg/storage/memory"
+)
+
+func TestBlockTimestampResolver(t *testing.T) {
+	// Blocks 0 to 10 are produced every 12 seconds starting at the genesis time.
+	genesisTime := time.Unix(1_700_000_000, 0).UTC()
+
+	tests := []struct {
+		name         string
+		t            time.Time
+		wantFirst    int
+		wantFirstErr bool
+		wantLast     int
+		wantLastErr  bool
+	}{
+		{name: "block time", t: genesisTime.Add(36 * time.Second), wantFirst: 3, wantLast: 3},
+		{name: "between blocks", t: genesisTime.Add(40 * time.Second), wantFirst: 4, wantLast: 3},
+		{name: "before genesis", t: genesisTime.Add(-time.Second), wantFirst: 0, wantLastErr: true},
+		{name: "after the latest block", t: genesisTime.Add(time.Hour), wantFirstErr: true, wantLast: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeRPCClient()
+			client.handle("eth_blockNumber", func(params []any) (any, error) {
+				return numbers.IntToHex(10), nil
+			})
+			client.handle("eth_getBlockByNumber", func(params []any) (any, error) {
+				blockNum, err := numbers.HexToInt(fmt.Sprint(params[0]))
+				if err != nil {
+					return nil, err
+				}
+
+				blockTime := genesisTime.Add(time.Duration(blockNum) * 12 * time.Second)
+
+				return map[string]any{"timestamp": numbers.IntToHex(int(blockTime.Unix()))}, nil
+			})
+
+			parser := NewBlockParser(client, memory.NewTransactionsRepository(), memory.NewSubscriptionsRepository())
+
+			first, err := parser.GetFirstBlockNumberAtOrAfter(context.Background(), tt.t)
+			if (err != nil) != tt.wantFirstErr {
+				t.Fatalf("GetFirstBlockNumberAtOrAfter() error = %v, wantErr %v", err, tt.wantFirstErr)
+			}
+
+			if err == nil && first != tt.wantFirst {
+				t.Errorf("GetFirstBlockNumberAtOrAfter() = %d, want %d", first, tt.wantFirst)
+			}
+
+			last, err := parser.GetLastBlockNumberAtOrBefore(context.Background(), tt.t)
+			if (err != nil) != tt.wantLastErr {
+				t.Fatalf("GetLastBlockNumberAtOrBefore() error = %v, wantErr %v", err, tt.wantLastErr)
+			}
+
+			if err == nil && last != tt.wantLast {
+				t.Errorf("GetLastBlockNumberAtOrBefore() = %d, want %d", last, tt.wantLast)
+			}
+
+			// The binary search over 11 blocks fetches at most 4 block headers per lookup.
+			if fetched := client.callCount("eth_getBlockByNumber"); fetched > 8 {
+				t.Errorf("fetched %d block headers, want at most 8", fetched)
+			}
+		})
+	}
+}
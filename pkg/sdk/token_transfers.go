@@ -0,0 +1,96 @@
+package sdk
+
+import (
+	"context"
+	"strings"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+// _maxTopicAddresses is the maximum number of addresses matched by a single eth_getLogs topic filter.
+const _maxTopicAddresses = 100
+
+// logFilter represents the filter object of an eth_getLogs call.
+type logFilter struct {
+	BlockHash blocks.Hash `json:"blockHash"`
+	Topics    []any       `json:"topics"`
+}
+
+// GetTokenTransfers returns the ERC-20 token transfers contained in a block sent from or to any of given addresses.
+// The logs are filtered by block hash, so that the transfers are consistent with the block even during a reorg.
+func (p *BlockParser) GetTokenTransfers(
+	ctx context.Context, blockHash blocks.Hash, addresses []blocks.Address) (_ []blocks.TokenTransfer, err error) {
+	ctx, span := startSpan(ctx, "BlockParser.GetTokenTransfers", _attrBlockHash.String(blockHash.Hex()))
+	defer endSpan(span, &err)
+
+	filters := make([]logFilter, 0)
+
+	for start := 0; start < len(addresses); start += _maxTopicAddresses {
+		end := start + _maxTopicAddresses
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+
+		topics := make([]blocks.Hash, 0, end-start)
+
+		for _, a := range addresses[start:end] {
+			topics = append(topics, blocks.AddressToTopic(a))
+		}
+
+		// Indexed topics are matched by position, so outgoing and incoming transfers need a filter each.
+		filters = append(filters,
+			logFilter{BlockHash: blockHash, Topics: []any{blocks.TransferEventTopic, topics}},
+			logFilter{BlockHash: blockHash, Topics: []any{blocks.TransferEventTopic, nil, topics}},
+		)
+	}
+
+	transfers := make([]blocks.TokenTransfer, 0)
+	seen := make(map[string]bool)
+
+	for start := 0; start < len(filters); start += _maxBatchSize {
+		end := start + _maxBatchSize
+		if end > len(filters) {
+			end = len(filters)
+		}
+
+		requests := make(jsonrpc.RPCRequests, 0, end-start)
+		batchLogs := make([][]blocks.Log, end-start)
+		out := make([]any, end-start)
+
+		for i, filter := range filters[start:end] {
+			requests = append(requests, jsonrpc.NewRequest("eth_getLogs", []logFilter{filter}))
+			out[i] = &batchLogs[i]
+		}
+
+		if err = p.EthClient.CallBatchFor(ctx, out, requests); err != nil {
+			return nil, err
+		}
+
+		for _, logs := range batchLogs {
+			for _, log := range logs {
+				transfer, ok := blocks.DecodeTokenTransfer(log)
+				if !ok || log.Removed {
+					continue
+				}
+
+				// A transfer between two subscribed addresses is returned by both filters.
+				id := transfer.TransactionHash.Hex() + transfer.LogIndex.Hex()
+				if seen[id] {
+					continue
+				}
+
+				seen[id] = true
+
+				transfers = append(transfers, transfer)
+			}
+		}
+	}
+
+	return transfers, nil
+}
+
+// GetTokenTransfersPerSubscriber lists observed ERC-20 token transfers given a registered subscriber address.
+func (p *BlockParser) GetTokenTransfersPerSubscriber(address string) ([]blocks.TokenTransfer, error) {
+	return p.SubsStore.GetTokenTransfersPerAddress(strings.ToLower(address))
+}
@@ -0,0 +1,72 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+func TestGetTokenTransfers(t *testing.T) {
+	testAddress, _ := blocks.ParseAddress(_testAddress)
+	otherAddress := blocks.Address{blocks.AddressLength - 1: 0xbb}
+	amount := blocks.Hash{blocks.HashLength - 2: 0x03, blocks.HashLength - 1: 0xe8}
+
+	transferLog := func(from, to blocks.Address, logIndex int, removed bool) blocks.Log {
+		return blocks.Log{
+			Topics:          []blocks.Hash{blocks.TransferEventTopic, blocks.AddressToTopic(from), blocks.AddressToTopic(to)},
+			Data:            amount[:],
+			LogIndex:        blocks.NewQuantity(logIndex),
+			TransactionHash: testHash("tx"),
+			Removed:         removed,
+		}
+	}
+
+	logs := []blocks.Log{
+		transferLog(testAddress, otherAddress, 0, false),
+		transferLog(otherAddress, testAddress, 1, false),
+		transferLog(testAddress, otherAddress, 2, true),
+	}
+
+	client := newFakeRPCClient()
+	client.handle("eth_getLogs", func(params []any) (any, error) {
+		filter, _ := params[0].(map[string]any)
+		topics, _ := filter["topics"].([]any)
+
+		// Every filter matches all logs, so transfers between subscribed addresses are returned twice.
+		if len(topics) < 2 {
+			return nil, fmt.Errorf("unexpected topics %v", topics)
+		}
+
+		return logs, nil
+	})
+
+	parser := NewBlockParser(client, nil, nil)
+
+	transfers, err := parser.GetTokenTransfers(context.Background(), testHash("block-10"),
+		[]blocks.Address{testAddress, otherAddress})
+	if err != nil {
+		t.Fatalf("GetTokenTransfers() error = %v", err)
+	}
+
+	var got []blocks.Quantity
+
+	for _, transfer := range transfers {
+		got = append(got, transfer.LogIndex)
+	}
+
+	if fmt.Sprint(got) != "[0x0 0x1]" {
+		t.Errorf("GetTokenTransfers() log indices = %v, want [0x0 0x1]", got)
+	}
+
+	// One batch holds a filter for the senders and one for the recipients.
+	if calls := client.callCount("eth_getLogs"); calls != 2 {
+		t.Errorf("eth_getLogs calls = %d, want 2", calls)
+	}
+}
+
+// noLogs answers eth_getLogs for blocks whose logs do not matter to a test.
+func noLogs(_ []any) (any, error) {
+	return []any{}, nil
+}
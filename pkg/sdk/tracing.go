@@ -0,0 +1,242 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+// Tracer selects the node API used to trace the internal calls of transactions.
+type Tracer string
+
+const (
+	// TracerNone disables tracing, so internal transfers are not tracked.
+	TracerNone Tracer = "none"
+	// TracerCallTracer traces blocks with debug_traceBlockByNumber and the built-in callTracer.
+	TracerCallTracer Tracer = "callTracer"
+	// TracerTraceBlock traces blocks with the trace_block method of Erigon and Nethermind.
+	TracerTraceBlock Tracer = "trace_block"
+)
+
+// callFrame represents a call of the call tree returned by the callTracer.
+type callFrame struct {
+	Type string         `json:"type"`
+	From blocks.Address `json:"from"`
+	To   blocks.Address `json:"to"`
+	// Value is not set for delegate and static calls.
+	Value *blocks.Quantity `json:"value"`
+	Error string           `json:"error"`
+	Calls []callFrame      `json:"calls"`
+}
+
+// callTrace represents the call tree of a single transaction returned by the callTracer.
+type callTrace struct {
+	TxHash *blocks.Hash `json:"txHash"`
+	Result callFrame    `json:"result"`
+}
+
+// blockTrace represents a single call of the flat list of calls returned by trace_block.
+type blockTrace struct {
+	Action struct {
+		CallType      string           `json:"callType"`
+		From          blocks.Address   `json:"from"`
+		To            blocks.Address   `json:"to"`
+		Value         *blocks.Quantity `json:"value"`
+		Address       blocks.Address   `json:"address"`
+		RefundAddress blocks.Address   `json:"refundAddress"`
+		Balance       *blocks.Quantity `json:"balance"`
+	} `json:"action"`
+	Result *struct {
+		Address *blocks.Address `json:"address"`
+	} `json:"result"`
+	Error           string      `json:"error"`
+	TraceAddress    []int       `json:"traceAddress"`
+	TransactionHash blocks.Hash `json:"transactionHash"`
+	Type            string      `json:"type"`
+}
+
+// GetInternalTransfers returns the value transfers made by internal calls of the transactions contained in a block.
+// No transfers are returned if tracing is disabled. If the node does not implement the configured tracing API,
+// tracing is disabled and internal transfers are not tracked from then on.
+func (p *BlockParser) GetInternalTransfers(
+	ctx context.Context, block *blocks.Block) (_ []blocks.InternalTransfer, err error) {
+	ctx, span := startSpan(ctx, "BlockParser.GetInternalTransfers",
+		_attrBlockHash.String(block.Hash.Hex()), _attrTracer.String(string(p.config.tracer)))
+	defer endSpan(span, &err)
+
+	var transfers []blocks.InternalTransfer
+
+	if p.tracingUnsupported.Load() {
+		return nil, nil
+	}
+
+	switch p.config.tracer {
+	case TracerNone, "":
+		return nil, nil
+	case TracerCallTracer:
+		transfers, err = p.traceCalls(ctx, block)
+	case TracerTraceBlock:
+		transfers, err = p.traceBlock(ctx, block)
+	default:
+		return nil, fmt.Errorf("unknown tracer %q", p.config.tracer)
+	}
+
+	if jsonrpc.IsMethodNotFound(err) {
+		// The node does not implement the tracing API, so do not try it again.
+		if p.tracingUnsupported.CompareAndSwap(false, true) {
+			slog.WarnContext(ctx, "tracer is not supported, internal transfers are not tracked",
+				"tracer", p.config.tracer, "error", err)
+		}
+
+		return nil, nil
+	}
+
+	return transfers, err
+}
+
+// traceCalls walks the call trees returned by debug_traceBlockByNumber with the callTracer.
+func (p *BlockParser) traceCalls(ctx context.Context, block *blocks.Block) ([]blocks.InternalTransfer, error) {
+	var traces []callTrace
+
+	err := p.EthClient.CallFor(
+		ctx, &traces, "debug_traceBlockByNumber", block.Number, map[string]string{"tracer": "callTracer"})
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := make([]blocks.InternalTransfer, 0)
+
+	for i, trace := range traces {
+		var txHash blocks.Hash
+
+		// Older nodes do not return the transaction hash, but the traces follow the transaction order.
+		switch {
+		case trace.TxHash != nil:
+			txHash = *trace.TxHash
+		case i < len(block.Transactions):
+			txHash = block.Transactions[i].Hash
+		}
+
+		transfers = walkCallFrame(transfers, txHash, trace.Result, nil)
+	}
+
+	return transfers, nil
+}
+
+// walkCallFrame collects the value transfers of the subcalls of a call frame. Reverted calls are skipped
+// together with their subcalls, since none of their transfers took effect.
+func walkCallFrame(
+	transfers []blocks.InternalTransfer, txHash blocks.Hash,
+	frame callFrame, traceAddress []int) []blocks.InternalTransfer {
+	if frame.Error != "" {
+		return transfers
+	}
+
+	// The top level call is the transaction itself.
+	if len(traceAddress) > 0 && isValueTransferCall(frame.Type) && hasValue(frame.Value) {
+		transfers = append(transfers, blocks.InternalTransfer{
+			TransactionHash: txHash,
+			Type:            strings.ToUpper(frame.Type),
+			From:            frame.From,
+			To:              frame.To,
+			Value:           *frame.Value,
+			TraceAddress:    traceAddress,
+		})
+	}
+
+	for i, call := range frame.Calls {
+		transfers = walkCallFrame(transfers, txHash, call, append(slices.Clone(traceAddress), i))
+	}
+
+	return transfers
+}
+
+// traceBlock collects the value transfers from the flat list of calls returned by trace_block.
+func (p *BlockParser) traceBlock(ctx context.Context, block *blocks.Block) ([]blocks.InternalTransfer, error) {
+	var traces []blockTrace
+
+	if err := p.EthClient.CallFor(ctx, &traces, "trace_block", []blocks.Quantity{block.Number}); err != nil {
+		return nil, err
+	}
+
+	transfers := make([]blocks.InternalTransfer, 0)
+	reverted := make(map[blocks.Hash][][]int)
+
+	for _, trace := range traces {
+		if trace.Error != "" {
+			reverted[trace.TransactionHash] = append(reverted[trace.TransactionHash], trace.TraceAddress)
+
+			continue
+		}
+
+		// The top level call is the transaction itself and the calls of a reverted call took no effect.
+		if len(trace.TraceAddress) == 0 || isReverted(trace.TraceAddress, reverted[trace.TransactionHash]) {
+			continue
+		}
+
+		transfer := blocks.InternalTransfer{
+			TransactionHash: trace.TransactionHash,
+			From:            trace.Action.From,
+			To:              trace.Action.To,
+			TraceAddress:    trace.TraceAddress,
+		}
+		value := trace.Action.Value
+
+		switch trace.Type {
+		case "call":
+			transfer.Type = strings.ToUpper(trace.Action.CallType)
+		case "create":
+			transfer.Type = "CREATE"
+
+			if trace.Result != nil && trace.Result.Address != nil {
+				transfer.To = *trace.Result.Address
+			}
+		case "suicide":
+			transfer.Type = "SELFDESTRUCT"
+			transfer.From = trace.Action.Address
+			transfer.To = trace.Action.RefundAddress
+			value = trace.Action.Balance
+		default:
+			continue
+		}
+
+		if isValueTransferCall(transfer.Type) && hasValue(value) {
+			transfer.Value = *value
+			transfers = append(transfers, transfer)
+		}
+	}
+
+	return transfers, nil
+}
+
+// isValueTransferCall reports if a call of a given type moves value between accounts.
+// Delegate and static calls only carry over the value of their caller.
+func isValueTransferCall(callType string) bool {
+	switch strings.ToUpper(callType) {
+	case "CALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+		return true
+	default:
+		return false
+	}
+}
+
+// hasValue reports if a call carries a value which is not zero.
+func hasValue(value *blocks.Quantity) bool {
+	return value != nil && !value.IsZero()
+}
+
+// isReverted reports if a call is a subcall of any of given reverted calls.
+func isReverted(traceAddress []int, revertedTraceAddresses [][]int) bool {
+	for _, reverted := range revertedTraceAddresses {
+		if len(reverted) <= len(traceAddress) && slices.Equal(traceAddress[:len(reverted)], reverted) {
+			return true
+		}
+	}
+
+	return false
+}
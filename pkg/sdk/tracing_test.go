@@ -0,0 +1,123 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+func TestGetInternalTransfersCallTracer(t *testing.T) {
+	block := testBlock(16, 1)
+	client := newFakeRPCClient()
+
+	client.handle("debug_traceBlockByNumber", func(params []any) (any, error) {
+		// The transaction hash is left out as by older nodes, so it is taken from the block.
+		return []map[string]any{{
+			"result": map[string]any{
+				"type": "CALL", "value": "0x1",
+				"calls": []map[string]any{
+					{"type": "CALL", "value": "0x2", "calls": []map[string]any{
+						{"type": "DELEGATECALL", "value": "0x3"},
+						{"type": "CALL", "value": "0x0"},
+						{"type": "CREATE", "value": "0x4"},
+					}},
+					{"type": "CALL", "value": "0x5", "error": "execution reverted", "calls": []map[string]any{
+						{"type": "CALL", "value": "0x6"},
+					}},
+				},
+			},
+		}}, nil
+	})
+
+	parser := NewBlockParser(client, nil, nil, WithTracer(TracerCallTracer))
+
+	transfers, err := parser.GetInternalTransfers(context.Background(), block)
+	if err != nil {
+		t.Fatalf("GetInternalTransfers() error = %v", err)
+	}
+
+	var got []string
+
+	for _, transfer := range transfers {
+		if transfer.TransactionHash != block.Transactions[0].Hash {
+			t.Errorf("transfer of transaction %s, want %s", transfer.TransactionHash, block.Transactions[0].Hash)
+		}
+
+		got = append(got, fmt.Sprintf("%s %s %v", transfer.Type, transfer.Value, transfer.TraceAddress))
+	}
+
+	if want := "[CALL 0x2 [0] CREATE 0x4 [0 2]]"; fmt.Sprint(got) != want {
+		t.Errorf("GetInternalTransfers() = %v, want %s", got, want)
+	}
+}
+
+func TestGetInternalTransfersTraceBlock(t *testing.T) {
+	tests := []struct {
+		name            string
+		traceErr        error
+		wantErr         bool
+		wantTransfers   int
+		wantUnsupported bool
+	}{
+		{
+			name:          "transfers of reverted calls are skipped",
+			wantTransfers: 1,
+		},
+		{
+			name:            "method not found disables tracing",
+			traceErr:        &jsonrpc.RPCError{Code: jsonrpc.MethodNotFoundCode, Message: "method not found"},
+			wantUnsupported: true,
+		},
+		{
+			name:     "rate limit keeps tracing enabled",
+			traceErr: &jsonrpc.RPCError{Code: 429, Message: "too many requests"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := testBlock(16, 1)
+			txHash := block.Transactions[0].Hash.Hex()
+			client := newFakeRPCClient()
+
+			client.handle("trace_block", func(params []any) (any, error) {
+				if len(params) != 1 || params[0] != block.Number.Hex() {
+					return nil, &jsonrpc.RPCError{Code: jsonrpc.InvalidParamsCode, Message: "invalid params"}
+				}
+
+				if tt.traceErr != nil {
+					return nil, tt.traceErr
+				}
+
+				return []map[string]any{
+					{"type": "call", "traceAddress": []int{}, "transactionHash": txHash,
+						"action": map[string]any{"callType": "call", "value": "0x1"}},
+					{"type": "call", "traceAddress": []int{0}, "transactionHash": txHash,
+						"action": map[string]any{"callType": "call", "value": "0x2"}},
+					{"type": "call", "traceAddress": []int{1}, "transactionHash": txHash, "error": "Reverted",
+						"action": map[string]any{"callType": "call", "value": "0x3"}},
+					{"type": "call", "traceAddress": []int{1, 0}, "transactionHash": txHash,
+						"action": map[string]any{"callType": "call", "value": "0x4"}},
+				}, nil
+			})
+
+			parser := NewBlockParser(client, nil, nil, WithTracer(TracerTraceBlock))
+
+			transfers, err := parser.GetInternalTransfers(context.Background(), block)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetInternalTransfers() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if len(transfers) != tt.wantTransfers {
+				t.Errorf("GetInternalTransfers() = %+v, want %d transfers", transfers, tt.wantTransfers)
+			}
+
+			if got := parser.tracingUnsupported.Load(); got != tt.wantUnsupported {
+				t.Errorf("tracing unsupported = %v, want %v", got, tt.wantUnsupported)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package sdk
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+const _subscriberBufferSize = 64
+
+// TransactionBus fans out the transactions observed for subscribed addresses in process.
+//
+// Every published transaction is stored first, so that it gets its sequence, and then handed to the stream
+// subscribers asynchronously and to the notifiers synchronously. A subscriber which does not keep up is dropped
+// by closing its channel, since it can resume from the stored sequence of the last event it received.
+type TransactionBus struct {
+	SubsStore   SubscriptionsStore
+	notifiers   []TransactionNotifier
+	mu          sync.Mutex
+	subscribers map[*busSubscriber]bool
+}
+
+type busSubscriber struct {
+	address string
+	ch      chan blocks.TransactionEvent
+}
+
+// NewTransactionBus is a constructor function for TransactionBus.
+func NewTransactionBus(subsStore SubscriptionsStore, notifiers ...TransactionNotifier) *TransactionBus {
+	return &TransactionBus{
+		SubsStore:   subsStore,
+		notifiers:   notifiers,
+		subscribers: make(map[*busSubscriber]bool),
+	}
+}
+
+// PublishObservedTransaction stores a transaction observed for a subscribed address and passes it on
+// to the stream subscribers and the notifiers of the address.
+//
+// A transaction may be published again, e.g. because a block failed half way and is processed again.
+// The stream subscribers are passed a transaction right after it has been stored, which cannot fail, so a
+// transaction which is stored already has reached them. The notifiers are called for it again, since
+// a previous notification may have failed. Notifiers are idempotent, so nothing is notified twice.
+func (b *TransactionBus) PublishObservedTransaction(address string, tx blocks.ObservedTransaction) error {
+	stored, inserted, err := b.SubsStore.InsertObservedTransaction(address, tx)
+	if err != nil {
+		return err
+	}
+
+	if inserted {
+		b.broadcast(blocks.TransactionEvent{
+			Sequence:    stored.Sequence,
+			Address:     address,
+			Transaction: stored,
+		})
+	}
+
+	for _, notifier := range b.notifiers {
+		if err = notifier.NotifyObservedTransaction(address, stored); err != nil {
+			return fmt.Errorf("could not notify observed transaction %s: %w", stored.ID(), err)
+		}
+	}
+
+	return nil
+}
+
+// RetractObservedTransaction passes the retraction of a transaction observed for a subscribed address on
+// to the stream subscribers and the notifiers of the address, before the transaction is removed since its
+// block was orphaned. Retractions are not stored, so they only reach the stream subscribers connected live.
+func (b *TransactionBus) RetractObservedTransaction(address string, tx blocks.ObservedTransaction) error {
+	b.broadcast(blocks.TransactionEvent{
+		Sequence:    tx.Sequence,
+		Address:     address,
+		Transaction: tx,
+		Retracted:   true,
+	})
+
+	for _, notifier := range b.notifiers {
+		if err := notifier.NotifyRetractedTransaction(address, tx); err != nil {
+			return fmt.Errorf("could not notify retracted transaction %s: %w", tx.ID(), err)
+		}
+	}
+
+	return nil
+}
+
+// PublishReorg passes a chain reorganization on to all stream subscribers regardless of their address.
+// The transactions of the orphaned blocks are retracted before, so the reorg only tells what happened to the chain.
+// Reorgs are not stored, so they only reach the stream subscribers connected live.
+func (b *TransactionBus) PublishReorg(event blocks.ReorgEvent) {
+	b.broadcast(blocks.TransactionEvent{Reorg: &event})
+}
+
+// broadcast passes an event on to the stream subscribers of its address without blocking.
+// Reorgs are passed on to all stream subscribers.
+func (b *TransactionBus) broadcast(event blocks.TransactionEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subscribers {
+		if event.Reorg == nil && s.address != "" && s.address != event.Address {
+			continue
+		}
+
+		select {
+		case s.ch <- event:
+		default:
+			b.remove(s)
+		}
+	}
+}
+
+// SubscribeTransactions returns a channel of the transactions published for an address from now on
+// together with a function canceling the subscription. An empty address subscribes for the transactions
+// of all subscribed addresses. The channel is closed when the subscription is canceled or falls behind.
+func (b *TransactionBus) SubscribeTransactions(address string) (<-chan blocks.TransactionEvent, func()) {
+	s := &busSubscriber{
+		address: address,
+		ch:      make(chan blocks.TransactionEvent, _subscriberBufferSize),
+	}
+
+	b.mu.Lock()
+	b.subscribers[s] = true
+	b.mu.Unlock()
+
+	return s.ch, func() {
+		b.mu.Lock()
+		b.remove(s)
+		b.mu.Unlock()
+	}
+}
+
+// GetTransactionEventsAfter returns up to limit stored transactions with a sequence greater than a given one
+// in sequence order. An empty address matches the observed transactions of all subscribed addresses.
+func (b *TransactionBus) GetTransactionEventsAfter(
+	address string, sequence uint64, limit int) ([]blocks.TransactionEvent, error) {
+	return b.SubsStore.GetTransactionEventsAfter(address, sequence, limit)
+}
+
+// remove closes the channel of a subscriber unless it has been removed already. The caller must hold the lock.
+func (b *TransactionBus) remove(s *busSubscriber) {
+	if b.subscribers[s] {
+		delete(b.subscribers, s)
+		close(s.ch)
+	}
+}
@@ -0,0 +1,208 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/storage/memory"
+)
+
+// failingNotifier fails a given number of notifications before it passes them on to the next notifier.
+type failingNotifier struct {
+	failures int
+	next     TransactionNotifier
+}
+
+func (n *failingNotifier) NotifyObservedTransaction(address string, tx blocks.ObservedTransaction) error {
+	if n.failures > 0 {
+		n.failures--
+
+		return errors.New("notifier is down")
+	}
+
+	return n.next.NotifyObservedTransaction(address, tx)
+}
+
+func (n *failingNotifier) NotifyRetractedTransaction(address string, tx blocks.ObservedTransaction) error {
+	return n.next.NotifyRetractedTransaction(address, tx)
+}
+
+func TestPublishObservedTransaction(t *testing.T) {
+	const otherAddress = "0x00000000000000000000000000000000000000bb"
+
+	tests := []struct {
+		name          string
+		address       string
+		wantSequences string
+	}{
+		{name: "subscriber of an address", address: _testAddress, wantSequences: "[1 3]"},
+		{name: "subscriber of all addresses", wantSequences: "[1 2 3]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewTransactionBus(memory.NewSubscriptionsRepository())
+			events, cancel := bus.SubscribeTransactions(tt.address)
+
+			for i, address := range []string{_testAddress, otherAddress, _testAddress} {
+				if err := bus.PublishObservedTransaction(address, testObservedTransaction(1, i)); err != nil {
+					t.Fatalf("PublishObservedTransaction() error = %v", err)
+				}
+			}
+
+			cancel()
+
+			var sequences []uint64
+
+			for event := range events {
+				sequences = append(sequences, event.Sequence)
+			}
+
+			if fmt.Sprint(sequences) != tt.wantSequences {
+				t.Errorf("streamed sequences %v, want %s", sequences, tt.wantSequences)
+			}
+
+			replayed, err := bus.GetTransactionEventsAfter(tt.address, 1, 10)
+			if err != nil {
+				t.Fatalf("GetTransactionEventsAfter() error = %v", err)
+			}
+
+			if want := len(sequences) - 1; len(replayed) != want {
+				t.Errorf("GetTransactionEventsAfter() returned %d events, want %d", len(replayed), want)
+			}
+		})
+	}
+}
+
+func TestPublishReorg(t *testing.T) {
+	bus := NewTransactionBus(memory.NewSubscriptionsRepository())
+	addressEvents, cancelAddress := bus.SubscribeTransactions(_testAddress)
+	allEvents, cancelAll := bus.SubscribeTransactions("")
+
+	defer cancelAddress()
+	defer cancelAll()
+
+	bus.PublishReorg(blocks.ReorgEvent{Depth: 1, CommonAncestor: 9})
+
+	for name, events := range map[string]<-chan blocks.TransactionEvent{"address": addressEvents, "all": allEvents} {
+		select {
+		case event := <-events:
+			if event.Reorg == nil || event.Reorg.CommonAncestor != 9 {
+				t.Errorf("subscriber of %s received %+v, want the reorg", name, event)
+			}
+		default:
+			t.Errorf("subscriber of %s did not receive the reorg", name)
+		}
+	}
+}
+
+func TestPublishObservedTransactionRetry(t *testing.T) {
+	tests := []struct {
+		name           string
+		publishes      int
+		failures       int
+		wantErrors     int
+		wantEvents     int
+		wantDeliveries int
+	}{
+		{
+			name:           "published once",
+			publishes:      1,
+			wantEvents:     1,
+			wantDeliveries: 1,
+		},
+		{
+			name:           "published again",
+			publishes:      3,
+			wantEvents:     1,
+			wantDeliveries: 1,
+		},
+		{
+			name:           "published again after a failed notification",
+			publishes:      2,
+			failures:       1,
+			wantErrors:     1,
+			wantEvents:     1,
+			wantDeliveries: 1,
+		},
+		{
+			name:       "notification keeps failing",
+			publishes:  2,
+			failures:   2,
+			wantErrors: 2,
+			wantEvents: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subsStore := memory.NewSubscriptionsRepository()
+			webhookStore := memory.NewWebhooksRepository()
+			dispatcher := NewWebhookDispatcher(subsStore, webhookStore)
+
+			if err := subsStore.InsertSubscriberAddress(_testAddress); err != nil {
+				t.Fatalf("InsertSubscriberAddress() error = %v", err)
+			}
+
+			if _, _, err := dispatcher.RegisterWebhook(_testAddress, "http://localhost/hook"); err != nil {
+				t.Fatalf("RegisterWebhook() error = %v", err)
+			}
+
+			bus := NewTransactionBus(subsStore, &failingNotifier{failures: tt.failures, next: dispatcher})
+			events, cancel := bus.SubscribeTransactions(_testAddress)
+
+			defer cancel()
+
+			tx := testObservedTransaction(1, 0)
+			errs := 0
+
+			for i := 0; i < tt.publishes; i++ {
+				if err := bus.PublishObservedTransaction(_testAddress, tx); err != nil {
+					errs++
+				}
+			}
+
+			if errs != tt.wantErrors {
+				t.Errorf("PublishObservedTransaction() failed %d times, want %d", errs, tt.wantErrors)
+			}
+
+			if got := len(events); got != tt.wantEvents {
+				t.Errorf("streamed %d events, want %d", got, tt.wantEvents)
+			}
+
+			_, deliveries, err := webhookStore.GetDeliveriesPerAddress(_testAddress, "", 0, 10)
+			if err != nil {
+				t.Fatalf("GetDeliveriesPerAddress() error = %v", err)
+			}
+
+			if deliveries != tt.wantDeliveries {
+				t.Errorf("enqueued %d deliveries, want %d", deliveries, tt.wantDeliveries)
+			}
+		})
+	}
+}
+
+func TestSubscriberFallingBehindIsDropped(t *testing.T) {
+	bus := NewTransactionBus(memory.NewSubscriptionsRepository())
+	events, cancel := bus.SubscribeTransactions("")
+
+	defer cancel()
+
+	for i := 0; i <= _subscriberBufferSize; i++ {
+		if err := bus.PublishObservedTransaction(_testAddress, testObservedTransaction(1, i)); err != nil {
+			t.Fatalf("PublishObservedTransaction() error = %v", err)
+		}
+	}
+
+	received := 0
+
+	for range events {
+		received++
+	}
+
+	if received != _subscriberBufferSize {
+		t.Errorf("received %d events before the channel was closed, want %d", received, _subscriberBufferSize)
+	}
+}
@@ -0,0 +1,397 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/httpx"
+)
+
+const (
+	_deliveryBatchSize   = 100
+	_webhookSecretLength = 32
+	_webhookIDLength     = 8
+
+	// WebhookSignatureHeader carries the hex encoded HMAC-SHA256 of the payload keyed with the address secret.
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	// WebhookDeliveryHeader carries the ID of a delivery, which stays the same across its attempts.
+	WebhookDeliveryHeader = "X-Webhook-Delivery"
+)
+
+var (
+	// ErrNotSubscribed is returned when managing webhooks of an address which is not subscribed.
+	ErrNotSubscribed = errors.New("address is not subscribed")
+	// ErrInvalidWebhookURL is returned when registering a webhook with a URL which is not an absolute HTTP(S) URL.
+	ErrInvalidWebhookURL = errors.New("webhook URL must be an absolute http or https URL")
+)
+
+// WebhookDispatcher delivers the transactions observed for subscribed addresses to their webhooks.
+//
+// Every observed transaction is stored as a pending delivery per webhook before it is attempted, so that
+// deliveries survive restarts with a persistent store. Failed deliveries are retried with exponential backoff
+// and dead-lettered after a maximum number of attempts.
+type WebhookDispatcher struct {
+	SubsStore    SubscriptionsStore
+	WebhookStore WebhookStore
+	httpClient   *httpx.Client
+	config       *webhookConfig
+	wakeCh       chan struct{}
+}
+
+// NewWebhookDispatcher is a constructor function for WebhookDispatcher.
+func NewWebhookDispatcher(
+	subsStore SubscriptionsStore,
+	webhookStore WebhookStore,
+	opts ...WebhookOption,
+) *WebhookDispatcher {
+	config := newWebhookDefaultConfig()
+
+	config.applyOptions(opts...)
+
+	return &WebhookDispatcher{
+		SubsStore:    subsStore,
+		WebhookStore: webhookStore,
+		httpClient:   httpx.NewClient(httpx.WithCustomRequestTimeout(int(math.Ceil(config.timeout.Seconds())))),
+		config:       config,
+		wakeCh:       make(chan struct{}, 1),
+	}
+}
+
+// RegisterWebhook registers a webhook URL for a subscribed address. It returns the webhook together with
+// the secret used to sign all payloads delivered for the address.
+func (d *WebhookDispatcher) RegisterWebhook(address, webhookURL string) (blocks.Webhook, string, error) {
+	parsedURL, err := url.Parse(webhookURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return blocks.Webhook{}, "", ErrInvalidWebhookURL
+	}
+
+	address = strings.ToLower(address)
+
+	if err = d.ensureSubscribed(address); err != nil {
+		return blocks.Webhook{}, "", err
+	}
+
+	id, err := randomHex(_webhookIDLength)
+	if err != nil {
+		return blocks.Webhook{}, "", err
+	}
+
+	secret, err := d.secret(address)
+	if err != nil {
+		return blocks.Webhook{}, "", err
+	}
+
+	webhook := blocks.Webhook{
+		ID:        id,
+		Address:   address,
+		URL:       webhookURL,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err = d.WebhookStore.InsertWebhook(webhook); err != nil {
+		return blocks.Webhook{}, "", err
+	}
+
+	return webhook, secret, nil
+}
+
+// GetWebhooks returns all webhooks registered for a subscribed address.
+func (d *WebhookDispatcher) GetWebhooks(address string) ([]blocks.Webhook, error) {
+	address = strings.ToLower(address)
+
+	if err := d.ensureSubscribed(address); err != nil {
+		return nil, err
+	}
+
+	return d.WebhookStore.GetWebhooksPerAddress(address)
+}
+
+// RemoveWebhook removes a webhook of a subscribed address. It returns false if the webhook does not exist.
+// Pending deliveries to the webhook are dead-lettered on their next attempt.
+func (d *WebhookDispatcher) RemoveWebhook(address, id string) (bool, error) {
+	address = strings.ToLower(address)
+
+	if err := d.ensureSubscribed(address); err != nil {
+		return false, err
+	}
+
+	return d.WebhookStore.RemoveWebhook(address, id)
+}
+
+// RemoveWebhooks removes all webhooks of an address and optionally its delivery log.
+func (d *WebhookDispatcher) RemoveWebhooks(address string, purge bool) error {
+	return d.WebhookStore.RemoveWebhooksPerAddress(strings.ToLower(address), purge)
+}
+
+// GetDeliveries returns a page of the delivery log of a subscribed address together with the total number
+// of deliveries. An empty status matches deliveries of any status.
+func (d *WebhookDispatcher) GetDeliveries(
+	address string, status blocks.DeliveryStatus, offset, limit int) ([]blocks.WebhookDelivery, int, error) {
+	address = strings.ToLower(address)
+
+	if err := d.ensureSubscribed(address); err != nil {
+		return nil, 0, err
+	}
+
+	return d.WebhookStore.GetDeliveriesPerAddress(address, status, offset, limit)
+}
+
+// NotifyObservedTransaction enqueues a pending delivery of an observed transaction for every webhook
+// registered for the address. A transaction is enqueued once per webhook, so notifying it again only enqueues
+// the deliveries missed by a previous notification which failed half way.
+func (d *WebhookDispatcher) NotifyObservedTransaction(address string, tx blocks.ObservedTransaction) error {
+	return d.enqueue(address, blocks.WebhookPayload{Address: address, Transaction: tx})
+}
+
+// NotifyRetractedTransaction enqueues a pending delivery of the retraction of an observed transaction for every
+// webhook registered for the address. A retraction is enqueued once per webhook and orphaned block.
+func (d *WebhookDispatcher) NotifyRetractedTransaction(address string, tx blocks.ObservedTransaction) error {
+	return d.enqueue(address, blocks.WebhookPayload{Address: address, Transaction: tx, Retracted: true})
+}
+
+// enqueue enqueues a pending delivery of a payload for every webhook registered for the address and wakes up
+// the delivery loop if any delivery was not enqueued before.
+func (d *WebhookDispatcher) enqueue(address string, payload blocks.WebhookPayload) error {
+	webhooks, err := d.WebhookStore.GetWebhooksPerAddress(address)
+	if err != nil {
+		return err
+	}
+
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	enqueued := false
+
+	for _, webhook := range webhooks {
+		_, inserted, errInsert := d.WebhookStore.InsertDelivery(blocks.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Address:       address,
+			URL:           webhook.URL,
+			Payload:       payload,
+			Status:        blocks.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+		if errInsert != nil {
+			return errInsert
+		}
+
+		enqueued = enqueued || inserted
+	}
+
+	if !enqueued {
+		return nil
+	}
+
+	// Wake up the delivery loop without blocking, a pending wake up covers the new deliveries as well.
+	select {
+	case d.wakeCh <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Run attempts the due deliveries until the context is canceled. Deliveries are attempted as soon as
+// they are enqueued and otherwise polled for due retries. Failures of the webhooks are recorded on the
+// deliveries themselves. A failure of the store is returned, so that the dispatcher is restarted with backoff.
+func (d *WebhookDispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.config.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-d.wakeCh:
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue attempts all deliveries whose next attempt is due.
+func (d *WebhookDispatcher) DeliverDue(ctx context.Context) error {
+	for ctx.Err() == nil {
+		deliveries, err := d.WebhookStore.GetDueDeliveries(time.Now().UTC(), _deliveryBatchSize)
+		if err != nil {
+			return fmt.Errorf("could not get due webhook deliveries: %w", err)
+		}
+
+		for _, delivery := range deliveries {
+			if err = d.deliver(ctx, delivery); err != nil {
+				return fmt.Errorf("could not update webhook delivery %s: %w", delivery.ID, err)
+			}
+		}
+
+		if len(deliveries) < _deliveryBatchSize {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// deliver makes a single attempt of a delivery and records its outcome.
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery blocks.WebhookDelivery) error {
+	webhooks, err := d.WebhookStore.GetWebhooksPerAddress(delivery.Address)
+	if err != nil {
+		return err
+	}
+
+	registered := false
+
+	for _, webhook := range webhooks {
+		registered = registered || webhook.ID == delivery.WebhookID
+	}
+
+	if !registered {
+		delivery.Status = blocks.DeliveryDeadLetter
+		delivery.LastError = "webhook has been removed"
+		delivery.UpdatedAt = time.Now().UTC()
+
+		return d.WebhookStore.UpdateDelivery(delivery)
+	}
+
+	// The secret is created together with the first webhook of the address, so it is only looked up here.
+	secret, err := d.secret(delivery.Address)
+	if err != nil {
+		return err
+	}
+
+	statusCode, errPost := d.post(ctx, delivery, secret)
+	if ctx.Err() != nil {
+		// The attempt was interrupted by a shutdown rather than failed by the webhook.
+		return nil
+	}
+
+	now := time.Now().UTC()
+
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.UpdatedAt = now
+
+	switch {
+	case errPost == nil:
+		delivery.Status = blocks.DeliveryDelivered
+		delivery.LastError = ""
+	case delivery.Attempts >= d.config.maxAttempts:
+		delivery.Status = blocks.DeliveryDeadLetter
+		delivery.LastError = errPost.Error()
+	default:
+		delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+		delivery.LastError = errPost.Error()
+	}
+
+	return d.WebhookStore.UpdateDelivery(delivery)
+}
+
+// post sends the signed payload of a delivery to its webhook and returns the HTTP status of the response.
+// Any response other than 2xx is treated as a failure.
+func (d *WebhookDispatcher) post(ctx context.Context, delivery blocks.WebhookDelivery, secret string) (int, error) {
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := httpx.PostRequest(ctx, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(secret, body))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt of a delivery which has failed a given number of times.
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.initialBackoff
+
+	for i := 1; i < attempts && delay < d.config.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > d.config.maxBackoff {
+		return d.config.maxBackoff
+	}
+
+	return delay
+}
+
+// secret returns the signing secret of an address and generates it if the address has none yet.
+func (d *WebhookDispatcher) secret(address string) (string, error) {
+	secret, err := randomHex(_webhookSecretLength)
+	if err != nil {
+		return "", err
+	}
+
+	return d.WebhookStore.GetOrCreateSecret(address, secret)
+}
+
+func (d *WebhookDispatcher) ensureSubscribed(address string) error {
+	subscription, err := d.SubsStore.GetSubscription(address)
+	if err != nil {
+		return err
+	}
+
+	if subscription == nil {
+		return fmt.Errorf("%w: %s", ErrNotSubscribed, address)
+	}
+
+	return nil
+}
+
+// SignWebhookPayload returns the hex encoded HMAC-SHA256 of a webhook payload keyed with the address secret.
+// Receivers verify a delivery by comparing it with the value of the signature header after the "sha256=" prefix.
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomHex(length int) (string, error) {
+	b := make([]byte, length)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
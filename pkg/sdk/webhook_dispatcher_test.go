@@ -0,0 +1,167 @@
+package sdk
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+	"github.com/powerslider/ethereum-block-scanner/pkg/storage/memory"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		payload string
+		want    string
+	}{
+		{
+			name:    "RFC 4231 test case 2",
+			secret:  "Jefe",
+			payload: "what do ya want for nothing?",
+			want:    "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+		{
+			name:    "empty payload",
+			secret:  "key",
+			payload: "",
+			want:    "5d5d139563c95b5967b9bd9a8c9b233a9dedb45072794cd232dc1b74832607d0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignWebhookPayload(tt.secret, []byte(tt.payload)); got != tt.want {
+				t.Errorf("SignWebhookPayload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliverDue(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		maxAttempts  int
+		wantStatus   blocks.DeliveryStatus
+		wantAttempts int
+		wantBackoff  bool
+	}{
+		{
+			name:         "acknowledged delivery",
+			status:       http.StatusNoContent,
+			maxAttempts:  3,
+			wantStatus:   blocks.DeliveryDelivered,
+			wantAttempts: 1,
+		},
+		{
+			name:         "failed delivery is retried",
+			status:       http.StatusInternalServerError,
+			maxAttempts:  3,
+			wantStatus:   blocks.DeliveryPending,
+			wantAttempts: 1,
+			wantBackoff:  true,
+		},
+		{
+			name:         "failed delivery is dead-lettered after the last attempt",
+			status:       http.StatusBadGateway,
+			maxAttempts:  1,
+			wantStatus:   blocks.DeliveryDeadLetter,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSignature, gotDeliveryID string
+
+			var gotBody []byte
+
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				gotSignature = r.Header.Get(WebhookSignatureHeader)
+				gotDeliveryID = r.Header.Get(WebhookDeliveryHeader)
+				gotBody, _ = io.ReadAll(r.Body)
+
+				rw.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			subsStore := memory.NewSubscriptionsRepository()
+			webhookStore := memory.NewWebhooksRepository()
+			dispatcher := NewWebhookDispatcher(subsStore, webhookStore,
+				WithDeliveryMaxAttempts(tt.maxAttempts), WithDeliveryBackoff(time.Minute, time.Hour))
+
+			if err := subsStore.InsertSubscriberAddress(_testAddress); err != nil {
+				t.Fatalf("InsertSubscriberAddress() error = %v", err)
+			}
+
+			_, secret, err := dispatcher.RegisterWebhook(_testAddress, server.URL)
+			if err != nil {
+				t.Fatalf("RegisterWebhook() error = %v", err)
+			}
+
+			if err = dispatcher.NotifyObservedTransaction(_testAddress, testObservedTransaction(1, 0)); err != nil {
+				t.Fatalf("NotifyObservedTransaction() error = %v", err)
+			}
+
+			start := time.Now().UTC()
+
+			if err = dispatcher.DeliverDue(context.Background()); err != nil {
+				t.Fatalf("DeliverDue() error = %v", err)
+			}
+
+			deliveries, _, err := webhookStore.GetDeliveriesPerAddress(_testAddress, "", 0, 10)
+			if err != nil || len(deliveries) != 1 {
+				t.Fatalf("GetDeliveriesPerAddress() = %v, %v, want a single delivery", deliveries, err)
+			}
+
+			delivery := deliveries[0]
+
+			if want := "sha256=" + SignWebhookPayload(secret, gotBody); gotSignature != want {
+				t.Errorf("signature header = %s, want %s", gotSignature, want)
+			}
+
+			if gotDeliveryID != delivery.ID {
+				t.Errorf("delivery header = %s, want %s", gotDeliveryID, delivery.ID)
+			}
+
+			if delivery.Status != tt.wantStatus || delivery.Attempts != tt.wantAttempts {
+				t.Errorf("delivery status = %s after %d attempts, want %s after %d attempts",
+					delivery.Status, delivery.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+
+			if delivery.LastStatusCode != tt.status {
+				t.Errorf("last status code = %d, want %d", delivery.LastStatusCode, tt.status)
+			}
+
+			if backoff := delivery.NextAttemptAt.Sub(start); tt.wantBackoff && backoff < time.Minute {
+				t.Errorf("next attempt in %v, want a backoff of at least a minute", backoff)
+			}
+		})
+	}
+}
+
+func TestDeliveryBackoff(t *testing.T) {
+	dispatcher := NewWebhookDispatcher(nil, nil, WithDeliveryBackoff(time.Second, 10*time.Second))
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 5, want: 10 * time.Second},
+		{attempts: 50, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := dispatcher.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	pkgErrors "github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const _openTimeout = 5 * time.Second
+
+var (
+	_subscriptionsBucket        = []byte("subscriptions")
+	_observedTransactionsBucket = []byte("observed_transactions")
+	_observedSequencesBucket    = []byte("observed_transaction_sequences")
+	_observedStatusesBucket     = []byte("observed_transaction_statuses")
+	_tokenTransfersBucket       = []byte("token_transfers")
+	_inboundTransactionsBucket  = []byte("inbound_transactions")
+	_outboundTransactionsBucket = []byte("outbound_transactions")
+	_scannedRangesBucket        = []byte("scanned_ranges")
+	_processedBlocksBucket      = []byte("processed_blocks")
+	_webhooksBucket             = []byte("webhooks")
+	_webhookSecretsBucket       = []byte("webhook_secrets")
+	_webhookDeliveriesBucket    = []byte("webhook_deliveries")
+	_webhookDeliveryKeysBucket  = []byte("webhook_delivery_keys")
+	_pendingDeliveriesBucket    = []byte("pending_webhook_deliveries")
+	_metaBucket                 = []byte("meta")
+
+	_lastProcessedBlockKey = []byte("last_processed_block")
+	_schemaVersionKey      = []byte("schema_version")
+	_budgetUsageKey        = []byte("rpc_budget_usage")
+)
+
+// DB represents an embedded bbolt database file shared by all bolt repositories.
+type DB struct {
+	bolt *bolt.DB
+}
+
+// Open opens or creates a bbolt database file at a given path and makes sure all top level buckets exist.
+func Open(path string) (*DB, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: _openTimeout})
+	if err != nil {
+		return nil, pkgErrors.Wrapf(err, "could not open bolt database %s", path)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		buckets := [][]byte{
+			_subscriptionsBucket,
+			_observedTransactionsBucket,
+			_observedSequencesBucket,
+			_observedStatusesBucket,
+			_tokenTransfersBucket,
+			_inboundTransactionsBucket,
+			_outboundTransactionsBucket,
+			_scannedRangesBucket,
+			_processedBlocksBucket,
+			_webhooksBucket,
+			_webhookSecretsBucket,
+			_webhookDeliveriesBucket,
+			_webhookDeliveryKeysBucket,
+			_pendingDeliveriesBucket,
+			_metaBucket,
+		}
+
+		for _, name := range buckets {
+			if _, errBucket := tx.CreateBucketIfNotExists(name); errBucket != nil {
+				return errBucket
+			}
+		}
+
+		return migrate(tx, path)
+	})
+	if err != nil {
+		return nil, pkgErrors.Wrapf(errors.Join(err, db.Close()), "could not initialize bolt database %s", path)
+	}
+
+	return &DB{bolt: db}, nil
+}
+
+// Close releases the database file. Every committed transaction has been synced to disk already,
+// so there is nothing left to flush.
+func (d *DB) Close() error {
+	return pkgErrors.WithStack(d.bolt.Close())
+}
+
+// Sizes returns the number of stored entries per kind, i.e. subscriptions, transactions, token transfers,
+// webhooks and deliveries. Entries kept in per address buckets are summed up over all addresses.
+func (d *DB) Sizes() (map[string]int, error) {
+	buckets := [][]byte{
+		_subscriptionsBucket,
+		_observedTransactionsBucket,
+		_tokenTransfersBucket,
+		_inboundTransactionsBucket,
+		_outboundTransactionsBucket,
+		_webhooksBucket,
+		_webhookDeliveriesBucket,
+		_pendingDeliveriesBucket,
+	}
+
+	sizes := make(map[string]int, len(buckets))
+
+	err := d.bolt.View(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			sizes[string(name)] = countValues(tx.Bucket(name))
+		}
+
+		return nil
+	})
+
+	return sizes, err
+}
+
+func encodeInt(i int) []byte {
+	return []byte(strconv.Itoa(i))
+}
+
+func decodeInt(value []byte, defaultValue int) (int, error) {
+	if value == nil {
+		return defaultValue, nil
+	}
+
+	return strconv.Atoi(string(value))
+}
+
+// putJSON stores a value under a given key of a bucket.
+func putJSON(bucket *bolt.Bucket, key []byte, value any) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return bucket.Put(key, valueBytes)
+}
+
+// decodeValues decodes all values of a bucket in key order. A nil bucket results in an empty slice.
+func decodeValues[T any](bucket *bolt.Bucket) ([]T, error) {
+	values := make([]T, 0)
+
+	if bucket == nil {
+		return values, nil
+	}
+
+	err := bucket.ForEach(func(_, valueBytes []byte) error {
+		var value T
+
+		if err := json.Unmarshal(valueBytes, &value); err != nil {
+			return err
+		}
+
+		values = append(values, value)
+
+		return nil
+	})
+
+	return values, err
+}
+
+// countValues returns the number of values stored in a bucket and all of its nested buckets. The counts are
+// read from the page headers rather than by iterating the keys. Nested buckets are stored as keys of their
+// parent bucket, so they are subtracted from the key count.
+func countValues(bucket *bolt.Bucket) int {
+	stats := bucket.Stats()
+
+	return stats.KeyN - (stats.BucketN - 1)
+}
@@ -0,0 +1,254 @@
+package boltdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	db, err := Open(filepath.Join(t.TempDir(), "scanner.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
+
+func TestInsertIsIdempotent(t *testing.T) {
+	observedTx := testObservedTransaction(1, 0)
+	transfer := testTokenTransfer()
+
+	tests := []struct {
+		name   string
+		bucket []byte
+		insert func(db *DB) error
+	}{
+		{
+			name:   "observed transaction",
+			bucket: _observedTransactionsBucket,
+			insert: func(db *DB) error {
+				_, _, err := NewSubscriptionsRepository(db).InsertObservedTransaction(_testAddress, observedTx)
+
+				return err
+			},
+		},
+		{
+			name:   "token transfer",
+			bucket: _tokenTransfersBucket,
+			insert: func(db *DB) error {
+				return NewSubscriptionsRepository(db).InsertTokenTransfer(_testAddress, transfer)
+			},
+		},
+		{
+			name:   "inbound transaction",
+			bucket: _inboundTransactionsBucket,
+			insert: func(db *DB) error {
+				return NewTransactionsRepository(db).Insert(_testAddress, 1, observedTx.Transaction, true)
+			},
+		},
+		{
+			name:   "outbound transaction",
+			bucket: _outboundTransactionsBucket,
+			insert: func(db *DB) error {
+				return NewTransactionsRepository(db).Insert(_testAddress, 1, observedTx.Transaction, false)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+
+			for i := 0; i < 3; i++ {
+				if err := tt.insert(db); err != nil {
+					t.Fatalf("insert #%d error = %v", i, err)
+				}
+			}
+
+			if got := countEntries(t, db, tt.bucket); got != 1 {
+				t.Errorf("stored %d entries after inserting the same one 3 times, want 1", got)
+			}
+		})
+	}
+}
+
+func TestMigrateCompactsDuplicates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scanner.db")
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	observedTx := testObservedTransaction(1, 0)
+	transfer := testTokenTransfer()
+	buckets := map[string]any{
+		string(_observedTransactionsBucket): observedTx,
+		string(_tokenTransfersBucket):       transfer,
+		string(_inboundTransactionsBucket):  observedTx.Transaction,
+		string(_outboundTransactionsBucket): observedTx.Transaction,
+	}
+
+	// Turn the database into one written before entries were keyed by their identity, when every insert
+	// appended a new entry under the next sequence.
+	err = db.bolt.Update(func(tx *bolt.Tx) error {
+		for name, value := range buckets {
+			bucket, errBucket := tx.Bucket([]byte(name)).CreateBucketIfNotExists([]byte(_testAddress))
+			if errBucket != nil {
+				return errBucket
+			}
+
+			for i := 0; i < 3; i++ {
+				if errPut := putJSON(bucket, encodeInt(i), value); errPut != nil {
+					return errPut
+				}
+			}
+		}
+
+		return tx.Bucket(_metaBucket).Put(_schemaVersionKey, encodeInt(0))
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	defer db.Close()
+
+	for name := range buckets {
+		if got := countEntries(t, db, []byte(name)); got != 1 {
+			t.Errorf("%s holds %d entries after migration, want 1", name, got)
+		}
+	}
+}
+
+func TestCountValues(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     int
+		nested     map[string]int
+		wantValues int
+	}{
+		{
+			name: "empty bucket",
+		},
+		{
+			name:       "flat bucket",
+			values:     3,
+			wantValues: 3,
+		},
+		{
+			name:       "nested buckets",
+			nested:     map[string]int{"0xa": 2, "0xb": 5},
+			wantValues: 7,
+		},
+		{
+			name:       "empty nested bucket",
+			values:     1,
+			nested:     map[string]int{"0xa": 0},
+			wantValues: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+
+			err := db.bolt.Update(func(tx *bolt.Tx) error {
+				bucket, err := tx.CreateBucket([]byte("test"))
+				if err != nil {
+					return err
+				}
+
+				if err = putValues(bucket, tt.values); err != nil {
+					return err
+				}
+
+				for name, n := range tt.nested {
+					nestedBucket, errBucket := bucket.CreateBucket([]byte(name))
+					if errBucket != nil {
+						return errBucket
+					}
+
+					if err = putValues(nestedBucket, n); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			_ = db.bolt.View(func(tx *bolt.Tx) error {
+				if got := countValues(tx.Bucket([]byte("test"))); got != tt.wantValues {
+					t.Errorf("countValues() = %d, want %d", got, tt.wantValues)
+				}
+
+				return nil
+			})
+		})
+	}
+}
+
+func putValues(bucket *bolt.Bucket, n int) error {
+	for i := 0; i < n; i++ {
+		if err := bucket.Put(encodeInt(i), []byte("value")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// countEntries returns the number of entries stored in the per address buckets of a root bucket.
+func countEntries(t *testing.T, db *DB, name []byte) int {
+	t.Helper()
+
+	n := 0
+
+	err := db.bolt.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(name).ForEach(func(address, _ []byte) error {
+			n += tx.Bucket(name).Bucket(address).Stats().KeyN
+
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+
+	return n
+}
+
+// testTokenTransfer returns a token transfer to the test address.
+func testTokenTransfer() blocks.TokenTransfer {
+	to, _ := blocks.ParseAddress(_testAddress)
+
+	return blocks.TokenTransfer{
+		To:              to,
+		Amount:          blocks.NewQuantity(1),
+		BlockHash:       blocks.Hash{1},
+		BlockNumber:     blocks.NewQuantity(1),
+		LogIndex:        blocks.NewQuantity(0),
+		TransactionHash: blocks.Hash{1, 1},
+	}
+}
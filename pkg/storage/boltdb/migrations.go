@@ -0,0 +1,241 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+// _schemaVersion is the version of the layout of the database. Databases created with an older layout
+// are migrated when they are opened.
+const _schemaVersion = 6
+
+// migrate brings a database created with an older layout up to the current schema version.
+func migrate(tx *bolt.Tx, path string) error {
+	meta := tx.Bucket(_metaBucket)
+
+	version, err := decodeInt(meta.Get(_schemaVersionKey), 0)
+	if err != nil {
+		return err
+	}
+
+	if version < 1 {
+		removed, errCompact := compact(tx)
+		if errCompact != nil {
+			return errCompact
+		}
+
+		if removed > 0 {
+			slog.Info("removed duplicate entries from bolt database", "path", path, "removed", removed)
+		}
+	}
+
+	if version < 2 {
+		if err = indexDeliveryKeys(tx); err != nil {
+			return err
+		}
+	}
+
+	// Observed transactions are keyed by their position as of version 3.
+	if version < 3 {
+		if _, err = rebuildAddressBuckets(tx.Bucket(_observedTransactionsBucket), observedTransactionKey); err != nil {
+			return err
+		}
+	}
+
+	// Observed transactions are indexed by their sequence as of version 4.
+	if version < 4 {
+		if err = indexSequences(tx); err != nil {
+			return err
+		}
+	}
+
+	// Observed transactions are indexed by their status as of version 5.
+	if version < 5 {
+		if err = indexStatuses(tx); err != nil {
+			return err
+		}
+	}
+
+	// Deliveries are keyed by block and retraction as well as of version 6.
+	if version < 6 {
+		if err = reindexDeliveryKeys(tx); err != nil {
+			return err
+		}
+	}
+
+	return meta.Put(_schemaVersionKey, encodeInt(_schemaVersion))
+}
+
+// compact rewrites the transactions and token transfers stored before they were keyed by their identity,
+// when every insert appended a new entry. Duplicates are dropped, keeping the first stored entry.
+// It returns the number of dropped duplicates.
+func compact(tx *bolt.Tx) (int, error) {
+	removed := 0
+
+	for _, name := range [][]byte{_inboundTransactionsBucket, _outboundTransactionsBucket} {
+		n, err := rebuildAddressBuckets(tx.Bucket(name), func(_ []byte, transaction blocks.Transaction) ([]byte, error) {
+			blockNum, errBlockNum := transaction.BlockNumber.Int()
+
+			return transactionKey(blockNum, transaction.Hash), errBlockNum
+		})
+		if err != nil {
+			return removed, err
+		}
+
+		removed += n
+	}
+
+	n, err := rebuildAddressBuckets(tx.Bucket(_observedTransactionsBucket),
+		observedTransactionKey)
+	if err != nil {
+		return removed, err
+	}
+
+	removed += n
+
+	n, err = rebuildAddressBuckets(tx.Bucket(_tokenTransfersBucket),
+		func(address []byte, transfer blocks.TokenTransfer) ([]byte, error) {
+			return tokenTransferKey(string(address), transfer), nil
+		})
+
+	return removed + n, err
+}
+
+// rebuildAddressBuckets rewrites the values of all per address buckets of a root bucket under the keys returned
+// by a key function. Values sharing a key are stored once. It returns the number of dropped values.
+func rebuildAddressBuckets[T any](root *bolt.Bucket, keyOf func(address []byte, value T) ([]byte, error)) (int, error) {
+	addresses := make([][]byte, 0)
+
+	err := root.ForEach(func(address, _ []byte) error {
+		addresses = append(addresses, append([]byte(nil), address...))
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+
+	for _, address := range addresses {
+		values, errValues := decodeValues[T](root.Bucket(address))
+		if errValues != nil {
+			return removed, errValues
+		}
+
+		if err = root.DeleteBucket(address); err != nil {
+			return removed, err
+		}
+
+		bucket, errBucket := root.CreateBucket(address)
+		if errBucket != nil {
+			return removed, errBucket
+		}
+
+		for _, value := range values {
+			key, errKey := keyOf(address, value)
+			if errKey != nil {
+				return removed, errKey
+			}
+
+			if bucket.Get(key) != nil {
+				removed++
+
+				continue
+			}
+
+			if err = putJSON(bucket, key, value); err != nil {
+				return removed, err
+			}
+		}
+	}
+
+	return removed, nil
+}
+
+// indexDeliveryKeys indexes the IDs of the deliveries stored before they were keyed by webhook and transaction.
+// If a transaction was delivered to a webhook more than once, the first delivery is indexed.
+func indexDeliveryKeys(tx *bolt.Tx) error {
+	keysRoot := tx.Bucket(_webhookDeliveryKeysBucket)
+
+	return tx.Bucket(_webhookDeliveriesBucket).ForEach(func(address, _ []byte) error {
+		keys, err := keysRoot.CreateBucketIfNotExists(address)
+		if err != nil {
+			return err
+		}
+
+		deliveries, err := decodeValues[blocks.WebhookDelivery](tx.Bucket(_webhookDeliveriesBucket).Bucket(address))
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			if keys.Get([]byte(delivery.Key())) != nil {
+				continue
+			}
+
+			if err = keys.Put([]byte(delivery.Key()), []byte(delivery.ID)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// reindexDeliveryKeys indexes the IDs of all deliveries again under their current keys.
+func reindexDeliveryKeys(tx *bolt.Tx) error {
+	if err := tx.DeleteBucket(_webhookDeliveryKeysBucket); err != nil {
+		return err
+	}
+
+	if _, err := tx.CreateBucket(_webhookDeliveryKeysBucket); err != nil {
+		return err
+	}
+
+	return indexDeliveryKeys(tx)
+}
+
+// indexSequences indexes the observed transactions stored before they were indexed by their sequence.
+// Transactions stored before they were assigned a sequence are never replayed, so they are not indexed.
+func indexSequences(tx *bolt.Tx) error {
+	root := tx.Bucket(_observedTransactionsBucket)
+	sequences := tx.Bucket(_observedSequencesBucket)
+
+	return root.ForEach(func(address, _ []byte) error {
+		return root.Bucket(address).ForEach(func(key, valueBytes []byte) error {
+			var observedTx blocks.ObservedTransaction
+
+			if err := json.Unmarshal(valueBytes, &observedTx); err != nil {
+				return err
+			}
+
+			if observedTx.Sequence == 0 {
+				return nil
+			}
+
+			return sequences.Put(sequenceKey(observedTx.Sequence), observedTransactionRef(address, key))
+		})
+	})
+}
+
+// indexStatuses indexes the observed transactions stored before they were indexed by their status.
+func indexStatuses(tx *bolt.Tx) error {
+	root := tx.Bucket(_observedTransactionsBucket)
+
+	return root.ForEach(func(address, _ []byte) error {
+		return root.Bucket(address).ForEach(func(key, valueBytes []byte) error {
+			var observedTx blocks.ObservedTransaction
+
+			if err := json.Unmarshal(valueBytes, &observedTx); err != nil {
+				return err
+			}
+
+			return putStatusRef(tx, observedTx.Status, observedTransactionRef(address, key))
+		})
+	})
+}
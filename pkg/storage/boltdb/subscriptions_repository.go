@@ -0,0 +1,807 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+// subscriptionRecord represents the persisted state of an address subscription.
+type subscriptionRecord struct {
+	CreatedAt        time.Time `json:"createdAt"`
+	LastCheckedBlock int       `json:"lastCheckedBlock"`
+}
+
+// SubscriptionsRepository holds the CRUD db operations for address subscriptions persisted in a bolt database.
+//
+// Observed transactions are kept in per address buckets keyed by their position and indexed by their sequence
+// and their status in separate buckets, so that the transactions stored after a given sequence or the ones
+// which are not finalized yet are found without walking all observed transactions.
+type SubscriptionsRepository struct {
+	db *DB
+}
+
+// NewSubscriptionsRepository is a constructor function for SubscriptionsRepository.
+func NewSubscriptionsRepository(db *DB) *SubscriptionsRepository {
+	return &SubscriptionsRepository{
+		db: db,
+	}
+}
+
+// InsertSubscriberAddress inserts a new address as a subscriber to be observed for new transactions.
+func (r *SubscriptionsRepository) InsertSubscriberAddress(address string) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(_subscriptionsBucket)
+		if bucket.Get([]byte(address)) != nil {
+			return nil
+		}
+
+		return putSubscription(bucket, address, subscriptionRecord{CreatedAt: time.Now().UTC(), LastCheckedBlock: -1})
+	})
+}
+
+// RemoveSubscriberAddress removes a subscribed address. If purge is set, its observed transactions
+// and token transfers are removed as well.
+func (r *SubscriptionsRepository) RemoveSubscriberAddress(address string, purge bool) (bool, error) {
+	var found bool
+
+	err := r.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(_subscriptionsBucket)
+		if found = bucket.Get([]byte(address)) != nil; !found {
+			return nil
+		}
+
+		if err := bucket.Delete([]byte(address)); err != nil {
+			return err
+		}
+
+		if !purge {
+			return nil
+		}
+
+		err := removeObservedTransactionsPerAddress(tx, []byte(address), func(blocks.ObservedTransaction) bool {
+			return true
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, name := range [][]byte{_observedTransactionsBucket, _tokenTransfersBucket} {
+			err = tx.Bucket(name).DeleteBucket([]byte(address))
+			if err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return found, err
+}
+
+// GetSubscription returns the details of a subscribed address or nil if the address is not subscribed.
+func (r *SubscriptionsRepository) GetSubscription(address string) (*blocks.Subscription, error) {
+	var subscription *blocks.Subscription
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		sub, found, err := getSubscription(tx.Bucket(_subscriptionsBucket), address)
+		if err != nil || !found {
+			return err
+		}
+
+		s := toSubscription(tx, address, sub)
+		subscription = &s
+
+		return nil
+	})
+
+	return subscription, err
+}
+
+// GetSubscriptions returns a page of subscriptions ordered by address together with the total number of them.
+func (r *SubscriptionsRepository) GetSubscriptions(offset, limit int) ([]blocks.Subscription, int, error) {
+	subscriptions := make([]blocks.Subscription, 0)
+	total := 0
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(_subscriptionsBucket)
+		total = bucket.Stats().KeyN
+
+		cursor := bucket.Cursor()
+		i := 0
+
+		// Bolt keeps keys sorted, so the subscriptions are iterated in address order.
+		for k, v := cursor.First(); k != nil && i < offset+limit; k, v = cursor.Next() {
+			if i >= offset {
+				var sub subscriptionRecord
+
+				if err := json.Unmarshal(v, &sub); err != nil {
+					return err
+				}
+
+				subscriptions = append(subscriptions, toSubscription(tx, string(k), sub))
+			}
+
+			i++
+		}
+
+		return nil
+	})
+
+	return subscriptions, total, err
+}
+
+// InsertObservedTransaction inserts a new transaction that involves a subscribed address and returns it
+// with its assigned sequence. Transactions are keyed by ID and direction, so inserting a transaction which
+// is stored already returns the stored one and false.
+func (r *SubscriptionsRepository) InsertObservedTransaction(
+	address string, observedTx blocks.ObservedTransaction) (blocks.ObservedTransaction, bool, error) {
+	inserted := false
+
+	err := r.db.bolt.Update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(_observedTransactionsBucket)
+
+		bucket, err := root.CreateBucketIfNotExists([]byte(address))
+		if err != nil {
+			return err
+		}
+
+		key, err := observedTransactionKey([]byte(address), observedTx)
+		if err != nil {
+			return err
+		}
+
+		if storedBytes := bucket.Get(key); storedBytes != nil {
+			return json.Unmarshal(storedBytes, &observedTx)
+		}
+
+		// The sequence of the root bucket is shared by all addresses, so that it orders all observed transactions.
+		seq, err := root.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		observedTx.Sequence = seq
+		inserted = true
+
+		ref := observedTransactionRef([]byte(address), key)
+
+		if err = tx.Bucket(_observedSequencesBucket).Put(sequenceKey(seq), ref); err != nil {
+			return err
+		}
+
+		if err = putStatusRef(tx, observedTx.Status, ref); err != nil {
+			return err
+		}
+
+		return putJSON(bucket, key, observedTx)
+	})
+
+	return observedTx, inserted, err
+}
+
+// GetTransactionEventsAfter returns up to limit observed transactions with a sequence greater than a given one
+// in sequence order. An empty address matches the observed transactions of all subscribed addresses.
+// Reading the sequence index starts right after the given sequence and stops once limit transactions have matched.
+func (r *SubscriptionsRepository) GetTransactionEventsAfter(
+	address string, sequence uint64, limit int) ([]blocks.TransactionEvent, error) {
+	events := make([]blocks.TransactionEvent, 0)
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(_observedTransactionsBucket)
+		cursor := tx.Bucket(_observedSequencesBucket).Cursor()
+
+		for k, ref := cursor.Seek(sequenceKey(sequence + 1)); k != nil && len(events) < limit; k, ref = cursor.Next() {
+			refAddress, key := parseObservedTransactionRef(ref)
+			if address != "" && string(refAddress) != address {
+				continue
+			}
+
+			bucket := root.Bucket(refAddress)
+			if bucket == nil {
+				continue
+			}
+
+			var observedTx blocks.ObservedTransaction
+
+			if err := json.Unmarshal(bucket.Get(key), &observedTx); err != nil {
+				return err
+			}
+
+			events = append(events, blocks.TransactionEvent{
+				Sequence:    observedTx.Sequence,
+				Address:     string(refAddress),
+				Transaction: observedTx,
+			})
+		}
+
+		return nil
+	})
+
+	return events, err
+}
+
+// GetObservedTransactionsPerStatus returns the observed transactions with any of the given statuses
+// per subscribed address. Only the transactions referred to by the status index are read.
+func (r *SubscriptionsRepository) GetObservedTransactionsPerStatus(
+	statuses ...blocks.ConfirmationStatus) (map[string][]blocks.ObservedTransaction, error) {
+	txs := make(map[string][]blocks.ObservedTransaction)
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(_observedTransactionsBucket)
+
+		for _, status := range statuses {
+			refs := tx.Bucket(_observedStatusesBucket).Bucket(statusKey(status))
+			if refs == nil {
+				continue
+			}
+
+			err := refs.ForEach(func(ref, _ []byte) error {
+				address, key := parseObservedTransactionRef(ref)
+
+				bucket := root.Bucket(address)
+				if bucket == nil {
+					return nil
+				}
+
+				var observedTx blocks.ObservedTransaction
+
+				if err := json.Unmarshal(bucket.Get(key), &observedTx); err != nil {
+					return err
+				}
+
+				txs[string(address)] = append(txs[string(address)], observedTx)
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return txs, err
+}
+
+// UpdateObservedTransactions replaces already observed transactions at the same positions per subscribed
+// address. All transactions are replaced in a single bolt transaction.
+func (r *SubscriptionsRepository) UpdateObservedTransactions(txs map[string][]blocks.ObservedTransaction) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		for address, observedTxs := range txs {
+			bucket := tx.Bucket(_observedTransactionsBucket).Bucket([]byte(address))
+			if bucket == nil {
+				continue
+			}
+
+			for _, observedTx := range observedTxs {
+				if err := updateObservedTransaction(tx, bucket, []byte(address), observedTx); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// RemoveObservedTransactionsPerBlockHash removes all observed transactions contained in a given block.
+func (r *SubscriptionsRepository) RemoveObservedTransactionsPerBlockHash(blockHash blocks.Hash) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		return removeObservedTransactions(tx, func(existing blocks.ObservedTransaction) bool {
+			return existing.BlockHash == blockHash
+		})
+	})
+}
+
+// InsertTokenTransfer inserts a new ERC-20 token transfer that involves a subscribed address.
+// Inserting a token transfer which is stored already is a no-op.
+func (r *SubscriptionsRepository) InsertTokenTransfer(address string, transfer blocks.TokenTransfer) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(_tokenTransfersBucket).CreateBucketIfNotExists([]byte(address))
+		if err != nil {
+			return err
+		}
+
+		return putJSON(bucket, tokenTransferKey(address, transfer), transfer)
+	})
+}
+
+// RemoveTokenTransfersPerBlockHash removes all observed ERC-20 token transfers contained in a given block.
+func (r *SubscriptionsRepository) RemoveTokenTransfersPerBlockHash(blockHash blocks.Hash) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		return removeFromAddressBuckets(tx.Bucket(_tokenTransfersBucket), func(existing blocks.TokenTransfer) bool {
+			return existing.BlockHash == blockHash
+		})
+	})
+}
+
+// GetAllSubscriptions returns all address subscriptions.
+func (r *SubscriptionsRepository) GetAllSubscriptions() ([]string, error) {
+	addresses := make([]string, 0)
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(_subscriptionsBucket).ForEach(func(address, _ []byte) error {
+			addresses = append(addresses, string(address))
+
+			return nil
+		})
+	})
+
+	return addresses, err
+}
+
+// GetLastCheckedBlockNumberPerAddress returns the last check block number a given subscribed address.
+func (r *SubscriptionsRepository) GetLastCheckedBlockNumberPerAddress(address string) (int, error) {
+	blockNum := -1
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		sub, found, err := getSubscription(tx.Bucket(_subscriptionsBucket), address)
+		if err != nil || !found {
+			return err
+		}
+
+		blockNum = sub.LastCheckedBlock
+
+		return nil
+	})
+
+	return blockNum, err
+}
+
+// UpdateLastCheckedBlockNumberPerAddress updates the last checked block number for a given subscribed address.
+func (r *SubscriptionsRepository) UpdateLastCheckedBlockNumberPerAddress(address string, blockNum int) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(_subscriptionsBucket)
+
+		sub, found, err := getSubscription(bucket, address)
+		if err != nil || !found {
+			return err
+		}
+
+		sub.LastCheckedBlock = blockNum
+
+		return putSubscription(bucket, address, sub)
+	})
+}
+
+// GetLastProcessedBlockNumber returns the block number up to which the observer has processed the chain
+// or -1 if no block has been processed yet.
+func (r *SubscriptionsRepository) GetLastProcessedBlockNumber() (int, error) {
+	var blockNum int
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		var err error
+
+		blockNum, err = decodeInt(tx.Bucket(_metaBucket).Get(_lastProcessedBlockKey), -1)
+
+		return err
+	})
+
+	return blockNum, err
+}
+
+// UpdateLastProcessedBlock moves the processed block cursor of the observer and records the hash of the block,
+// unless it is zero. The hashes of the blocks after it and the ones of the blocks which fell out of a window
+// of the most recent blocks are dropped. The cursor and the hashes are stored together, so that they match
+// when the observer is restarted.
+func (r *SubscriptionsRepository) UpdateLastProcessedBlock(blockNum int, blockHash blocks.Hash, window int) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(_metaBucket).Put(_lastProcessedBlockKey, encodeInt(blockNum)); err != nil {
+			return err
+		}
+
+		bucket := tx.Bucket(_processedBlocksBucket)
+		stale := make([][]byte, 0)
+		oldest := blockKey(max(blockNum-window+1, 0))
+		cursor := bucket.Cursor()
+
+		for k, _ := cursor.First(); k != nil && bytes.Compare(k, oldest) < 0; k, _ = cursor.Next() {
+			stale = append(stale, append([]byte(nil), k...))
+		}
+
+		for k, _ := cursor.Seek(blockKey(blockNum + 1)); k != nil; k, _ = cursor.Next() {
+			stale = append(stale, append([]byte(nil), k...))
+		}
+
+		for _, k := range stale {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		if blockHash.IsZero() {
+			return bucket.Delete(blockKey(blockNum))
+		}
+
+		return bucket.Put(blockKey(blockNum), blockHash[:])
+	})
+}
+
+// GetProcessedBlockHashes returns the recorded hashes of the most recently processed blocks keyed by block number.
+func (r *SubscriptionsRepository) GetProcessedBlockHashes() (map[int]blocks.Hash, error) {
+	hashes := make(map[int]blocks.Hash)
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(_processedBlocksBucket).ForEach(func(k, v []byte) error {
+			var hash blocks.Hash
+
+			copy(hash[:], v)
+			hashes[int(binary.BigEndian.Uint64(k))] = hash
+
+			return nil
+		})
+	})
+
+	return hashes, err
+}
+
+// GetObservedTransactionsPerAddress returns all observed transactions per subscribed address.
+func (r *SubscriptionsRepository) GetObservedTransactionsPerAddress(
+	address string) ([]blocks.ObservedTransaction, error) {
+	var txs []blocks.ObservedTransaction
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		var err error
+
+		txs, err = decodeValues[blocks.ObservedTransaction](
+			tx.Bucket(_observedTransactionsBucket).Bucket([]byte(address)))
+
+		return err
+	})
+
+	return txs, err
+}
+
+// QueryObservedTransactionsPerAddress returns a page of the observed transactions per subscribed address
+// matching a query. If further transactions match, the cursor of the page is returned as well.
+// Transactions are keyed by their position, so reading starts at the cursor or the start of the block range
+// and stops once the page and the first transaction of the next page have matched.
+func (r *SubscriptionsRepository) QueryObservedTransactionsPerAddress(
+	address string, query blocks.TransactionQuery) ([]blocks.ObservedTransaction, *blocks.TransactionCursor, error) {
+	txs := make([]blocks.ObservedTransaction, 0)
+	lowerBound, upperBound := queryBounds(query)
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(_observedTransactionsBucket).Bucket([]byte(address))
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+
+		for k, v := cursor.Seek(lowerBound); k != nil && !query.Full(len(txs)); k, v = cursor.Next() {
+			if upperBound != nil && bytes.Compare(k, upperBound) >= 0 {
+				break
+			}
+
+			var observedTx blocks.ObservedTransaction
+
+			if err := json.Unmarshal(v, &observedTx); err != nil {
+				return err
+			}
+
+			matches, err := query.Matches(address, observedTx)
+			if err != nil {
+				return err
+			}
+
+			if matches {
+				txs = append(txs, observedTx)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return blocks.ApplyQuery(address, txs, query)
+}
+
+// GetTokenTransfersPerAddress returns all observed ERC-20 token transfers per subscribed address.
+func (r *SubscriptionsRepository) GetTokenTransfersPerAddress(address string) ([]blocks.TokenTransfer, error) {
+	var transfers []blocks.TokenTransfer
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		var err error
+
+		transfers, err = decodeValues[blocks.TokenTransfer](tx.Bucket(_tokenTransfersBucket).Bucket([]byte(address)))
+
+		return err
+	})
+
+	return transfers, err
+}
+
+// toSubscription combines the persisted state of a subscription with the number of its observed transactions.
+func toSubscription(tx *bolt.Tx, address string, sub subscriptionRecord) blocks.Subscription {
+	observed := 0
+
+	if bucket := tx.Bucket(_observedTransactionsBucket).Bucket([]byte(address)); bucket != nil {
+		observed = bucket.Stats().KeyN
+	}
+
+	return blocks.Subscription{
+		Address:              address,
+		CreatedAt:            sub.CreatedAt,
+		LastCheckedBlock:     sub.LastCheckedBlock,
+		ObservedTransactions: observed,
+	}
+}
+
+// observedTransactionKey returns the key of an observed transaction in the bucket of a subscribed address.
+// It starts with the position of the transaction, so that transactions are iterated in listing order.
+func observedTransactionKey(address []byte, observedTx blocks.ObservedTransaction) ([]byte, error) {
+	cursor, err := observedTx.Cursor()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(positionKey(cursor.BlockNumber, cursor.Index), observedTx.Key(string(address))...), nil
+}
+
+// sequenceKey encodes the sequence of an observed transaction as a big endian key of the sequence index,
+// so that keys are iterated in sequence order.
+func sequenceKey(sequence uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+
+	return key
+}
+
+// updateObservedTransaction replaces an observed transaction stored in the bucket of a subscribed address,
+// if any, and moves it to the status index entry of its new status.
+func updateObservedTransaction(
+	tx *bolt.Tx, bucket *bolt.Bucket, address []byte, observedTx blocks.ObservedTransaction) error {
+	key, err := observedTransactionKey(address, observedTx)
+	if err != nil {
+		return err
+	}
+
+	storedBytes := bucket.Get(key)
+	if storedBytes == nil {
+		return nil
+	}
+
+	var stored blocks.ObservedTransaction
+
+	if err = json.Unmarshal(storedBytes, &stored); err != nil {
+		return err
+	}
+
+	// The sequence is assigned once, so that the transaction is not replayed as a new one.
+	observedTx.Sequence = stored.Sequence
+
+	if !bytes.Equal(statusKey(stored.Status), statusKey(observedTx.Status)) {
+		ref := observedTransactionRef(address, key)
+
+		if err = deleteStatusRef(tx, stored.Status, ref); err != nil {
+			return err
+		}
+
+		if err = putStatusRef(tx, observedTx.Status, ref); err != nil {
+			return err
+		}
+	}
+
+	return putJSON(bucket, key, observedTx)
+}
+
+// statusKey returns the name of the status index bucket of a confirmation status. Transactions stored
+// before they had a status are pending confirmation.
+func statusKey(status blocks.ConfirmationStatus) []byte {
+	if status == "" {
+		status = blocks.StatusPendingConfirmation
+	}
+
+	return []byte(status)
+}
+
+// putStatusRef adds an observed transaction to the status index entry of a confirmation status.
+func putStatusRef(tx *bolt.Tx, status blocks.ConfirmationStatus, ref []byte) error {
+	bucket, err := tx.Bucket(_observedStatusesBucket).CreateBucketIfNotExists(statusKey(status))
+	if err != nil {
+		return err
+	}
+
+	return bucket.Put(ref, []byte{})
+}
+
+// deleteStatusRef removes an observed transaction from the status index entry of a confirmation status.
+func deleteStatusRef(tx *bolt.Tx, status blocks.ConfirmationStatus, ref []byte) error {
+	bucket := tx.Bucket(_observedStatusesBucket).Bucket(statusKey(status))
+	if bucket == nil {
+		return nil
+	}
+
+	return bucket.Delete(ref)
+}
+
+// observedTransactionRef refers to an observed transaction from an index by its address and its key,
+// separated by a zero byte, which addresses never contain.
+func observedTransactionRef(address, key []byte) []byte {
+	ref := make([]byte, 0, len(address)+1+len(key))
+	ref = append(ref, address...)
+	ref = append(ref, 0)
+
+	return append(ref, key...)
+}
+
+// parseObservedTransactionRef returns the address and the key of the observed transaction an index entry refers to.
+func parseObservedTransactionRef(ref []byte) ([]byte, []byte) {
+	i := bytes.IndexByte(ref, 0)
+	if i < 0 {
+		return ref, nil
+	}
+
+	return ref[:i], ref[i+1:]
+}
+
+// queryBounds returns the keys of the first and past the last position which may hold transactions matching
+// a query. The upper bound is nil if the query has no block range.
+func queryBounds(query blocks.TransactionQuery) ([]byte, []byte) {
+	lowerBound := positionKey(0, 0)
+
+	var upperBound []byte
+
+	if query.BlockRange != nil {
+		lowerBound = positionKey(query.BlockRange.From, 0)
+		upperBound = positionKey(query.BlockRange.To+1, 0)
+	}
+
+	if query.After != nil {
+		if afterKey := positionKey(query.After.BlockNumber, query.After.Index); bytes.Compare(afterKey, lowerBound) > 0 {
+			lowerBound = afterKey
+		}
+	}
+
+	return lowerBound, upperBound
+}
+
+// tokenTransferKey returns the key of a token transfer in the bucket of a subscribed address.
+func tokenTransferKey(address string, transfer blocks.TokenTransfer) []byte {
+	return []byte(transfer.Key(address))
+}
+
+func getSubscription(bucket *bolt.Bucket, address string) (subscriptionRecord, bool, error) {
+	var sub subscriptionRecord
+
+	subBytes := bucket.Get([]byte(address))
+	if subBytes == nil {
+		return sub, false, nil
+	}
+
+	err := json.Unmarshal(subBytes, &sub)
+
+	return sub, err == nil, err
+}
+
+func putSubscription(bucket *bolt.Bucket, address string, sub subscriptionRecord) error {
+	subBytes, err := json.Marshal(sub)
+	if err != nil {
+		return err
+	}
+
+	return bucket.Put([]byte(address), subBytes)
+}
+
+// matchingKeys collects the keys of all bucket values for which the match function returns true.
+// Keys are collected upfront, since modifying a bucket while iterating over it invalidates the cursor.
+func matchingKeys[T any](bucket *bolt.Bucket, match func(value T) bool) ([][]byte, error) {
+	keys := make([][]byte, 0)
+
+	err := bucket.ForEach(func(key, valueBytes []byte) error {
+		var value T
+
+		if err := json.Unmarshal(valueBytes, &value); err != nil {
+			return err
+		}
+
+		if match(value) {
+			keys = append(keys, append([]byte(nil), key...))
+		}
+
+		return nil
+	})
+
+	return keys, err
+}
+
+// removeObservedTransactions removes the observed transactions matched by a given function of all subscribed
+// addresses together with their index entries.
+func removeObservedTransactions(tx *bolt.Tx, match func(observedTx blocks.ObservedTransaction) bool) error {
+	addresses := make([][]byte, 0)
+
+	err := tx.Bucket(_observedTransactionsBucket).ForEach(func(address, _ []byte) error {
+		addresses = append(addresses, append([]byte(nil), address...))
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, address := range addresses {
+		if err = removeObservedTransactionsPerAddress(tx, address, match); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeObservedTransactionsPerAddress removes the observed transactions of a subscribed address matched
+// by a given function together with their index entries.
+func removeObservedTransactionsPerAddress(
+	tx *bolt.Tx, address []byte, match func(observedTx blocks.ObservedTransaction) bool) error {
+	bucket := tx.Bucket(_observedTransactionsBucket).Bucket(address)
+	if bucket == nil {
+		return nil
+	}
+
+	removed := make(map[string]blocks.ObservedTransaction)
+
+	err := bucket.ForEach(func(key, valueBytes []byte) error {
+		var observedTx blocks.ObservedTransaction
+
+		if err := json.Unmarshal(valueBytes, &observedTx); err != nil {
+			return err
+		}
+
+		if match(observedTx) {
+			removed[string(key)] = observedTx
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sequences := tx.Bucket(_observedSequencesBucket)
+
+	for key, observedTx := range removed {
+		if err = bucket.Delete([]byte(key)); err != nil {
+			return err
+		}
+
+		if err = sequences.Delete(sequenceKey(observedTx.Sequence)); err != nil {
+			return err
+		}
+
+		if err = deleteStatusRef(tx, observedTx.Status, observedTransactionRef(address, []byte(key))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeFromAddressBuckets removes the values matched by a given function from all per address buckets.
+func removeFromAddressBuckets[T any](root *bolt.Bucket, match func(value T) bool) error {
+	return root.ForEach(func(address, _ []byte) error {
+		bucket := root.Bucket(address)
+
+		keys, err := matchingKeys(bucket, match)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err = bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,807 @@
+package boltdb
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+const _testAddress = "0x00000000000000000000000000000000000000aa"
+
+func TestSubscriptionsArePersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scanner.db")
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	repo := NewSubscriptionsRepository(db)
+
+	if err = repo.InsertSubscriberAddress(_testAddress); err != nil {
+		t.Fatalf("InsertSubscriberAddress() error = %v", err)
+	}
+
+	if err = repo.UpdateLastCheckedBlockNumberPerAddress(_testAddress, 7); err != nil {
+		t.Fatalf("UpdateLastCheckedBlockNumberPerAddress() error = %v", err)
+	}
+
+	if err = repo.UpdateLastProcessedBlock(9, testBlockHash(9), 1); err != nil {
+		t.Fatalf("UpdateLastProcessedBlock() error = %v", err)
+	}
+
+	for blockNum := 1; blockNum <= 3; blockNum++ {
+		if _, _, err = repo.InsertObservedTransaction(_testAddress, testObservedTransaction(blockNum, 0)); err != nil {
+			t.Fatalf("InsertObservedTransaction() error = %v", err)
+		}
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	db = reopenTestDB(t, path)
+	repo = NewSubscriptionsRepository(db)
+
+	addresses, err := repo.GetAllSubscriptions()
+	if err != nil {
+		t.Fatalf("GetAllSubscriptions() error = %v", err)
+	}
+
+	if fmt.Sprint(addresses) != fmt.Sprint([]string{_testAddress}) {
+		t.Errorf("GetAllSubscriptions() = %v, want [%s]", addresses, _testAddress)
+	}
+
+	lastCheckedBlockNum, err := repo.GetLastCheckedBlockNumberPerAddress(_testAddress)
+	if err != nil {
+		t.Fatalf("GetLastCheckedBlockNumberPerAddress() error = %v", err)
+	}
+
+	if lastCheckedBlockNum != 7 {
+		t.Errorf("GetLastCheckedBlockNumberPerAddress() = %d, want 7", lastCheckedBlockNum)
+	}
+
+	lastProcessedBlockNum, err := repo.GetLastProcessedBlockNumber()
+	if err != nil {
+		t.Fatalf("GetLastProcessedBlockNumber() error = %v", err)
+	}
+
+	if lastProcessedBlockNum != 9 {
+		t.Errorf("GetLastProcessedBlockNumber() = %d, want 9", lastProcessedBlockNum)
+	}
+
+	txs, err := repo.GetObservedTransactionsPerAddress(_testAddress)
+	if err != nil {
+		t.Fatalf("GetObservedTransactionsPerAddress() error = %v", err)
+	}
+
+	if got := blockNumbers(txs); fmt.Sprint(got) != "[0x1 0x2 0x3]" {
+		t.Errorf("GetObservedTransactionsPerAddress() blocks = %v, want [0x1 0x2 0x3]", got)
+	}
+}
+
+func TestRemoveTokenTransfersPerBlockHash(t *testing.T) {
+	repo := NewSubscriptionsRepository(openTestDB(t))
+
+	from, _ := blocks.ParseAddress(_testAddress)
+
+	for blockNum := 1; blockNum <= 3; blockNum++ {
+		transfer := blocks.TokenTransfer{
+			From:        from,
+			BlockHash:   testBlockHash(blockNum),
+			BlockNumber: blocks.NewQuantity(blockNum),
+			LogIndex:    blocks.NewQuantity(blockNum),
+		}
+
+		if err := repo.InsertTokenTransfer(_testAddress, transfer); err != nil {
+			t.Fatalf("InsertTokenTransfer() error = %v", err)
+		}
+	}
+
+	if err := repo.RemoveTokenTransfersPerBlockHash(testBlockHash(2)); err != nil {
+		t.Fatalf("RemoveTokenTransfersPerBlockHash() error = %v", err)
+	}
+
+	transfers, err := repo.GetTokenTransfersPerAddress(_testAddress)
+	if err != nil {
+		t.Fatalf("GetTokenTransfersPerAddress() error = %v", err)
+	}
+
+	var got []blocks.Quantity
+
+	for _, transfer := range transfers {
+		got = append(got, transfer.BlockNumber)
+	}
+
+	if fmt.Sprint(got) != "[0x1 0x3]" {
+		t.Errorf("GetTokenTransfersPerAddress() blocks = %v, want [0x1 0x3]", got)
+	}
+}
+
+func TestRemoveSubscriberAddress(t *testing.T) {
+	tests := []struct {
+		name         string
+		address      string
+		purge        bool
+		wantFound    bool
+		wantObserved int
+	}{
+		{name: "keep observed transactions", address: _testAddress, wantFound: true, wantObserved: 2},
+		{name: "purge observed transactions", address: _testAddress, purge: true, wantFound: true},
+		{name: "address not subscribed", address: "0x00000000000000000000000000000000000000bb", wantObserved: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewSubscriptionsRepository(openTestDB(t))
+
+			if err := repo.InsertSubscriberAddress(_testAddress); err != nil {
+				t.Fatalf("InsertSubscriberAddress() error = %v", err)
+			}
+
+			for blockNum := 1; blockNum <= 2; blockNum++ {
+				if _, _, err := repo.InsertObservedTransaction(_testAddress, testObservedTransaction(blockNum, 0)); err != nil {
+					t.Fatalf("InsertObservedTransaction() error = %v", err)
+				}
+			}
+
+			found, err := repo.RemoveSubscriberAddress(tt.address, tt.purge)
+			if err != nil {
+				t.Fatalf("RemoveSubscriberAddress() error = %v", err)
+			}
+
+			if found != tt.wantFound {
+				t.Errorf("RemoveSubscriberAddress() = %v, want %v", found, tt.wantFound)
+			}
+
+			subscription, err := repo.GetSubscription(_testAddress)
+			if err != nil {
+				t.Fatalf("GetSubscription() error = %v", err)
+			}
+
+			if (subscription == nil) != tt.wantFound {
+				t.Errorf("GetSubscription() = %+v after removing %s", subscription, tt.address)
+			}
+
+			txs, err := repo.GetObservedTransactionsPerAddress(_testAddress)
+			if err != nil {
+				t.Fatalf("GetObservedTransactionsPerAddress() error = %v", err)
+			}
+
+			if len(txs) != tt.wantObserved {
+				t.Errorf("GetObservedTransactionsPerAddress() returned %d transactions, want %d", len(txs), tt.wantObserved)
+			}
+		})
+	}
+}
+
+func TestGetSubscriptions(t *testing.T) {
+	repo := NewSubscriptionsRepository(openTestDB(t))
+
+	// The subscriptions are inserted out of address order.
+	for _, b := range []byte{0xcc, 0xaa, 0xdd, 0xbb} {
+		address := fmt.Sprintf("0x%038x%02x", 0, b)
+
+		if err := repo.InsertSubscriberAddress(address); err != nil {
+			t.Fatalf("InsertSubscriberAddress() error = %v", err)
+		}
+	}
+
+	if _, _, err := repo.InsertObservedTransaction(_testAddress, testObservedTransaction(1, 0)); err != nil {
+		t.Fatalf("InsertObservedTransaction() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		offset int
+		limit  int
+		want   []string
+	}{
+		{name: "first page", offset: 0, limit: 2, want: []string{"aa", "bb"}},
+		{name: "last page", offset: 2, limit: 5, want: []string{"cc", "dd"}},
+		{name: "beyond the last page", offset: 4, limit: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscriptions, total, err := repo.GetSubscriptions(tt.offset, tt.limit)
+			if err != nil {
+				t.Fatalf("GetSubscriptions() error = %v", err)
+			}
+
+			if total != 4 {
+				t.Errorf("GetSubscriptions() total = %d, want 4", total)
+			}
+
+			var got []string
+
+			for _, subscription := range subscriptions {
+				got = append(got, subscription.Address[len(subscription.Address)-2:])
+
+				wantObserved := 0
+				if subscription.Address == _testAddress {
+					wantObserved = 1
+				}
+
+				if subscription.ObservedTransactions != wantObserved || subscription.LastCheckedBlock != -1 {
+					t.Errorf("subscription %+v, want %d observed transactions and no checked block",
+						subscription, wantObserved)
+				}
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("GetSubscriptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTransactionEventsAfter(t *testing.T) {
+	const otherAddress = "0x00000000000000000000000000000000000000bb"
+
+	tests := []struct {
+		name     string
+		address  string
+		sequence uint64
+		limit    int
+		remove   func(repo *SubscriptionsRepository) error
+		want     []uint64
+	}{
+		{
+			name:  "all addresses",
+			limit: 10,
+			want:  []uint64{1, 2, 3, 4, 5, 6},
+		},
+		{
+			name:     "after a sequence up to the limit",
+			sequence: 2,
+			limit:    3,
+			want:     []uint64{3, 4, 5},
+		},
+		{
+			name:     "one address",
+			address:  otherAddress,
+			sequence: 2,
+			limit:    10,
+			want:     []uint64{4, 6},
+		},
+		{
+			name:  "after removing a block",
+			limit: 10,
+			remove: func(repo *SubscriptionsRepository) error {
+				return repo.RemoveObservedTransactionsPerBlockHash(testBlockHash(2))
+			},
+			want: []uint64{1, 2, 5, 6},
+		},
+		{
+			name:  "after purging an address",
+			limit: 10,
+			remove: func(repo *SubscriptionsRepository) error {
+				_, err := repo.RemoveSubscriberAddress(otherAddress, true)
+
+				return err
+			},
+			want: []uint64{1, 3, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewSubscriptionsRepository(openTestDB(t))
+
+			if err := repo.InsertSubscriberAddress(otherAddress); err != nil {
+				t.Fatalf("InsertSubscriberAddress() error = %v", err)
+			}
+
+			for blockNum := 1; blockNum <= 3; blockNum++ {
+				for _, address := range []string{_testAddress, otherAddress} {
+					if _, _, err := repo.InsertObservedTransaction(address, testObservedTransaction(blockNum, 0)); err != nil {
+						t.Fatalf("InsertObservedTransaction() error = %v", err)
+					}
+				}
+			}
+
+			if tt.remove != nil {
+				if err := tt.remove(repo); err != nil {
+					t.Fatalf("remove error = %v", err)
+				}
+			}
+
+			events, err := repo.GetTransactionEventsAfter(tt.address, tt.sequence, tt.limit)
+			if err != nil {
+				t.Fatalf("GetTransactionEventsAfter() error = %v", err)
+			}
+
+			got := make([]uint64, len(events))
+
+			for i, event := range events {
+				got[i] = event.Sequence
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("GetTransactionEventsAfter() sequences = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryObservedTransactionsPerAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     blocks.TransactionQuery
+		wantPages [][]string
+	}{
+		{
+			name:      "all transactions",
+			query:     blocks.TransactionQuery{},
+			wantPages: [][]string{{"1-0", "1-1", "2-0", "2-1", "3-0", "3-1"}},
+		},
+		{
+			name:      "pages",
+			query:     blocks.TransactionQuery{Limit: 4},
+			wantPages: [][]string{{"1-0", "1-1", "2-0", "2-1"}, {"3-0", "3-1"}},
+		},
+		{
+			name:      "pages of a block range",
+			query:     blocks.TransactionQuery{BlockRange: &blocks.Range{From: 2, To: 3}, Limit: 3},
+			wantPages: [][]string{{"2-0", "2-1", "3-0"}, {"3-1"}},
+		},
+		{
+			name:      "page filling a block range",
+			query:     blocks.TransactionQuery{BlockRange: &blocks.Range{From: 2, To: 2}, Limit: 2},
+			wantPages: [][]string{{"2-0", "2-1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewSubscriptionsRepository(openTestDB(t))
+
+			// Transactions are inserted out of order, so that the listing order comes from the keys.
+			for blockNum := 3; blockNum >= 1; blockNum-- {
+				for i := 1; i >= 0; i-- {
+					_, _, err := repo.InsertObservedTransaction(_testAddress, testObservedTransaction(blockNum, i))
+					if err != nil {
+						t.Fatalf("InsertObservedTransaction() error = %v", err)
+					}
+				}
+			}
+
+			query := tt.query
+			pages := make([][]string, 0)
+
+			for {
+				page, next, err := repo.QueryObservedTransactionsPerAddress(_testAddress, query)
+				if err != nil {
+					t.Fatalf("QueryObservedTransactionsPerAddress() error = %v", err)
+				}
+
+				pages = append(pages, positions(t, page))
+
+				if next == nil {
+					break
+				}
+
+				query.After = next
+			}
+
+			if fmt.Sprint(pages) != fmt.Sprint(tt.wantPages) {
+				t.Errorf("QueryObservedTransactionsPerAddress() pages = %v, want %v", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestQueryObservedTransactionsPerAddressSeeks(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewSubscriptionsRepository(db)
+
+	for blockNum := 1; blockNum <= 5; blockNum++ {
+		if _, _, err := repo.InsertObservedTransaction(_testAddress, testObservedTransaction(blockNum, 0)); err != nil {
+			t.Fatalf("InsertObservedTransaction() error = %v", err)
+		}
+	}
+
+	// Corrupt the transactions before the cursor and past the first transaction of the next page,
+	// so that the query fails if it reads them. The transaction at the cursor itself is read.
+	err := db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(_observedTransactionsBucket).Bucket([]byte(_testAddress))
+
+		for _, blockNum := range []int{1, 5} {
+			key, errKey := observedTransactionKey([]byte(_testAddress), testObservedTransaction(blockNum, 0))
+			if errKey != nil {
+				return errKey
+			}
+
+			if errPut := bucket.Put(key, []byte("corrupt")); errPut != nil {
+				return errPut
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	after, err := testObservedTransaction(2, 0).Cursor()
+	if err != nil {
+		t.Fatalf("Cursor() error = %v", err)
+	}
+
+	page, next, err := repo.QueryObservedTransactionsPerAddress(_testAddress, blocks.TransactionQuery{
+		After: &after,
+		Limit: 1,
+	})
+	if err != nil {
+		t.Fatalf("QueryObservedTransactionsPerAddress() error = %v", err)
+	}
+
+	if got := positions(t, page); fmt.Sprint(got) != "[3-0]" || next == nil {
+		t.Errorf("QueryObservedTransactionsPerAddress() = %v, next %v, want [3-0] and a next cursor", got, next)
+	}
+}
+
+func TestMigrateKeysObservedTransactionsByPosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scanner.db")
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// Turn the database into one written before observed transactions were keyed by their position.
+	err = db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket, errBucket := tx.Bucket(_observedTransactionsBucket).CreateBucket([]byte(_testAddress))
+		if errBucket != nil {
+			return errBucket
+		}
+
+		for _, blockNum := range []int{2, 1} {
+			observedTx := testObservedTransaction(blockNum, 0)
+
+			if errPut := putJSON(bucket, []byte(observedTx.Key(_testAddress)), observedTx); errPut != nil {
+				return errPut
+			}
+		}
+
+		return tx.Bucket(_metaBucket).Put(_schemaVersionKey, encodeInt(2))
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	defer db.Close()
+
+	repo := NewSubscriptionsRepository(db)
+
+	_, inserted, err := repo.InsertObservedTransaction(_testAddress, testObservedTransaction(1, 0))
+	if err != nil {
+		t.Fatalf("InsertObservedTransaction() error = %v", err)
+	}
+
+	if inserted {
+		t.Error("InsertObservedTransaction() after migration inserted a stored transaction again")
+	}
+
+	page, _, err := repo.QueryObservedTransactionsPerAddress(_testAddress, blocks.TransactionQuery{})
+	if err != nil {
+		t.Fatalf("QueryObservedTransactionsPerAddress() error = %v", err)
+	}
+
+	if got := positions(t, page); fmt.Sprint(got) != "[1-0 2-0]" {
+		t.Errorf("QueryObservedTransactionsPerAddress() after migration = %v, want [1-0 2-0]", got)
+	}
+}
+
+func TestMigrateIndexesSequences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scanner.db")
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	for blockNum := 1; blockNum <= 3; blockNum++ {
+		_, _, err = NewSubscriptionsRepository(db).InsertObservedTransaction(_testAddress,
+			testObservedTransaction(blockNum, 0))
+		if err != nil {
+			t.Fatalf("InsertObservedTransaction() error = %v", err)
+		}
+	}
+
+	// Turn the database into one written before observed transactions were indexed by their sequence.
+	err = db.bolt.Update(func(tx *bolt.Tx) error {
+		if errDelete := tx.DeleteBucket(_observedSequencesBucket); errDelete != nil {
+			return errDelete
+		}
+
+		return tx.Bucket(_metaBucket).Put(_schemaVersionKey, encodeInt(3))
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	defer db.Close()
+
+	events, err := NewSubscriptionsRepository(db).GetTransactionEventsAfter(_testAddress, 1, 10)
+	if err != nil {
+		t.Fatalf("GetTransactionEventsAfter() error = %v", err)
+	}
+
+	if len(events) != 2 || events[0].Sequence != 2 || events[1].Sequence != 3 {
+		t.Errorf("GetTransactionEventsAfter() after migration = %+v, want sequences 2 and 3", events)
+	}
+}
+
+func TestUpdateObservedTransactions(t *testing.T) {
+	repo := NewSubscriptionsRepository(openTestDB(t))
+
+	for blockNum := 1; blockNum <= 3; blockNum++ {
+		if _, _, err := repo.InsertObservedTransaction(_testAddress, testObservedTransaction(blockNum, 0)); err != nil {
+			t.Fatalf("InsertObservedTransaction() error = %v", err)
+		}
+	}
+
+	pending, err := repo.GetObservedTransactionsPerStatus(blocks.StatusPendingConfirmation)
+	if err != nil {
+		t.Fatalf("GetObservedTransactionsPerStatus() error = %v", err)
+	}
+
+	if got := positions(t, pending[_testAddress]); fmt.Sprint(got) != "[1-0 2-0 3-0]" {
+		t.Fatalf("GetObservedTransactionsPerStatus() = %v, want [1-0 2-0 3-0]", got)
+	}
+
+	confirmed := pending[_testAddress][0]
+	confirmed.Status = blocks.StatusConfirmed
+
+	// The sequence of an updated transaction is kept.
+	finalized := pending[_testAddress][1]
+	finalized.Status = blocks.StatusFinalized
+	finalized.Sequence = 0
+
+	err = repo.UpdateObservedTransactions(map[string][]blocks.ObservedTransaction{
+		_testAddress: {confirmed, finalized},
+	})
+	if err != nil {
+		t.Fatalf("UpdateObservedTransactions() error = %v", err)
+	}
+
+	if err = repo.RemoveObservedTransactionsPerBlockHash(testBlockHash(3)); err != nil {
+		t.Fatalf("RemoveObservedTransactionsPerBlockHash() error = %v", err)
+	}
+
+	tests := []struct {
+		status blocks.ConfirmationStatus
+		want   string
+	}{
+		{status: blocks.StatusPendingConfirmation, want: "[]"},
+		{status: blocks.StatusConfirmed, want: "[1-0]"},
+		{status: blocks.StatusFinalized, want: "[2-0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			txs, errStatus := repo.GetObservedTransactionsPerStatus(tt.status)
+			if errStatus != nil {
+				t.Fatalf("GetObservedTransactionsPerStatus() error = %v", errStatus)
+			}
+
+			if got := positions(t, txs[_testAddress]); fmt.Sprint(got) != tt.want {
+				t.Errorf("GetObservedTransactionsPerStatus() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	events, err := repo.GetTransactionEventsAfter(_testAddress, 1, 10)
+	if err != nil {
+		t.Fatalf("GetTransactionEventsAfter() error = %v", err)
+	}
+
+	if len(events) != 1 || events[0].Sequence != 2 || events[0].Transaction.Status != blocks.StatusFinalized {
+		t.Errorf("GetTransactionEventsAfter() = %+v, want the finalized transaction with sequence 2", events)
+	}
+}
+
+func TestMigrateIndexesStatuses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scanner.db")
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// Turn the database into one written before observed transactions were indexed by their status,
+	// including a transaction stored before it had a status.
+	err = db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket, errBucket := tx.Bucket(_observedTransactionsBucket).CreateBucket([]byte(_testAddress))
+		if errBucket != nil {
+			return errBucket
+		}
+
+		for blockNum, status := range []blocks.ConfirmationStatus{"", blocks.StatusConfirmed} {
+			observedTx := testObservedTransaction(blockNum+1, 0)
+			observedTx.Status = status
+
+			key, errKey := observedTransactionKey([]byte(_testAddress), observedTx)
+			if errKey != nil {
+				return errKey
+			}
+
+			if errPut := putJSON(bucket, key, observedTx); errPut != nil {
+				return errPut
+			}
+		}
+
+		return tx.Bucket(_metaBucket).Put(_schemaVersionKey, encodeInt(4))
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	defer db.Close()
+
+	txs, err := NewSubscriptionsRepository(db).GetObservedTransactionsPerStatus(
+		blocks.StatusPendingConfirmation, blocks.StatusConfirmed)
+	if err != nil {
+		t.Fatalf("GetObservedTransactionsPerStatus() error = %v", err)
+	}
+
+	if got := positions(t, txs[_testAddress]); fmt.Sprint(got) != "[1-0 2-0]" {
+		t.Errorf("GetObservedTransactionsPerStatus() after migration = %v, want [1-0 2-0]", got)
+	}
+}
+
+func TestUpdateLastProcessedBlock(t *testing.T) {
+	tests := []struct {
+		name       string
+		blockNums  []int
+		window     int
+		wantHashes []int
+	}{
+		{name: "blocks within the window", blockNums: []int{1, 2, 3}, window: 3, wantHashes: []int{1, 2, 3}},
+		{name: "blocks out of the window", blockNums: []int{1, 2, 3, 4, 5}, window: 3, wantHashes: []int{3, 4, 5}},
+		{name: "cursor moved back", blockNums: []int{1, 2, 3, 4, 3}, window: 3, wantHashes: []int{2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewSubscriptionsRepository(openTestDB(t))
+
+			for _, blockNum := range tt.blockNums {
+				if err := repo.UpdateLastProcessedBlock(blockNum, blocks.Hash{byte(blockNum)}, tt.window); err != nil {
+					t.Fatalf("UpdateLastProcessedBlock() error = %v", err)
+				}
+			}
+
+			lastProcessedBlockNum, err := repo.GetLastProcessedBlockNumber()
+			if err != nil {
+				t.Fatalf("GetLastProcessedBlockNumber() error = %v", err)
+			}
+
+			if want := tt.blockNums[len(tt.blockNums)-1]; lastProcessedBlockNum != want {
+				t.Errorf("GetLastProcessedBlockNumber() = %d, want %d", lastProcessedBlockNum, want)
+			}
+
+			hashes, err := repo.GetProcessedBlockHashes()
+			if err != nil {
+				t.Fatalf("GetProcessedBlockHashes() error = %v", err)
+			}
+
+			want := make(map[int]blocks.Hash, len(tt.wantHashes))
+			for _, blockNum := range tt.wantHashes {
+				want[blockNum] = blocks.Hash{byte(blockNum)}
+			}
+
+			if !maps.Equal(hashes, want) {
+				t.Errorf("GetProcessedBlockHashes() = %v, want %v", hashes, want)
+			}
+		})
+	}
+}
+
+func reopenTestDB(t *testing.T, path string) *DB {
+	t.Helper()
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
+
+// testObservedTransaction returns a pending transaction sent to the test address, positioned at a given index
+// of a given block.
+func testObservedTransaction(blockNum, index int) blocks.ObservedTransaction {
+	var hash blocks.Hash
+
+	hash[0] = byte(blockNum)
+	hash[1] = byte(index)
+
+	to, _ := blocks.ParseAddress(_testAddress)
+
+	return blocks.ObservedTransaction{
+		Transaction: blocks.Transaction{
+			Hash:             hash,
+			BlockHash:        testBlockHash(blockNum),
+			To:               &to,
+			BlockNumber:      blocks.NewQuantity(blockNum),
+			TransactionIndex: blocks.NewQuantity(index),
+		},
+		Status: blocks.StatusPendingConfirmation,
+	}
+}
+
+// testBlockHash returns the hash of a test block.
+func testBlockHash(blockNum int) blocks.Hash {
+	var hash blocks.Hash
+
+	hash[blocks.HashLength-1] = byte(blockNum)
+
+	return hash
+}
+
+func blockNumbers(txs []blocks.ObservedTransaction) []blocks.Quantity {
+	got := make([]blocks.Quantity, len(txs))
+
+	for i, tx := range txs {
+		got[i] = tx.BlockNumber
+	}
+
+	return got
+}
+
+// positions returns the positions of transactions as "block-index".
+func positions[T blocks.Queryable](t *testing.T, txs []T) []string {
+	t.Helper()
+
+	got := make([]string, len(txs))
+
+	for i, tx := range txs {
+		cursor, err := tx.Cursor()
+		if err != nil {
+			t.Fatalf("Cursor() error = %v", err)
+		}
+
+		got[i] = fmt.Sprintf("%d-%d", cursor.BlockNumber, cursor.Index)
+	}
+
+	return got
+}
@@ -0,0 +1,273 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+// _blockKeyLength is the length of the block number prefix of the keys of transactions.
+const _blockKeyLength = 8
+
+// TransactionHistoryRepository holds the CRUD db operations for the transaction history of addresses
+// persisted in a bolt database. Transactions are keyed by block number followed by the transaction hash,
+// so that block ranges can be read with a single cursor seek and storing a transaction again is a no-op.
+// The direction of a transaction is given by the bucket it is stored in.
+type TransactionHistoryRepository struct {
+	db *DB
+}
+
+// NewTransactionsRepository is a constructor function for TransactionHistoryRepository.
+func NewTransactionsRepository(db *DB) *TransactionHistoryRepository {
+	return &TransactionHistoryRepository{
+		db: db,
+	}
+}
+
+// Insert inserts a new blocks.Transaction entity. Transactions are keyed by hash and direction,
+// so inserting a transaction which is stored already is a no-op.
+func (r *TransactionHistoryRepository) Insert(
+	address string, blockNumber int, transaction blocks.Transaction, isInbound bool) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucketName := _outboundTransactionsBucket
+		if isInbound {
+			bucketName = _inboundTransactionsBucket
+		}
+
+		bucket, err := tx.Bucket(bucketName).CreateBucketIfNotExists([]byte(address))
+		if err != nil {
+			return err
+		}
+
+		return putJSON(bucket, transactionKey(blockNumber, transaction.Hash), transaction)
+	})
+}
+
+// GetLatestBlockNumberPerAddress returns the latest block scanned for transactions to/from a given address.
+func (r *TransactionHistoryRepository) GetLatestBlockNumberPerAddress(address string) (int, error) {
+	ranges, err := r.GetScannedBlockRangesPerAddress(address)
+	if err != nil || len(ranges) == 0 {
+		return -1, err
+	}
+
+	return ranges[len(ranges)-1].To, nil
+}
+
+// GetScannedBlockRangesPerAddress returns the sorted block ranges already scanned for a given address.
+func (r *TransactionHistoryRepository) GetScannedBlockRangesPerAddress(address string) ([]blocks.Range, error) {
+	ranges := make([]blocks.Range, 0)
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		rangesBytes := tx.Bucket(_scannedRangesBucket).Get([]byte(address))
+		if rangesBytes == nil {
+			return nil
+		}
+
+		return json.Unmarshal(rangesBytes, &ranges)
+	})
+
+	return ranges, err
+}
+
+// InsertScannedBlockRange marks a block range as scanned for a given address.
+func (r *TransactionHistoryRepository) InsertScannedBlockRange(address string, blockRange blocks.Range) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(_scannedRangesBucket)
+		ranges := make([]blocks.Range, 0)
+
+		if rangesBytes := bucket.Get([]byte(address)); rangesBytes != nil {
+			if err := json.Unmarshal(rangesBytes, &ranges); err != nil {
+				return err
+			}
+		}
+
+		rangesBytes, err := json.Marshal(blocks.MergeRanges(ranges, blockRange))
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put([]byte(address), rangesBytes)
+	})
+}
+
+// GetAllTransactionsPerAddress returns all inbound and outbound transactions per a given address.
+func (r *TransactionHistoryRepository) GetAllTransactionsPerAddress(address string) ([]blocks.Transaction, error) {
+	txs := make([]blocks.Transaction, 0)
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		for _, bucketName := range [][]byte{_inboundTransactionsBucket, _outboundTransactionsBucket} {
+			bucketTxs, err := decodeValues[blocks.Transaction](tx.Bucket(bucketName).Bucket([]byte(address)))
+			if err != nil {
+				return err
+			}
+
+			txs = append(txs, bucketTxs...)
+		}
+
+		return nil
+	})
+
+	return txs, err
+}
+
+// QueryTransactionsPerAddress returns a page of the inbound and outbound transactions per a given address
+// matching a query. If further transactions match, the cursor of the page is returned as well.
+// Only the keys of the blocks between the cursor or the start of the block range and the end of the block range
+// are read. Transactions are keyed by block rather than by position, so reading a bucket stops at the end
+// of the block in which the page and the first transaction of the next page have matched.
+func (r *TransactionHistoryRepository) QueryTransactionsPerAddress(
+	address string, query blocks.TransactionQuery) ([]blocks.Transaction, *blocks.TransactionCursor, error) {
+	txs := make([]blocks.Transaction, 0)
+
+	lowerBound := blockKey(0)
+
+	var upperBound []byte
+
+	if query.BlockRange != nil {
+		lowerBound = blockKey(query.BlockRange.From)
+		upperBound = blockKey(query.BlockRange.To + 1)
+	}
+
+	if query.After != nil {
+		if afterKey := blockKey(query.After.BlockNumber); bytes.Compare(afterKey, lowerBound) > 0 {
+			lowerBound = afterKey
+		}
+	}
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		for _, bucketName := range [][]byte{_inboundTransactionsBucket, _outboundTransactionsBucket} {
+			bucket := tx.Bucket(bucketName).Bucket([]byte(address))
+			if bucket == nil {
+				continue
+			}
+
+			bucketTxs, err := queryBucket(bucket, address, lowerBound, upperBound, query)
+			if err != nil {
+				return err
+			}
+
+			txs = append(txs, bucketTxs...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return blocks.ApplyQuery(address, txs, query)
+}
+
+// RemoveTransactionsFromBlock removes the transactions of a given address contained in the blocks from a given one
+// on and marks these blocks as not scanned for the address. Transactions are keyed by block, so only the keys from
+// the first removed block on are read.
+func (r *TransactionHistoryRepository) RemoveTransactionsFromBlock(address string, blockNum int) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		for _, bucketName := range [][]byte{_inboundTransactionsBucket, _outboundTransactionsBucket} {
+			bucket := tx.Bucket(bucketName).Bucket([]byte(address))
+			if bucket == nil {
+				continue
+			}
+
+			keys := make([][]byte, 0)
+			cursor := bucket.Cursor()
+
+			for k, _ := cursor.Seek(blockKey(blockNum)); k != nil; k, _ = cursor.Next() {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+
+			for _, k := range keys {
+				if err := bucket.Delete(k); err != nil {
+					return err
+				}
+			}
+		}
+
+		bucket := tx.Bucket(_scannedRangesBucket)
+
+		rangesBytes := bucket.Get([]byte(address))
+		if rangesBytes == nil {
+			return nil
+		}
+
+		var ranges []blocks.Range
+
+		if err := json.Unmarshal(rangesBytes, &ranges); err != nil {
+			return err
+		}
+
+		return putJSON(bucket, []byte(address), blocks.TruncateRanges(ranges, blockNum-1))
+	})
+}
+
+// queryBucket reads the transactions of a bucket matching a query from a lower bound key up to an upper bound key,
+// if any. The page of the query only spans the blocks up to the one in which the page and the first transaction
+// of the next page have matched, so reading stops at the next block.
+func queryBucket(
+	bucket *bolt.Bucket, address string, lowerBound, upperBound []byte, query blocks.TransactionQuery,
+) ([]blocks.Transaction, error) {
+	txs := make([]blocks.Transaction, 0)
+	cursor := bucket.Cursor()
+
+	var fullBlock []byte
+
+	for k, v := cursor.Seek(lowerBound); k != nil; k, v = cursor.Next() {
+		if upperBound != nil && bytes.Compare(k, upperBound) >= 0 {
+			break
+		}
+
+		if fullBlock != nil && !bytes.HasPrefix(k, fullBlock) {
+			break
+		}
+
+		var transaction blocks.Transaction
+
+		if err := json.Unmarshal(v, &transaction); err != nil {
+			return nil, err
+		}
+
+		matches, err := query.Matches(address, transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		if !matches {
+			continue
+		}
+
+		txs = append(txs, transaction)
+
+		if fullBlock == nil && query.Full(len(txs)) {
+			fullBlock = k[:_blockKeyLength]
+		}
+	}
+
+	return txs, nil
+}
+
+// transactionKey returns the key of a transaction in the bucket of an address.
+func transactionKey(blockNumber int, hash blocks.Hash) []byte {
+	return append(blockKey(blockNumber), hash[:]...)
+}
+
+// positionKey encodes the position of a transaction in a listing, i.e. its block number followed by its index,
+// as a big endian key prefix, so that keys are iterated in listing order.
+func positionKey(blockNumber, index int) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(blockNumber))
+	binary.BigEndian.PutUint64(key[8:], uint64(index))
+
+	return key
+}
+
+// blockKey encodes a block number as a big endian key prefix, so that keys are iterated in block order.
+func blockKey(blockNumber int) []byte {
+	key := make([]byte, _blockKeyLength)
+	binary.BigEndian.PutUint64(key, uint64(blockNumber))
+
+	return key
+}
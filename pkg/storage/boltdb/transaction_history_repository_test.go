@@ -0,0 +1,208 @@
+package boltdb
+
+import (
+	"fmt"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+func TestInsertTransactions(t *testing.T) {
+	repo := NewTransactionsRepository(openTestDB(t))
+
+	for blockNum := 1; blockNum <= 5; blockNum++ {
+		tx := testObservedTransaction(blockNum, 0).Transaction
+
+		if err := repo.Insert(_testAddress, blockNum, tx, blockNum != 2); err != nil {
+			t.Fatalf("Insert() error = %v", err)
+		}
+	}
+
+	// Inbound transactions are listed before outbound ones.
+	txs, err := repo.GetAllTransactionsPerAddress(_testAddress)
+	if err != nil {
+		t.Fatalf("GetAllTransactionsPerAddress() error = %v", err)
+	}
+
+	if got := transactionBlockNumbers(txs); fmt.Sprint(got) != "[0x1 0x3 0x4 0x5 0x2]" {
+		t.Errorf("GetAllTransactionsPerAddress() blocks = %v, want [0x1 0x3 0x4 0x5 0x2]", got)
+	}
+
+	// Queried transactions are listed in block order.
+	query := blocks.TransactionQuery{BlockRange: &blocks.Range{From: 2, To: 4}}
+
+	txs, _, err = repo.QueryTransactionsPerAddress(_testAddress, query)
+	if err != nil {
+		t.Fatalf("QueryTransactionsPerAddress() error = %v", err)
+	}
+
+	if got := transactionBlockNumbers(txs); fmt.Sprint(got) != "[0x2 0x3 0x4]" {
+		t.Errorf("QueryTransactionsPerAddress() blocks = %v, want [0x2 0x3 0x4]", got)
+	}
+}
+
+func TestQueryTransactionsPerAddress(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewTransactionsRepository(db)
+
+	for blockNum := 1; blockNum <= 5; blockNum++ {
+		for i := 0; i < 2; i++ {
+			tx := testObservedTransaction(blockNum, i).Transaction
+
+			if err := repo.Insert(_testAddress, blockNum, tx, i == 0); err != nil {
+				t.Fatalf("Insert() error = %v", err)
+			}
+		}
+	}
+
+	// Every bucket is read until the page and the first transaction of the next page have matched in it,
+	// i.e. up to block 4. Corrupt a transaction of block 5, so that the query fails if it reads past that block.
+	err := db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(_inboundTransactionsBucket).Bucket([]byte(_testAddress))
+		corrupt := testObservedTransaction(5, 0).Transaction
+
+		return bucket.Put(transactionKey(5, corrupt.Hash), []byte("corrupt"))
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	query := blocks.TransactionQuery{Limit: 3}
+
+	page, next, err := repo.QueryTransactionsPerAddress(_testAddress, query)
+	if err != nil {
+		t.Fatalf("QueryTransactionsPerAddress() error = %v", err)
+	}
+
+	if got := positions(t, page); fmt.Sprint(got) != "[1-0 1-1 2-0]" || next == nil {
+		t.Fatalf("QueryTransactionsPerAddress() = %v, next %v, want [1-0 1-1 2-0] and a next cursor", got, next)
+	}
+
+	query.After = next
+	query.BlockRange = &blocks.Range{From: 0, To: 3}
+
+	page, next, err = repo.QueryTransactionsPerAddress(_testAddress, query)
+	if err != nil {
+		t.Fatalf("QueryTransactionsPerAddress() error = %v", err)
+	}
+
+	if got := positions(t, page); fmt.Sprint(got) != "[2-1 3-0 3-1]" || next != nil {
+		t.Errorf("QueryTransactionsPerAddress() = %v, next %v, want [2-1 3-0 3-1] and no next cursor", got, next)
+	}
+}
+
+func TestInsertScannedBlockRange(t *testing.T) {
+	repo := NewTransactionsRepository(openTestDB(t))
+
+	latestBlockNum, err := repo.GetLatestBlockNumberPerAddress(_testAddress)
+	if err != nil {
+		t.Fatalf("GetLatestBlockNumberPerAddress() error = %v", err)
+	}
+
+	if latestBlockNum != -1 {
+		t.Errorf("GetLatestBlockNumberPerAddress() = %d, want -1 without scanned blocks", latestBlockNum)
+	}
+
+	for _, blockRange := range []blocks.Range{{From: 10, To: 12}, {From: 0, To: 4}, {From: 5, To: 6}} {
+		if err = repo.InsertScannedBlockRange(_testAddress, blockRange); err != nil {
+			t.Fatalf("InsertScannedBlockRange() error = %v", err)
+		}
+	}
+
+	ranges, err := repo.GetScannedBlockRangesPerAddress(_testAddress)
+	if err != nil {
+		t.Fatalf("GetScannedBlockRangesPerAddress() error = %v", err)
+	}
+
+	if want := []blocks.Range{{From: 0, To: 6}, {From: 10, To: 12}}; fmt.Sprint(ranges) != fmt.Sprint(want) {
+		t.Errorf("GetScannedBlockRangesPerAddress() = %v, want %v", ranges, want)
+	}
+
+	latestBlockNum, err = repo.GetLatestBlockNumberPerAddress(_testAddress)
+	if err != nil {
+		t.Fatalf("GetLatestBlockNumberPerAddress() error = %v", err)
+	}
+
+	if latestBlockNum != 12 {
+		t.Errorf("GetLatestBlockNumberPerAddress() = %d, want 12", latestBlockNum)
+	}
+}
+
+func TestRemoveTransactionsFromBlock(t *testing.T) {
+	const otherAddress = "0x00000000000000000000000000000000000000bb"
+
+	repo := NewTransactionsRepository(openTestDB(t))
+
+	for _, address := range []string{_testAddress, otherAddress} {
+		for blockNum := 1; blockNum <= 5; blockNum++ {
+			for i := 0; i < 2; i++ {
+				tx := testObservedTransaction(blockNum, i).Transaction
+
+				if err := repo.Insert(address, blockNum, tx, i == 0); err != nil {
+					t.Fatalf("Insert() error = %v", err)
+				}
+			}
+		}
+
+		for _, blockRange := range []blocks.Range{{From: 0, To: 1}, {From: 3, To: 5}} {
+			if err := repo.InsertScannedBlockRange(address, blockRange); err != nil {
+				t.Fatalf("InsertScannedBlockRange() error = %v", err)
+			}
+		}
+	}
+
+	if err := repo.RemoveTransactionsFromBlock(_testAddress, 4); err != nil {
+		t.Fatalf("RemoveTransactionsFromBlock() error = %v", err)
+	}
+
+	tests := []struct {
+		address       string
+		wantPositions string
+		wantRanges    []blocks.Range
+	}{
+		{
+			address:       _testAddress,
+			wantPositions: "[1-0 1-1 2-0 2-1 3-0 3-1]",
+			wantRanges:    []blocks.Range{{From: 0, To: 1}, {From: 3, To: 3}},
+		},
+		{
+			address:       otherAddress,
+			wantPositions: "[1-0 1-1 2-0 2-1 3-0 3-1 4-0 4-1 5-0 5-1]",
+			wantRanges:    []blocks.Range{{From: 0, To: 1}, {From: 3, To: 5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			page, _, err := repo.QueryTransactionsPerAddress(tt.address, blocks.TransactionQuery{})
+			if err != nil {
+				t.Fatalf("QueryTransactionsPerAddress() error = %v", err)
+			}
+
+			if got := positions(t, page); fmt.Sprint(got) != tt.wantPositions {
+				t.Errorf("QueryTransactionsPerAddress() = %v, want %s", got, tt.wantPositions)
+			}
+
+			ranges, err := repo.GetScannedBlockRangesPerAddress(tt.address)
+			if err != nil {
+				t.Fatalf("GetScannedBlockRangesPerAddress() error = %v", err)
+			}
+
+			if fmt.Sprint(ranges) != fmt.Sprint(tt.wantRanges) {
+				t.Errorf("GetScannedBlockRangesPerAddress() = %v, want %v", ranges, tt.wantRanges)
+			}
+		})
+	}
+}
+
+func transactionBlockNumbers(txs []blocks.Transaction) []blocks.Quantity {
+	got := make([]blocks.Quantity, len(txs))
+
+	for i, tx := range txs {
+		got[i] = tx.BlockNumber
+	}
+
+	return got
+}
@@ -0,0 +1,44 @@
+package boltdb
+
+import (
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+// UsageRepository holds the db operations for the compute units spent on RPC calls persisted in a bolt database.
+type UsageRepository struct {
+	db *DB
+}
+
+// NewUsageRepository is a constructor function for UsageRepository.
+func NewUsageRepository(db *DB) *UsageRepository {
+	return &UsageRepository{
+		db: db,
+	}
+}
+
+// GetBudgetUsage returns the stored usage of the RPC compute unit budget or nil if none has been stored yet.
+func (r *UsageRepository) GetBudgetUsage() (*jsonrpc.BudgetUsage, error) {
+	var usage *jsonrpc.BudgetUsage
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		usageBytes := tx.Bucket(_metaBucket).Get(_budgetUsageKey)
+		if usageBytes == nil {
+			return nil
+		}
+
+		return json.Unmarshal(usageBytes, &usage)
+	})
+
+	return usage, err
+}
+
+// UpdateBudgetUsage stores the usage of the RPC compute unit budget.
+func (r *UsageRepository) UpdateBudgetUsage(usage jsonrpc.BudgetUsage) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(_metaBucket), _budgetUsageKey, usage)
+	})
+}
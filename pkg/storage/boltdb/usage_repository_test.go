@@ -0,0 +1,38 @@
+package boltdb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+func TestUpdateBudgetUsage(t *testing.T) {
+	repo := NewUsageRepository(openTestDB(t))
+
+	usage, err := repo.GetBudgetUsage()
+	if err != nil || usage != nil {
+		t.Fatalf("GetBudgetUsage() = %v, %v, want no usage", usage, err)
+	}
+
+	want := jsonrpc.BudgetUsage{
+		Day:         "2026-10-17",
+		DailyUsed:   40,
+		Month:       "2026-10",
+		MonthlyUsed: 90,
+		Methods:     map[string]jsonrpc.MethodUsage{"eth_blockNumber": {Calls: 9, ComputeUnits: 90}},
+	}
+
+	if err = repo.UpdateBudgetUsage(want); err != nil {
+		t.Fatalf("UpdateBudgetUsage() error = %v", err)
+	}
+
+	usage, err = repo.GetBudgetUsage()
+	if err != nil {
+		t.Fatalf("GetBudgetUsage() error = %v", err)
+	}
+
+	if usage == nil || !reflect.DeepEqual(*usage, want) {
+		t.Errorf("GetBudgetUsage() = %+v, want %+v", usage, want)
+	}
+}
@@ -0,0 +1,265 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+// WebhooksRepository holds the CRUD db operations for webhooks and their deliveries persisted in a bolt database.
+//
+// Deliveries are kept in per address buckets keyed by their ID and pending deliveries are indexed
+// by ID in a separate bucket, so that due deliveries are found without walking the whole delivery log.
+// The IDs of deliveries are also indexed by their key, so that a transaction is delivered to a webhook once.
+type WebhooksRepository struct {
+	db *DB
+}
+
+// NewWebhooksRepository is a constructor function for WebhooksRepository.
+func NewWebhooksRepository(db *DB) *WebhooksRepository {
+	return &WebhooksRepository{
+		db: db,
+	}
+}
+
+// InsertWebhook registers a new webhook for a subscribed address.
+func (r *WebhooksRepository) InsertWebhook(webhook blocks.Webhook) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(_webhooksBucket).CreateBucketIfNotExists([]byte(webhook.Address))
+		if err != nil {
+			return err
+		}
+
+		return putJSON(bucket, []byte(webhook.ID), webhook)
+	})
+}
+
+// GetWebhooksPerAddress returns all webhooks registered for a subscribed address.
+func (r *WebhooksRepository) GetWebhooksPerAddress(address string) ([]blocks.Webhook, error) {
+	var webhooks []blocks.Webhook
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		var err error
+
+		webhooks, err = decodeValues[blocks.Webhook](tx.Bucket(_webhooksBucket).Bucket([]byte(address)))
+
+		return err
+	})
+
+	return webhooks, err
+}
+
+// RemoveWebhook removes a webhook of a subscribed address.
+func (r *WebhooksRepository) RemoveWebhook(address, id string) (bool, error) {
+	var found bool
+
+	err := r.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(_webhooksBucket).Bucket([]byte(address))
+		if bucket == nil {
+			return nil
+		}
+
+		if found = bucket.Get([]byte(id)) != nil; !found {
+			return nil
+		}
+
+		return bucket.Delete([]byte(id))
+	})
+
+	return found, err
+}
+
+// RemoveWebhooksPerAddress removes all webhooks and the signing secret of a subscribed address.
+// If purge is set, the delivery log of the address is removed as well.
+func (r *WebhooksRepository) RemoveWebhooksPerAddress(address string, purge bool) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(_webhooksBucket).DeleteBucket([]byte(address))
+		if err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+
+		if err = tx.Bucket(_webhookSecretsBucket).Delete([]byte(address)); err != nil {
+			return err
+		}
+
+		deliveries := tx.Bucket(_webhookDeliveriesBucket).Bucket([]byte(address))
+		if !purge || deliveries == nil {
+			return nil
+		}
+
+		pending := tx.Bucket(_pendingDeliveriesBucket)
+
+		err = deliveries.ForEach(func(id, _ []byte) error {
+			return pending.Delete(id)
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(_webhookDeliveryKeysBucket).DeleteBucket([]byte(address))
+		if err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+
+		return tx.Bucket(_webhookDeliveriesBucket).DeleteBucket([]byte(address))
+	})
+}
+
+// GetOrCreateSecret returns the webhook signing secret of a subscribed address.
+// If the address has no secret yet, the given one is stored and returned.
+func (r *WebhooksRepository) GetOrCreateSecret(address, secret string) (string, error) {
+	err := r.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(_webhookSecretsBucket)
+
+		if existing := bucket.Get([]byte(address)); existing != nil {
+			secret = string(existing)
+
+			return nil
+		}
+
+		return bucket.Put([]byte(address), []byte(secret))
+	})
+
+	return secret, err
+}
+
+// InsertDelivery stores a new delivery and returns it with its assigned ID. Deliveries are keyed by webhook
+// and transaction, so inserting a delivery which is stored already returns the stored one and false.
+func (r *WebhooksRepository) InsertDelivery(
+	delivery blocks.WebhookDelivery) (blocks.WebhookDelivery, bool, error) {
+	inserted := false
+
+	err := r.db.bolt.Update(func(tx *bolt.Tx) error {
+		keys, err := tx.Bucket(_webhookDeliveryKeysBucket).CreateBucketIfNotExists([]byte(delivery.Address))
+		if err != nil {
+			return err
+		}
+
+		if id := keys.Get([]byte(delivery.Key())); id != nil {
+			deliveries := tx.Bucket(_webhookDeliveriesBucket).Bucket([]byte(delivery.Address))
+
+			return json.Unmarshal(deliveries.Get(id), &delivery)
+		}
+
+		seq, err := tx.Bucket(_webhookDeliveriesBucket).NextSequence()
+		if err != nil {
+			return err
+		}
+
+		delivery.ID = fmt.Sprintf("%016x", seq)
+		inserted = true
+
+		if err = keys.Put([]byte(delivery.Key()), []byte(delivery.ID)); err != nil {
+			return err
+		}
+
+		return putDelivery(tx, delivery)
+	})
+
+	return delivery, inserted, err
+}
+
+// UpdateDelivery replaces a stored delivery with the same ID.
+func (r *WebhooksRepository) UpdateDelivery(delivery blocks.WebhookDelivery) error {
+	return r.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(_webhookDeliveriesBucket).Bucket([]byte(delivery.Address))
+		if bucket == nil || bucket.Get([]byte(delivery.ID)) == nil {
+			return nil
+		}
+
+		return putDelivery(tx, delivery)
+	})
+}
+
+// GetDueDeliveries returns up to limit pending deliveries whose next attempt is due at a given time.
+func (r *WebhooksRepository) GetDueDeliveries(now time.Time, limit int) ([]blocks.WebhookDelivery, error) {
+	deliveries := make([]blocks.WebhookDelivery, 0)
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(_webhookDeliveriesBucket)
+		cursor := tx.Bucket(_pendingDeliveriesBucket).Cursor()
+
+		for id, address := cursor.First(); id != nil && len(deliveries) < limit; id, address = cursor.Next() {
+			bucket := root.Bucket(address)
+			if bucket == nil {
+				continue
+			}
+
+			var delivery blocks.WebhookDelivery
+
+			if err := json.Unmarshal(bucket.Get(id), &delivery); err != nil {
+				return err
+			}
+
+			if !delivery.NextAttemptAt.After(now) {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+
+		return nil
+	})
+
+	return deliveries, err
+}
+
+// GetDeliveriesPerAddress returns a page of the delivery log of a subscribed address in delivery order
+// together with the total number of deliveries. An empty status matches deliveries of any status.
+func (r *WebhooksRepository) GetDeliveriesPerAddress(
+	address string, status blocks.DeliveryStatus, offset, limit int) ([]blocks.WebhookDelivery, int, error) {
+	deliveries := make([]blocks.WebhookDelivery, 0)
+	total := 0
+
+	err := r.db.bolt.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(_webhookDeliveriesBucket).Bucket([]byte(address))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(_, valueBytes []byte) error {
+			var delivery blocks.WebhookDelivery
+
+			if err := json.Unmarshal(valueBytes, &delivery); err != nil {
+				return err
+			}
+
+			if status != "" && delivery.Status != status {
+				return nil
+			}
+
+			if total >= offset && total < offset+limit {
+				deliveries = append(deliveries, delivery)
+			}
+
+			total++
+
+			return nil
+		})
+	})
+
+	return deliveries, total, err
+}
+
+// putDelivery stores a delivery in the bucket of its address and keeps the pending index in sync with its status.
+func putDelivery(tx *bolt.Tx, delivery blocks.WebhookDelivery) error {
+	bucket, err := tx.Bucket(_webhookDeliveriesBucket).CreateBucketIfNotExists([]byte(delivery.Address))
+	if err != nil {
+		return err
+	}
+
+	if err = putJSON(bucket, []byte(delivery.ID), delivery); err != nil {
+		return err
+	}
+
+	pending := tx.Bucket(_pendingDeliveriesBucket)
+
+	if delivery.Status == blocks.DeliveryPending {
+		return pending.Put([]byte(delivery.ID), []byte(delivery.Address))
+	}
+
+	return pending.Delete([]byte(delivery.ID))
+}
@@ -0,0 +1,376 @@
+package boltdb
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+func TestDeliveryLog(t *testing.T) {
+	repo := NewWebhooksRepository(openTestDB(t))
+	now := time.Now().UTC()
+
+	// The deliveries of transactions 1 and 2 are due, the one of transaction 3 is retried later.
+	var ids []string
+
+	for txHash := byte(1); txHash <= 3; txHash++ {
+		delivery := testDelivery("a", txHash)
+		delivery.NextAttemptAt = now.Add(time.Duration(int(txHash)-2) * time.Minute)
+
+		stored, _, err := repo.InsertDelivery(delivery)
+		if err != nil {
+			t.Fatalf("InsertDelivery() error = %v", err)
+		}
+
+		ids = append(ids, stored.ID)
+	}
+
+	due, err := repo.GetDueDeliveries(now, 10)
+	if err != nil {
+		t.Fatalf("GetDueDeliveries() error = %v", err)
+	}
+
+	if got := deliveryIDs(due); fmt.Sprint(got) != fmt.Sprint(ids[:2]) {
+		t.Fatalf("GetDueDeliveries() = %v, want %v", got, ids[:2])
+	}
+
+	delivered := due[0]
+	delivered.Status = blocks.DeliveryDelivered
+	delivered.Attempts = 1
+
+	if err = repo.UpdateDelivery(delivered); err != nil {
+		t.Fatalf("UpdateDelivery() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		status    blocks.DeliveryStatus
+		offset    int
+		limit     int
+		want      []string
+		wantTotal int
+	}{
+		{name: "all deliveries", limit: 10, want: ids, wantTotal: 3},
+		{name: "page of all deliveries", offset: 1, limit: 1, want: ids[1:2], wantTotal: 3},
+		{name: "delivered", status: blocks.DeliveryDelivered, limit: 10, want: ids[:1], wantTotal: 1},
+		{name: "pending", status: blocks.DeliveryPending, limit: 10, want: ids[1:], wantTotal: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries, total, errGet := repo.GetDeliveriesPerAddress(_testAddress, tt.status, tt.offset, tt.limit)
+			if errGet != nil {
+				t.Fatalf("GetDeliveriesPerAddress() error = %v", errGet)
+			}
+
+			if got := deliveryIDs(deliveries); fmt.Sprint(got) != fmt.Sprint(tt.want) || total != tt.wantTotal {
+				t.Errorf("GetDeliveriesPerAddress() = %v of %d, want %v of %d", got, total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+
+	due, err = repo.GetDueDeliveries(now.Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("GetDueDeliveries() error = %v", err)
+	}
+
+	if got := deliveryIDs(due); fmt.Sprint(got) != fmt.Sprint(ids[1:]) {
+		t.Errorf("GetDueDeliveries() after the delivery = %v, want %v", got, ids[1:])
+	}
+
+	if err = repo.RemoveWebhooksPerAddress(_testAddress, true); err != nil {
+		t.Fatalf("RemoveWebhooksPerAddress() error = %v", err)
+	}
+
+	due, err = repo.GetDueDeliveries(now.Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("GetDueDeliveries() error = %v", err)
+	}
+
+	if len(due) != 0 {
+		t.Errorf("GetDueDeliveries() after purging = %v, want none", deliveryIDs(due))
+	}
+}
+
+func TestInsertDelivery(t *testing.T) {
+	tests := []struct {
+		name         string
+		deliveries   []blocks.WebhookDelivery
+		purgeBefore  int
+		wantInserted []bool
+		wantLog      int
+	}{
+		{
+			name:         "same transaction to the same webhook",
+			deliveries:   []blocks.WebhookDelivery{testDelivery("a", 1), testDelivery("a", 1)},
+			purgeBefore:  -1,
+			wantInserted: []bool{true, false},
+			wantLog:      1,
+		},
+		{
+			name:         "same transaction to another webhook",
+			deliveries:   []blocks.WebhookDelivery{testDelivery("a", 1), testDelivery("b", 1)},
+			purgeBefore:  -1,
+			wantInserted: []bool{true, true},
+			wantLog:      2,
+		},
+		{
+			name:         "another transaction to the same webhook",
+			deliveries:   []blocks.WebhookDelivery{testDelivery("a", 1), testDelivery("a", 2)},
+			purgeBefore:  -1,
+			wantInserted: []bool{true, true},
+			wantLog:      2,
+		},
+		{
+			name:         "retraction of the same transaction",
+			deliveries:   []blocks.WebhookDelivery{testDelivery("a", 1), testRetraction("a", 1), testRetraction("a", 1)},
+			purgeBefore:  -1,
+			wantInserted: []bool{true, true, false},
+			wantLog:      2,
+		},
+		{
+			name:         "same transaction after the delivery log was purged",
+			deliveries:   []blocks.WebhookDelivery{testDelivery("a", 1), testDelivery("a", 1)},
+			purgeBefore:  1,
+			wantInserted: []bool{true, true},
+			wantLog:      1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewWebhooksRepository(openTestDB(t))
+			ids := make(map[string]bool)
+
+			for i, delivery := range tt.deliveries {
+				if i == tt.purgeBefore {
+					if err := repo.RemoveWebhooksPerAddress(_testAddress, true); err != nil {
+						t.Fatalf("RemoveWebhooksPerAddress() error = %v", err)
+					}
+				}
+
+				stored, inserted, err := repo.InsertDelivery(delivery)
+				if err != nil {
+					t.Fatalf("InsertDelivery() error = %v", err)
+				}
+
+				if inserted != tt.wantInserted[i] {
+					t.Errorf("InsertDelivery() #%d inserted = %v, want %v", i, inserted, tt.wantInserted[i])
+				}
+
+				ids[stored.ID] = true
+			}
+
+			_, total, err := repo.GetDeliveriesPerAddress(_testAddress, "", 0, 10)
+			if err != nil {
+				t.Fatalf("GetDeliveriesPerAddress() error = %v", err)
+			}
+
+			if total != tt.wantLog {
+				t.Errorf("delivery log holds %d deliveries, want %d", total, tt.wantLog)
+			}
+
+			due, err := repo.GetDueDeliveries(tt.deliveries[0].NextAttemptAt, 10)
+			if err != nil {
+				t.Fatalf("GetDueDeliveries() error = %v", err)
+			}
+
+			if len(due) != tt.wantLog {
+				t.Errorf("GetDueDeliveries() returned %d deliveries, want %d", len(due), tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestMigrateIndexesDeliveryKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scanner.db")
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	stored, _, err := NewWebhooksRepository(db).InsertDelivery(testDelivery("a", 1))
+	if err != nil {
+		t.Fatalf("InsertDelivery() error = %v", err)
+	}
+
+	// Turn the database into one written before deliveries were keyed.
+	err = db.bolt.Update(func(tx *bolt.Tx) error {
+		if errDelete := tx.Bucket(_webhookDeliveryKeysBucket).DeleteBucket([]byte(_testAddress)); errDelete != nil {
+			return errDelete
+		}
+
+		return tx.Bucket(_metaBucket).Put(_schemaVersionKey, encodeInt(1))
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	defer db.Close()
+
+	again, inserted, err := NewWebhooksRepository(db).InsertDelivery(testDelivery("a", 1))
+	if err != nil {
+		t.Fatalf("InsertDelivery() error = %v", err)
+	}
+
+	if inserted || again.ID != stored.ID {
+		t.Errorf("InsertDelivery() after migration = %s, inserted %v, want %s", again.ID, inserted, stored.ID)
+	}
+}
+
+func TestRemoveWebhook(t *testing.T) {
+	repo := NewWebhooksRepository(openTestDB(t))
+
+	for _, id := range []string{"a", "b"} {
+		webhook := blocks.Webhook{ID: id, Address: _testAddress, URL: "http://localhost/" + id}
+
+		if err := repo.InsertWebhook(webhook); err != nil {
+			t.Fatalf("InsertWebhook() error = %v", err)
+		}
+	}
+
+	for _, tt := range []struct {
+		id        string
+		wantFound bool
+	}{
+		{id: "a", wantFound: true},
+		{id: "a"},
+		{id: "c"},
+	} {
+		found, err := repo.RemoveWebhook(_testAddress, tt.id)
+		if err != nil {
+			t.Fatalf("RemoveWebhook() error = %v", err)
+		}
+
+		if found != tt.wantFound {
+			t.Errorf("RemoveWebhook(%s) = %v, want %v", tt.id, found, tt.wantFound)
+		}
+	}
+
+	webhooks, err := repo.GetWebhooksPerAddress(_testAddress)
+	if err != nil {
+		t.Fatalf("GetWebhooksPerAddress() error = %v", err)
+	}
+
+	if len(webhooks) != 1 || webhooks[0].ID != "b" {
+		t.Errorf("GetWebhooksPerAddress() = %+v, want webhook b", webhooks)
+	}
+}
+
+func TestMigrateReindexesDeliveryKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scanner.db")
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	delivery := testDelivery("a", 1)
+
+	stored, _, err := NewWebhooksRepository(db).InsertDelivery(delivery)
+	if err != nil {
+		t.Fatalf("InsertDelivery() error = %v", err)
+	}
+
+	// Turn the database into one written before deliveries were keyed by block and retraction.
+	oldKey := []byte(delivery.WebhookID + "/" + delivery.Payload.Transaction.Key(_testAddress))
+
+	err = db.bolt.Update(func(tx *bolt.Tx) error {
+		keys := tx.Bucket(_webhookDeliveryKeysBucket).Bucket([]byte(_testAddress))
+
+		if errDelete := keys.Delete([]byte(delivery.Key())); errDelete != nil {
+			return errDelete
+		}
+
+		if errPut := keys.Put(oldKey, []byte(stored.ID)); errPut != nil {
+			return errPut
+		}
+
+		return tx.Bucket(_metaBucket).Put(_schemaVersionKey, encodeInt(5))
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	defer db.Close()
+
+	again, inserted, err := NewWebhooksRepository(db).InsertDelivery(delivery)
+	if err != nil {
+		t.Fatalf("InsertDelivery() error = %v", err)
+	}
+
+	if inserted || again.ID != stored.ID {
+		t.Errorf("InsertDelivery() after migration = %s, inserted %v, want %s", again.ID, inserted, stored.ID)
+	}
+
+	err = db.bolt.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(_webhookDeliveryKeysBucket).Bucket([]byte(_testAddress)).Get(oldKey) != nil {
+			t.Errorf("delivery is still indexed under its old key %s", oldKey)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+}
+
+// testDelivery returns a pending delivery of a transaction, whose hash ends with a given byte, to a given webhook.
+func testDelivery(webhookID string, txHash byte) blocks.WebhookDelivery {
+	var hash blocks.Hash
+
+	hash[len(hash)-1] = txHash
+
+	return blocks.WebhookDelivery{
+		WebhookID: webhookID,
+		Address:   _testAddress,
+		Payload: blocks.WebhookPayload{
+			Address:     _testAddress,
+			Transaction: blocks.ObservedTransaction{Transaction: blocks.Transaction{Hash: hash}},
+		},
+		Status: blocks.DeliveryPending,
+	}
+}
+
+func deliveryIDs(deliveries []blocks.WebhookDelivery) []string {
+	ids := make([]string, len(deliveries))
+
+	for i, delivery := range deliveries {
+		ids[i] = delivery.ID
+	}
+
+	return ids
+}
+
+// testRetraction returns a pending delivery of the retraction of a transaction, whose hash ends with a given byte,
+// to a given webhook.
+func testRetraction(webhookID string, txHash byte) blocks.WebhookDelivery {
+	delivery := testDelivery(webhookID, txHash)
+	delivery.Payload.Retracted = true
+
+	return delivery
+}
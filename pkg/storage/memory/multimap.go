@@ -31,6 +31,77 @@ func (m *MultiMap[K, V]) Put(key K, value V) {
 	m.Unlock()
 }
 
+// PutIfAbsent stores a key-value pair unless a value matched by the same function is stored under the key already.
+// It returns the stored value and true if the given value was stored.
+func (m *MultiMap[K, V]) PutIfAbsent(key K, value V, same func(existing V) bool) (V, bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, existing := range m.m[key] {
+		if same(existing) {
+			return existing, false
+		}
+	}
+
+	m.m[key] = append(m.m[key], value)
+
+	return value, true
+}
+
+// ReplaceFunc replaces all values stored under a key for which the match function returns true with a new value.
+func (m *MultiMap[K, V]) ReplaceFunc(key K, value V, match func(existing V) bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	existingValues, found := m.m[key]
+	if !found {
+		return
+	}
+
+	values := make([]V, len(existingValues))
+
+	for i, existing := range existingValues {
+		if match(existing) {
+			values[i] = value
+		} else {
+			values[i] = existing
+		}
+	}
+
+	m.m[key] = values
+}
+
+// Remove removes a key together with all its values from the multimap.
+func (m *MultiMap[K, V]) Remove(key K) {
+	m.Lock()
+	delete(m.m, key)
+	m.Unlock()
+}
+
+// ForEach calls a function for every key of the multimap together with its values.
+func (m *MultiMap[K, V]) ForEach(fn func(key K, values []V)) {
+	m.RLock()
+	defer m.RUnlock()
+
+	for key, values := range m.m {
+		fn(key, values)
+	}
+}
+
+// Len returns the number of values stored under all keys of the multimap.
+func (m *MultiMap[K, V]) Len() int {
+	m.RLock()
+	defer m.RUnlock()
+
+	n := 0
+
+	for _, values := range m.m {
+		n += len(values)
+	}
+
+	return n
+}
+
 // PutAll stores a key-value pair in then multimap for each of the values, all using the same key.
 func (m *MultiMap[K, V]) PutAll(key K, values []V) {
 	m.Lock()
@@ -39,3 +110,44 @@ func (m *MultiMap[K, V]) PutAll(key K, values []V) {
 	}
 	m.RUnlock()
 }
+
+// RemoveFunc removes all values for which the remove function returns true regardless of their key.
+// Keys left without any values are deleted from the multimap.
+func (m *MultiMap[K, V]) RemoveFunc(remove func(value V) bool) {
+	m.Lock()
+	for key := range m.m {
+		m.removeFunc(key, remove)
+	}
+	m.Unlock()
+}
+
+// RemoveKeyFunc removes the values stored under a key for which the remove function returns true.
+// The key is deleted from the multimap if it is left without any values.
+func (m *MultiMap[K, V]) RemoveKeyFunc(key K, remove func(value V) bool) {
+	m.Lock()
+	m.removeFunc(key, remove)
+	m.Unlock()
+}
+
+// removeFunc removes the values stored under a key for which the remove function returns true.
+// The caller must hold the lock.
+func (m *MultiMap[K, V]) removeFunc(key K, remove func(value V) bool) {
+	values, found := m.m[key]
+	if !found {
+		return
+	}
+
+	kept := make([]V, 0, len(values))
+
+	for _, value := range values {
+		if !remove(value) {
+			kept = append(kept, value)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(m.m, key)
+	} else {
+		m.m[key] = kept
+	}
+}
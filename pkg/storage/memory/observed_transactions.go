@@ -0,0 +1,299 @@
+package memory
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+// observedTransactions keeps the observed transactions of every address ordered by their position in a listing,
+// so that a page of them is found with a binary search rather than by sorting all of them. The transactions
+// are referred to in sequence order and per status as well, so that the transactions stored after a given sequence
+// or the ones which are not finalized yet are found without walking all of them. It is not safe for concurrent use.
+type observedTransactions struct {
+	byAddress map[string][]observedEntry
+	sequences []sequenceRef
+	byStatus  map[blocks.ConfirmationStatus]map[positionRef]bool
+}
+
+// observedKey identifies an observed transaction of an address like the keys of the bolt store do, by its position
+// in a listing, its ID and its direction relative to the address, and orders the transactions in listing order.
+type observedKey struct {
+	cursor    blocks.TransactionCursor
+	direction blocks.Direction
+}
+
+// observedEntry represents an observed transaction together with its key.
+type observedEntry struct {
+	key observedKey
+	tx  blocks.ObservedTransaction
+}
+
+// sequenceRef refers to an observed transaction by its address and its key in sequence order.
+type sequenceRef struct {
+	sequence uint64
+	address  string
+	key      observedKey
+}
+
+// positionRef refers to an observed transaction by its address and its key.
+type positionRef struct {
+	address string
+	key     observedKey
+}
+
+func newObservedTransactions() *observedTransactions {
+	return &observedTransactions{
+		byAddress: make(map[string][]observedEntry),
+		byStatus:  make(map[blocks.ConfirmationStatus]map[positionRef]bool),
+	}
+}
+
+// insert stores a transaction of an address unless a transaction with the same key is stored already,
+// in which case the stored one is returned together with false. Transactions must be inserted in the order
+// of their sequences.
+func (o *observedTransactions) insert(
+	address string, tx blocks.ObservedTransaction) (blocks.ObservedTransaction, bool, error) {
+	key, err := newObservedKey(address, tx)
+	if err != nil {
+		return tx, false, err
+	}
+
+	entries := o.byAddress[address]
+
+	i := o.search(address, key)
+	if i < len(entries) && entries[i].key == key {
+		return entries[i].tx, false, nil
+	}
+
+	o.byAddress[address] = slices.Insert(entries, i, observedEntry{key: key, tx: tx})
+	o.sequences = append(o.sequences, sequenceRef{sequence: tx.Sequence, address: address, key: key})
+	o.indexStatus(address, key, tx.Status)
+
+	return tx, true, nil
+}
+
+// replace replaces the stored transaction of an address with the key of a given one, if any.
+// The stored transaction keeps its sequence.
+func (o *observedTransactions) replace(address string, tx blocks.ObservedTransaction) error {
+	key, err := newObservedKey(address, tx)
+	if err != nil {
+		return err
+	}
+
+	entries := o.byAddress[address]
+
+	if i := o.search(address, key); i < len(entries) && entries[i].key == key {
+		o.unindexStatus(address, key, entries[i].tx.Status)
+		o.indexStatus(address, key, tx.Status)
+
+		tx.Sequence = entries[i].tx.Sequence
+		entries[i].tx = tx
+	}
+
+	return nil
+}
+
+// perStatus returns the transactions with any of the given statuses per address.
+func (o *observedTransactions) perStatus(
+	statuses ...blocks.ConfirmationStatus) map[string][]blocks.ObservedTransaction {
+	txs := make(map[string][]blocks.ObservedTransaction)
+
+	for _, status := range statuses {
+		for ref := range o.byStatus[status] {
+			entries := o.byAddress[ref.address]
+
+			if i := o.search(ref.address, ref.key); i < len(entries) && entries[i].key == ref.key {
+				txs[ref.address] = append(txs[ref.address], entries[i].tx)
+			}
+		}
+	}
+
+	return txs
+}
+
+// get returns all transactions of an address in listing order.
+func (o *observedTransactions) get(address string) []blocks.ObservedTransaction {
+	entries := o.byAddress[address]
+	txs := make([]blocks.ObservedTransaction, len(entries))
+
+	for i, entry := range entries {
+		txs[i] = entry.tx
+	}
+
+	return txs
+}
+
+// query returns the transactions of an address matching a query in listing order. Reading starts at the cursor
+// or the start of the block range and stops once the page and the first transaction of the next page have matched.
+func (o *observedTransactions) query(
+	address string, query blocks.TransactionQuery) ([]blocks.ObservedTransaction, error) {
+	var start blocks.TransactionCursor
+
+	if query.BlockRange != nil {
+		start.BlockNumber = query.BlockRange.From
+	}
+
+	if query.After != nil && start.Before(*query.After) {
+		start = *query.After
+	}
+
+	entries := o.byAddress[address]
+	txs := make([]blocks.ObservedTransaction, 0)
+
+	for i := o.search(address, observedKey{cursor: start}); i < len(entries) && !query.Full(len(txs)); i++ {
+		if query.BlockRange != nil && entries[i].key.cursor.BlockNumber > query.BlockRange.To {
+			break
+		}
+
+		matches, err := query.Matches(address, entries[i].tx)
+		if err != nil {
+			return nil, err
+		}
+
+		if matches {
+			txs = append(txs, entries[i].tx)
+		}
+	}
+
+	return txs, nil
+}
+
+// after returns up to limit transactions of an address with a sequence greater than a given one in sequence order.
+// An empty address matches the transactions of all addresses.
+func (o *observedTransactions) after(address string, sequence uint64, limit int) []blocks.TransactionEvent {
+	events := make([]blocks.TransactionEvent, 0)
+
+	start := sort.Search(len(o.sequences), func(i int) bool {
+		return o.sequences[i].sequence > sequence
+	})
+
+	for _, ref := range o.sequences[start:] {
+		if len(events) >= limit {
+			break
+		}
+
+		if address != "" && ref.address != address {
+			continue
+		}
+
+		entries := o.byAddress[ref.address]
+
+		if i := o.search(ref.address, ref.key); i < len(entries) && entries[i].key == ref.key {
+			events = append(events, blocks.TransactionEvent{
+				Sequence:    ref.sequence,
+				Address:     ref.address,
+				Transaction: entries[i].tx,
+			})
+		}
+	}
+
+	return events
+}
+
+// removeFunc removes the transactions of all addresses for which the remove function returns true.
+// Addresses left without any transactions are forgotten.
+func (o *observedTransactions) removeFunc(remove func(tx blocks.ObservedTransaction) bool) {
+	removed := make(map[uint64]bool)
+
+	for address, entries := range o.byAddress {
+		entries = slices.DeleteFunc(entries, func(entry observedEntry) bool {
+			if remove(entry.tx) {
+				removed[entry.tx.Sequence] = true
+				o.unindexStatus(address, entry.key, entry.tx.Status)
+
+				return true
+			}
+
+			return false
+		})
+
+		if len(entries) == 0 {
+			delete(o.byAddress, address)
+		} else {
+			o.byAddress[address] = entries
+		}
+	}
+
+	o.sequences = slices.DeleteFunc(o.sequences, func(ref sequenceRef) bool {
+		return removed[ref.sequence]
+	})
+}
+
+// remove removes all transactions of an address.
+func (o *observedTransactions) remove(address string) {
+	for _, entry := range o.byAddress[address] {
+		o.unindexStatus(address, entry.key, entry.tx.Status)
+	}
+
+	delete(o.byAddress, address)
+
+	o.sequences = slices.DeleteFunc(o.sequences, func(ref sequenceRef) bool {
+		return ref.address == address
+	})
+}
+
+// count returns the number of transactions of an address.
+func (o *observedTransactions) count(address string) int {
+	return len(o.byAddress[address])
+}
+
+// len returns the number of transactions of all addresses.
+func (o *observedTransactions) len() int {
+	n := 0
+
+	for _, entries := range o.byAddress {
+		n += len(entries)
+	}
+
+	return n
+}
+
+// search returns the index of the first transaction of an address ordered at or after a given key.
+func (o *observedTransactions) search(address string, key observedKey) int {
+	entries := o.byAddress[address]
+
+	return sort.Search(len(entries), func(i int) bool {
+		return !entries[i].key.before(key)
+	})
+}
+
+// indexStatus refers to a transaction of an address from the index of its status.
+func (o *observedTransactions) indexStatus(
+	address string, key observedKey, status blocks.ConfirmationStatus) {
+	refs, found := o.byStatus[status]
+	if !found {
+		refs = make(map[positionRef]bool)
+		o.byStatus[status] = refs
+	}
+
+	refs[positionRef{address: address, key: key}] = true
+}
+
+// unindexStatus removes a transaction of an address from the index of its status.
+func (o *observedTransactions) unindexStatus(
+	address string, key observedKey, status blocks.ConfirmationStatus) {
+	delete(o.byStatus[status], positionRef{address: address, key: key})
+}
+
+// newObservedKey returns the key of a transaction observed for an address.
+func newObservedKey(address string, tx blocks.ObservedTransaction) (observedKey, error) {
+	cursor, err := tx.Cursor()
+	if err != nil {
+		return observedKey{}, err
+	}
+
+	_, to, _ := tx.Transfer()
+
+	return observedKey{cursor: cursor, direction: blocks.TransferDirection(address, to)}, nil
+}
+
+// before reports if a key is ordered before another one. Keys at the same position are ordered by direction.
+func (k observedKey) before(other observedKey) bool {
+	if k.cursor != other.cursor {
+		return k.cursor.Before(other.cursor)
+	}
+
+	return k.direction < other.direction
+}
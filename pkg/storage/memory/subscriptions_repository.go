@@ -1,73 +1,337 @@
 package memory
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
 )
 
-// SubscriptionsRepository holds the CRUD db operations for CasinoRoundBet.
+// subscriptionRecord represents the state of an address subscription.
+type subscriptionRecord struct {
+	createdAt        time.Time
+	lastCheckedBlock int
+}
+
+// SubscriptionsRepository holds the CRUD db operations for address subscriptions.
 type SubscriptionsRepository struct {
 	sync.RWMutex
-	subsStore       map[string]int
-	observedTxStore *MultiMap[string, blocks.Transaction]
+	subsStore         map[string]subscriptionRecord
+	observedTxs       *observedTransactions
+	tokenTxStore      *MultiMap[string, blocks.TokenTransfer]
+	processedBlockNum int
+	processedHashes   map[int]blocks.Hash
+	lastSequence      uint64
 }
 
 // NewSubscriptionsRepository is a constructor function for SubscriptionsRepository.
 func NewSubscriptionsRepository() *SubscriptionsRepository {
 	return &SubscriptionsRepository{
-		subsStore:       make(map[string]int, 0),
-		observedTxStore: New[string, blocks.Transaction](),
+		subsStore:         make(map[string]subscriptionRecord, 0),
+		observedTxs:       newObservedTransactions(),
+		tokenTxStore:      New[string, blocks.TokenTransfer](),
+		processedBlockNum: -1,
+		processedHashes:   make(map[int]blocks.Hash),
 	}
 }
 
 // InsertSubscriberAddress inserts a new address as a subscriber to be observed for new transactions.
-func (r *SubscriptionsRepository) InsertSubscriberAddress(address string) {
+func (r *SubscriptionsRepository) InsertSubscriberAddress(address string) error {
 	r.Lock()
-	r.subsStore[address] = -1
+	if _, found := r.subsStore[address]; !found {
+		r.subsStore[address] = subscriptionRecord{
+			createdAt:        time.Now().UTC(),
+			lastCheckedBlock: -1,
+		}
+	}
 	r.Unlock()
+
+	return nil
 }
 
-// InsertObservedTransaction inserts a new transaction that involves a subscribed address.
-func (r *SubscriptionsRepository) InsertObservedTransaction(address string, tx blocks.Transaction) {
-	r.observedTxStore.Put(address, tx)
+// RemoveSubscriberAddress removes a subscribed address. If purge is set, its observed transactions
+// and token transfers are removed as well.
+func (r *SubscriptionsRepository) RemoveSubscriberAddress(address string, purge bool) (bool, error) {
+	r.Lock()
+	_, found := r.subsStore[address]
+	delete(r.subsStore, address)
+
+	if found && purge {
+		r.observedTxs.remove(address)
+	}
+	r.Unlock()
+
+	if found && purge {
+		r.tokenTxStore.Remove(address)
+	}
+
+	return found, nil
 }
 
-// GetAllSubscriptions returns all address subscriptions.
-func (r *SubscriptionsRepository) GetAllSubscriptions() []string {
-	addresses := make([]string, len(r.subsStore))
+// GetSubscription returns the details of a subscribed address or nil if the address is not subscribed.
+func (r *SubscriptionsRepository) GetSubscription(address string) (*blocks.Subscription, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	sub, found := r.subsStore[address]
+	if !found {
+		return nil, nil
+	}
+
+	subscription := r.toSubscription(address, sub)
+
+	return &subscription, nil
+}
+
+// GetSubscriptions returns a page of subscriptions ordered by address together with the total number of them.
+func (r *SubscriptionsRepository) GetSubscriptions(offset, limit int) ([]blocks.Subscription, int, error) {
+	r.RLock()
+	addresses := make([]string, 0, len(r.subsStore))
+
+	for address := range r.subsStore {
+		addresses = append(addresses, address)
+	}
+
+	sort.Strings(addresses)
+
+	total := len(addresses)
+	subscriptions := make([]blocks.Subscription, 0)
+
+	for i := offset; i < total && i < offset+limit; i++ {
+		subscriptions = append(subscriptions, r.toSubscription(addresses[i], r.subsStore[addresses[i]]))
+	}
+	r.RUnlock()
+
+	return subscriptions, total, nil
+}
+
+// toSubscription combines the state of a subscription with the number of its observed transactions.
+// The caller must hold the lock.
+func (r *SubscriptionsRepository) toSubscription(address string, sub subscriptionRecord) blocks.Subscription {
+	return blocks.Subscription{
+		Address:              address,
+		CreatedAt:            sub.createdAt,
+		LastCheckedBlock:     sub.lastCheckedBlock,
+		ObservedTransactions: r.observedTxs.count(address),
+	}
+}
+
+// InsertObservedTransaction inserts a new transaction that involves a subscribed address and returns it
+// with its assigned sequence. Transactions are keyed like in the bolt store by position, ID and direction,
+// so inserting a transaction which is stored already returns the stored one and false.
+func (r *SubscriptionsRepository) InsertObservedTransaction(
+	address string, tx blocks.ObservedTransaction) (blocks.ObservedTransaction, bool, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	tx.Sequence = r.lastSequence + 1
+
+	stored, inserted, err := r.observedTxs.insert(address, tx)
+	if inserted {
+		r.lastSequence++
+	}
+
+	return stored, inserted, err
+}
+
+// GetTransactionEventsAfter returns up to limit observed transactions with a sequence greater than a given one
+// in sequence order. An empty address matches the observed transactions of all subscribed addresses.
+func (r *SubscriptionsRepository) GetTransactionEventsAfter(
+	address string, sequence uint64, limit int) ([]blocks.TransactionEvent, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.observedTxs.after(address, sequence, limit), nil
+}
+
+// GetObservedTransactionsPerStatus returns the observed transactions with any of the given statuses
+// per subscribed address.
+func (r *SubscriptionsRepository) GetObservedTransactionsPerStatus(
+	statuses ...blocks.ConfirmationStatus) (map[string][]blocks.ObservedTransaction, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.observedTxs.perStatus(statuses...), nil
+}
+
+// UpdateObservedTransactions replaces already observed transactions at the same positions per subscribed
+// address. All transactions are replaced while holding the lock.
+func (r *SubscriptionsRepository) UpdateObservedTransactions(txs map[string][]blocks.ObservedTransaction) error {
+	r.Lock()
+	defer r.Unlock()
+
+	for address, observedTxs := range txs {
+		for _, tx := range observedTxs {
+			if err := r.observedTxs.replace(address, tx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
 
-	var i int
+// RemoveObservedTransactionsPerBlockHash removes all observed transactions contained in a given block.
+func (r *SubscriptionsRepository) RemoveObservedTransactionsPerBlockHash(blockHash blocks.Hash) error {
+	r.Lock()
+	r.observedTxs.removeFunc(func(tx blocks.ObservedTransaction) bool {
+		return tx.BlockHash == blockHash
+	})
+	r.Unlock()
+
+	return nil
+}
+
+// InsertTokenTransfer inserts a new ERC-20 token transfer that involves a subscribed address.
+// Inserting a token transfer which is stored already is a no-op.
+func (r *SubscriptionsRepository) InsertTokenTransfer(address string, transfer blocks.TokenTransfer) error {
+	key := transfer.Key(address)
+
+	r.tokenTxStore.PutIfAbsent(address, transfer, func(existing blocks.TokenTransfer) bool {
+		return existing.Key(address) == key
+	})
+
+	return nil
+}
+
+// RemoveTokenTransfersPerBlockHash removes all observed ERC-20 token transfers contained in a given block.
+func (r *SubscriptionsRepository) RemoveTokenTransfersPerBlockHash(blockHash blocks.Hash) error {
+	r.tokenTxStore.RemoveFunc(func(transfer blocks.TokenTransfer) bool {
+		return transfer.BlockHash == blockHash
+	})
+
+	return nil
+}
 
+// GetAllSubscriptions returns all address subscriptions.
+func (r *SubscriptionsRepository) GetAllSubscriptions() ([]string, error) {
 	r.RLock()
+	addresses := make([]string, 0, len(r.subsStore))
+
 	for k := range r.subsStore {
-		addresses[i] = k
-		i++
+		addresses = append(addresses, k)
 	}
 	r.RUnlock()
 
-	return addresses
+	return addresses, nil
 }
 
 // GetLastCheckedBlockNumberPerAddress returns the last check block number a given subscribed address.
-func (r *SubscriptionsRepository) GetLastCheckedBlockNumberPerAddress(address string) int {
+func (r *SubscriptionsRepository) GetLastCheckedBlockNumberPerAddress(address string) (int, error) {
 	r.RLock()
-	blockNum, found := r.subsStore[address]
+	sub, found := r.subsStore[address]
 	r.RUnlock()
 
 	if !found {
-		return -1
+		return -1, nil
+	}
+
+	return sub.lastCheckedBlock, nil
+}
+
+// UpdateLastCheckedBlockNumberPerAddress updates the last checked block number for a given subscribed address.
+func (r *SubscriptionsRepository) UpdateLastCheckedBlockNumberPerAddress(address string, blockNum int) error {
+	r.Lock()
+	if sub, found := r.subsStore[address]; found {
+		sub.lastCheckedBlock = blockNum
+		r.subsStore[address] = sub
+	}
+	r.Unlock()
+
+	return nil
+}
+
+// GetLastProcessedBlockNumber returns the block number up to which the observer has processed the chain
+// or -1 if no block has been processed yet.
+func (r *SubscriptionsRepository) GetLastProcessedBlockNumber() (int, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.processedBlockNum, nil
+}
+
+// UpdateLastProcessedBlock moves the processed block cursor of the observer and records the hash of the block,
+// unless it is zero. The hashes of the blocks after it and the ones of the blocks which fell out of a window
+// of the most recent blocks are dropped.
+func (r *SubscriptionsRepository) UpdateLastProcessedBlock(blockNum int, blockHash blocks.Hash, window int) error {
+	r.Lock()
+	defer r.Unlock()
+
+	r.processedBlockNum = blockNum
+
+	for n := range r.processedHashes {
+		if n > blockNum || n <= blockNum-window {
+			delete(r.processedHashes, n)
+		}
+	}
+
+	if blockHash.IsZero() {
+		delete(r.processedHashes, blockNum)
+	} else {
+		r.processedHashes[blockNum] = blockHash
+	}
+
+	return nil
+}
+
+// GetProcessedBlockHashes returns the recorded hashes of the most recently processed blocks keyed by block number.
+func (r *SubscriptionsRepository) GetProcessedBlockHashes() (map[int]blocks.Hash, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	hashes := make(map[int]blocks.Hash, len(r.processedHashes))
+	for n, hash := range r.processedHashes {
+		hashes[n] = hash
 	}
 
-	return blockNum
+	return hashes, nil
 }
 
 // GetObservedTransactionsPerAddress returns all observed transactions per subscribed address.
-func (r *SubscriptionsRepository) GetObservedTransactionsPerAddress(address string) []blocks.Transaction {
-	txs, found := r.observedTxStore.Get(address)
+func (r *SubscriptionsRepository) GetObservedTransactionsPerAddress(
+	address string) ([]blocks.ObservedTransaction, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.observedTxs.get(address), nil
+}
+
+// QueryObservedTransactionsPerAddress returns a page of the observed transactions per subscribed address
+// matching a query. If further transactions match, the cursor of the page is returned as well.
+func (r *SubscriptionsRepository) QueryObservedTransactionsPerAddress(
+	address string, query blocks.TransactionQuery) ([]blocks.ObservedTransaction, *blocks.TransactionCursor, error) {
+	r.RLock()
+	txs, err := r.observedTxs.query(address, query)
+	r.RUnlock()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return blocks.ApplyQuery(address, txs, query)
+}
+
+// GetTokenTransfersPerAddress returns all observed ERC-20 token transfers per subscribed address.
+func (r *SubscriptionsRepository) GetTokenTransfersPerAddress(address string) ([]blocks.TokenTransfer, error) {
+	transfers, found := r.tokenTxStore.Get(address)
 	if !found {
-		return make([]blocks.Transaction, 0)
+		return make([]blocks.TokenTransfer, 0), nil
 	}
 
-	return txs
+	return transfers, nil
+}
+
+// Sizes returns the number of stored subscriptions, observed transactions and token transfers.
+func (r *SubscriptionsRepository) Sizes() (map[string]int, error) {
+	r.RLock()
+	subscriptions := len(r.subsStore)
+	observedTxs := r.observedTxs.len()
+	r.RUnlock()
+
+	return map[string]int{
+		"subscriptions":         subscriptions,
+		"observed_transactions": observedTxs,
+		"token_transfers":       r.tokenTxStore.Len(),
+	}, nil
 }
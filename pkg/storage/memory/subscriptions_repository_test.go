@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+const _testAddress = "0x00000000000000000000000000000000000000aa"
+
+func TestInsertObservedTransaction(t *testing.T) {
+	tx := testObservedTransaction(1, 0)
+
+	internal := tx
+	internal.Internal = true
+	internal.Trace = &blocks.InternalTransfer{TraceAddress: []int{0}}
+
+	outbound := tx
+	outbound.To = nil
+
+	tests := []struct {
+		name         string
+		tx           blocks.ObservedTransaction
+		wantInserted bool
+		wantSequence uint64
+	}{
+		{name: "same transaction", tx: tx, wantSequence: 1},
+		{name: "internal transfer of the transaction", tx: internal, wantInserted: true, wantSequence: 2},
+		{name: "transaction in the other direction", tx: outbound, wantInserted: true, wantSequence: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewSubscriptionsRepository()
+
+			if _, _, err := repo.InsertObservedTransaction(_testAddress, tx); err != nil {
+				t.Fatalf("InsertObservedTransaction() error = %v", err)
+			}
+
+			stored, inserted, err := repo.InsertObservedTransaction(_testAddress, tt.tx)
+			if err != nil {
+				t.Fatalf("InsertObservedTransaction() error = %v", err)
+			}
+
+			if inserted != tt.wantInserted || stored.Sequence != tt.wantSequence {
+				t.Errorf("InsertObservedTransaction() = sequence %d, inserted %v, want sequence %d, inserted %v",
+					stored.Sequence, inserted, tt.wantSequence, tt.wantInserted)
+			}
+
+			txs, err := repo.GetObservedTransactionsPerAddress(_testAddress)
+			if err != nil {
+				t.Fatalf("GetObservedTransactionsPerAddress() error = %v", err)
+			}
+
+			if want := int(tt.wantSequence); len(txs) != want {
+				t.Errorf("GetObservedTransactionsPerAddress() returned %d transactions, want %d", len(txs), want)
+			}
+		})
+	}
+}
+
+// testObservedTransaction returns a transaction sent to the test address, whose hash is derived from its position.
+func testObservedTransaction(blockNum, index int) blocks.ObservedTransaction {
+	var hash blocks.Hash
+
+	hash[0] = byte(blockNum)
+	hash[1] = byte(index)
+
+	to, _ := blocks.ParseAddress(_testAddress)
+
+	return blocks.ObservedTransaction{
+		Transaction: blocks.Transaction{
+			Hash:             hash,
+			To:               &to,
+			BlockNumber:      blocks.NewQuantity(blockNum),
+			TransactionIndex: blocks.NewQuantity(index),
+		},
+		Status: blocks.StatusPendingConfirmation,
+	}
+}
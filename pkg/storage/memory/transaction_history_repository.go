@@ -1,46 +1,98 @@
 package memory
 
 import (
+	"slices"
 	"sync"
 
 	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
 )
 
-// TransactionHistoryRepository holds the CRUD db operations for CasinoRoundBet.
+// TransactionHistoryRepository holds the CRUD db operations for the transaction history of addresses.
 type TransactionHistoryRepository struct {
-	inboundStore     *MultiMap[string, blocks.Transaction]
-	outboundStore    *MultiMap[string, blocks.Transaction]
-	latestBlockStore sync.Map
+	sync.RWMutex
+	inboundStore  *MultiMap[string, blocks.Transaction]
+	outboundStore *MultiMap[string, blocks.Transaction]
+	scannedStore  map[string][]blocks.Range
 }
 
 // NewTransactionsRepository is a constructor function for TransactionHistoryRepository.
 func NewTransactionsRepository() *TransactionHistoryRepository {
 	return &TransactionHistoryRepository{
-		inboundStore:     New[string, blocks.Transaction](),
-		outboundStore:    New[string, blocks.Transaction](),
-		latestBlockStore: sync.Map{},
+		inboundStore:  New[string, blocks.Transaction](),
+		outboundStore: New[string, blocks.Transaction](),
+		scannedStore:  make(map[string][]blocks.Range),
 	}
 }
 
-// Insert inserts a new blocks.Transaction entity.
-func (r *TransactionHistoryRepository) Insert(address string, blockNumber int, tx blocks.Transaction, isInbound bool) {
-	r.latestBlockStore.Store(address, blockNumber)
-
+// Insert inserts a new blocks.Transaction entity. Transactions are keyed like in the bolt store by address,
+// direction and hash, so inserting a transaction which is stored already is a no-op.
+func (r *TransactionHistoryRepository) Insert(
+	address string, _ int, tx blocks.Transaction, isInbound bool) error {
+	store, direction := r.outboundStore, blocks.DirectionOutbound
 	if isInbound {
-		r.inboundStore.Put(address, tx)
-	} else {
-		r.outboundStore.Put(address, tx)
+		store, direction = r.inboundStore, blocks.DirectionInbound
 	}
+
+	key := blocks.TransactionKey(tx.Hash.Hex(), direction)
+
+	// The values of the store are the transactions of the address in the given direction.
+	store.PutIfAbsent(address, tx, func(existing blocks.Transaction) bool {
+		return blocks.TransactionKey(existing.Hash.Hex(), direction) == key
+	})
+
+	return nil
 }
 
-// GetLatestBlockNumberPerAddress returns the latest block containing transactions to/from a given address.
-func (r *TransactionHistoryRepository) GetLatestBlockNumberPerAddress(address string) int {
-	blockNum, found := r.latestBlockStore.Load(address)
-	if !found {
-		return -1
+// GetLatestBlockNumberPerAddress returns the latest block scanned for transactions to/from a given address.
+func (r *TransactionHistoryRepository) GetLatestBlockNumberPerAddress(address string) (int, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	ranges := r.scannedStore[address]
+	if len(ranges) == 0 {
+		return -1, nil
+	}
+
+	return ranges[len(ranges)-1].To, nil
+}
+
+// GetScannedBlockRangesPerAddress returns the sorted block ranges already scanned for a given address.
+func (r *TransactionHistoryRepository) GetScannedBlockRangesPerAddress(address string) ([]blocks.Range, error) {
+	r.RLock()
+	ranges := make([]blocks.Range, len(r.scannedStore[address]))
+	copy(ranges, r.scannedStore[address])
+	r.RUnlock()
+
+	return ranges, nil
+}
+
+// InsertScannedBlockRange marks a block range as scanned for a given address.
+func (r *TransactionHistoryRepository) InsertScannedBlockRange(address string, blockRange blocks.Range) error {
+	r.Lock()
+	r.scannedStore[address] = blocks.MergeRanges(r.scannedStore[address], blockRange)
+	r.Unlock()
+
+	return nil
+}
+
+// RemoveTransactionsFromBlock removes the transactions of a given address contained in the blocks from a given one
+// on and marks these blocks as not scanned for the address.
+func (r *TransactionHistoryRepository) RemoveTransactionsFromBlock(address string, blockNum int) error {
+	for _, store := range []*MultiMap[string, blocks.Transaction]{r.inboundStore, r.outboundStore} {
+		store.RemoveKeyFunc(address, func(tx blocks.Transaction) bool {
+			txBlockNum, err := tx.BlockNumber.Int()
+
+			return err == nil && txBlockNum >= blockNum
+		})
+	}
+
+	r.Lock()
+	if ranges, found := r.scannedStore[address]; found {
+		r.scannedStore[address] = blocks.TruncateRanges(ranges, blockNum-1)
 	}
+	r.Unlock()
 
-	return blockNum.(int)
+	return nil
 }
 
 // GetInboundTransactionsPerAddress returns all inbound transactions per a given address.
@@ -63,12 +115,71 @@ func (r *TransactionHistoryRepository) GetOutboundTransactionsPerAddress(address
 	return txs
 }
 
-// GetAllTransactionsPerAddress returns all inbound transactions per a given address.
-func (r *TransactionHistoryRepository) GetAllTransactionsPerAddress(address string) []blocks.Transaction {
+// GetAllTransactionsPerAddress returns all inbound and outbound transactions per a given address.
+func (r *TransactionHistoryRepository) GetAllTransactionsPerAddress(address string) ([]blocks.Transaction, error) {
 	txs := make([]blocks.Transaction, 0)
 
 	txs = append(txs, r.GetInboundTransactionsPerAddress(address)...)
 	txs = append(txs, r.GetOutboundTransactionsPerAddress(address)...)
 
-	return txs
+	return txs, nil
+}
+
+// QueryTransactionsPerAddress returns a page of the inbound and outbound transactions per a given address
+// matching a query. If further transactions match, the cursor of the page is returned as well.
+//
+// Like in the bolt store, the transactions are read in listing order and reading stops once the page and the first
+// transaction of the next page have matched.
+func (r *TransactionHistoryRepository) QueryTransactionsPerAddress(
+	address string, query blocks.TransactionQuery) ([]blocks.Transaction, *blocks.TransactionCursor, error) {
+	allTxs, err := r.GetAllTransactionsPerAddress(address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cursors := make(map[blocks.Hash]blocks.TransactionCursor, len(allTxs))
+
+	for _, tx := range allTxs {
+		if cursors[tx.Hash], err = tx.Cursor(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	slices.SortFunc(allTxs, func(a, b blocks.Transaction) int {
+		switch {
+		case cursors[a.Hash].Before(cursors[b.Hash]):
+			return -1
+		case cursors[b.Hash].Before(cursors[a.Hash]):
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	txs := make([]blocks.Transaction, 0)
+
+	for _, tx := range allTxs {
+		if query.Full(len(txs)) {
+			break
+		}
+
+		matches, errMatch := query.Matches(address, tx)
+		if errMatch != nil {
+			return nil, nil, errMatch
+		}
+
+		if matches {
+			txs = append(txs, tx)
+		}
+	}
+
+	return blocks.ApplyQuery(address, txs, query)
+}
+
+// Sizes returns the number of stored inbound and outbound transactions.
+func (r *TransactionHistoryRepository) Sizes() (map[string]int, error) {
+	return map[string]int{
+		"inbound_transactions":  r.inboundStore.Len(),
+		"outbound_transactions": r.outboundStore.Len(),
+	}, nil
 }
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+func TestInsertTransactions(t *testing.T) {
+	tx := testObservedTransaction(1, 0).Transaction
+
+	tests := []struct {
+		name         string
+		inbound      []bool
+		wantInbound  int
+		wantOutbound int
+	}{
+		{name: "inserted once", inbound: []bool{true}, wantInbound: 1},
+		{name: "inserted twice", inbound: []bool{true, true}, wantInbound: 1},
+		{name: "inserted in both directions", inbound: []bool{true, false}, wantInbound: 1, wantOutbound: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewTransactionsRepository()
+
+			for _, isInbound := range tt.inbound {
+				if err := repo.Insert(_testAddress, 1, tx, isInbound); err != nil {
+					t.Fatalf("Insert() error = %v", err)
+				}
+			}
+
+			inbound := repo.GetInboundTransactionsPerAddress(_testAddress)
+			outbound := repo.GetOutboundTransactionsPerAddress(_testAddress)
+
+			if len(inbound) != tt.wantInbound || len(outbound) != tt.wantOutbound {
+				t.Errorf("stored %d inbound and %d outbound transactions, want %d and %d",
+					len(inbound), len(outbound), tt.wantInbound, tt.wantOutbound)
+			}
+		})
+	}
+}
+
+func TestQueryTransactionsPerAddress(t *testing.T) {
+	repo := NewTransactionsRepository()
+
+	// Insert the transactions out of listing order and in both directions.
+	for _, blockNum := range []int{3, 1, 2} {
+		for i := 0; i < 2; i++ {
+			tx := testObservedTransaction(blockNum, i).Transaction
+
+			if err := repo.Insert(_testAddress, blockNum, tx, i == 0); err != nil {
+				t.Fatalf("Insert() error = %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		limit     int
+		wantPages string
+	}{
+		{name: "all transactions", wantPages: "[[1-0 1-1 2-0 2-1 3-0 3-1]]"},
+		{name: "pages of four", limit: 4, wantPages: "[[1-0 1-1 2-0 2-1] [3-0 3-1]]"},
+		{name: "pages of three", limit: 3, wantPages: "[[1-0 1-1 2-0] [2-1 3-0 3-1]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := blocks.TransactionQuery{Limit: tt.limit}
+			pages := make([][]string, 0)
+
+			for {
+				page, next, err := repo.QueryTransactionsPerAddress(_testAddress, query)
+				if err != nil {
+					t.Fatalf("QueryTransactionsPerAddress() error = %v", err)
+				}
+
+				positions := make([]string, len(page))
+
+				for i, tx := range page {
+					positions[i] = fmt.Sprintf("%d-%d", tx.Hash[0], tx.Hash[1])
+				}
+
+				pages = append(pages, positions)
+
+				if next == nil {
+					break
+				}
+
+				query.After = next
+			}
+
+			if got := fmt.Sprint(pages); got != tt.wantPages {
+				t.Errorf("QueryTransactionsPerAddress() pages = %s, want %s", got, tt.wantPages)
+			}
+		})
+	}
+}
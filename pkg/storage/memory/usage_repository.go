@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+// UsageRepository holds the db operations for the compute units spent on RPC calls.
+type UsageRepository struct {
+	sync.RWMutex
+	budgetUsage *jsonrpc.BudgetUsage
+}
+
+// NewUsageRepository is a constructor function for UsageRepository.
+func NewUsageRepository() *UsageRepository {
+	return &UsageRepository{}
+}
+
+// GetBudgetUsage returns the stored usage of the RPC compute unit budget or nil if none has been stored yet.
+func (r *UsageRepository) GetBudgetUsage() (*jsonrpc.BudgetUsage, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.budgetUsage == nil {
+		return nil, nil
+	}
+
+	usage := *r.budgetUsage
+
+	return &usage, nil
+}
+
+// UpdateBudgetUsage stores the usage of the RPC compute unit budget.
+func (r *UsageRepository) UpdateBudgetUsage(usage jsonrpc.BudgetUsage) error {
+	r.Lock()
+	r.budgetUsage = &usage
+	r.Unlock()
+
+	return nil
+}
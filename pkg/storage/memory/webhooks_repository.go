@@ -0,0 +1,227 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/blocks"
+)
+
+// WebhooksRepository holds the CRUD db operations for webhooks and their deliveries.
+type WebhooksRepository struct {
+	sync.RWMutex
+	webhookStore      *MultiMap[string, blocks.Webhook]
+	secretStore       map[string]string
+	deliveryStore     map[string]blocks.WebhookDelivery
+	deliveryKeys      map[string]string
+	pendingDeliveries map[string]bool
+	lastDeliveryID    uint64
+}
+
+// NewWebhooksRepository is a constructor function for WebhooksRepository.
+func NewWebhooksRepository() *WebhooksRepository {
+	return &WebhooksRepository{
+		webhookStore:      New[string, blocks.Webhook](),
+		secretStore:       make(map[string]string),
+		deliveryStore:     make(map[string]blocks.WebhookDelivery),
+		deliveryKeys:      make(map[string]string),
+		pendingDeliveries: make(map[string]bool),
+	}
+}
+
+// InsertWebhook registers a new webhook for a subscribed address.
+func (r *WebhooksRepository) InsertWebhook(webhook blocks.Webhook) error {
+	r.webhookStore.Put(webhook.Address, webhook)
+
+	return nil
+}
+
+// GetWebhooksPerAddress returns all webhooks registered for a subscribed address.
+func (r *WebhooksRepository) GetWebhooksPerAddress(address string) ([]blocks.Webhook, error) {
+	webhooks, found := r.webhookStore.Get(address)
+	if !found {
+		return make([]blocks.Webhook, 0), nil
+	}
+
+	return webhooks, nil
+}
+
+// RemoveWebhook removes a webhook of a subscribed address.
+func (r *WebhooksRepository) RemoveWebhook(address, id string) (bool, error) {
+	matches := func(webhook blocks.Webhook) bool {
+		return webhook.Address == address && webhook.ID == id
+	}
+
+	webhooks, _ := r.webhookStore.Get(address)
+
+	for _, webhook := range webhooks {
+		if matches(webhook) {
+			r.webhookStore.RemoveFunc(matches)
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// RemoveWebhooksPerAddress removes all webhooks and the signing secret of a subscribed address.
+// If purge is set, the delivery log of the address is removed as well.
+func (r *WebhooksRepository) RemoveWebhooksPerAddress(address string, purge bool) error {
+	r.webhookStore.Remove(address)
+
+	r.Lock()
+	defer r.Unlock()
+
+	delete(r.secretStore, address)
+
+	if purge {
+		for id, delivery := range r.deliveryStore {
+			if delivery.Address == address {
+				delete(r.deliveryStore, id)
+				delete(r.deliveryKeys, deliveryKey(delivery))
+				delete(r.pendingDeliveries, id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetOrCreateSecret returns the webhook signing secret of a subscribed address.
+// If the address has no secret yet, the given one is stored and returned.
+func (r *WebhooksRepository) GetOrCreateSecret(address, secret string) (string, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if existing, found := r.secretStore[address]; found {
+		return existing, nil
+	}
+
+	r.secretStore[address] = secret
+
+	return secret, nil
+}
+
+// InsertDelivery stores a new delivery and returns it with its assigned ID. Deliveries are keyed by webhook
+// and transaction, so inserting a delivery which is stored already returns the stored one and false.
+func (r *WebhooksRepository) InsertDelivery(
+	delivery blocks.WebhookDelivery) (blocks.WebhookDelivery, bool, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	key := deliveryKey(delivery)
+
+	if id, found := r.deliveryKeys[key]; found {
+		return r.deliveryStore[id], false, nil
+	}
+
+	r.lastDeliveryID++
+	delivery.ID = deliveryID(r.lastDeliveryID)
+	r.deliveryStore[delivery.ID] = delivery
+	r.deliveryKeys[key] = delivery.ID
+
+	if delivery.Status == blocks.DeliveryPending {
+		r.pendingDeliveries[delivery.ID] = true
+	}
+
+	return delivery, true, nil
+}
+
+// UpdateDelivery replaces a stored delivery with the same ID.
+func (r *WebhooksRepository) UpdateDelivery(delivery blocks.WebhookDelivery) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, found := r.deliveryStore[delivery.ID]; !found {
+		return nil
+	}
+
+	r.deliveryStore[delivery.ID] = delivery
+
+	if delivery.Status == blocks.DeliveryPending {
+		r.pendingDeliveries[delivery.ID] = true
+	} else {
+		delete(r.pendingDeliveries, delivery.ID)
+	}
+
+	return nil
+}
+
+// GetDueDeliveries returns up to limit pending deliveries whose next attempt is due at a given time.
+func (r *WebhooksRepository) GetDueDeliveries(now time.Time, limit int) ([]blocks.WebhookDelivery, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	ids := make([]string, 0, len(r.pendingDeliveries))
+
+	for id := range r.pendingDeliveries {
+		if !r.deliveryStore[id].NextAttemptAt.After(now) {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	deliveries := make([]blocks.WebhookDelivery, 0, len(ids))
+
+	for _, id := range ids {
+		deliveries = append(deliveries, r.deliveryStore[id])
+	}
+
+	return deliveries, nil
+}
+
+// GetDeliveriesPerAddress returns a page of the delivery log of a subscribed address in delivery order
+// together with the total number of deliveries. An empty status matches deliveries of any status.
+func (r *WebhooksRepository) GetDeliveriesPerAddress(
+	address string, status blocks.DeliveryStatus, offset, limit int) ([]blocks.WebhookDelivery, int, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	ids := make([]string, 0)
+
+	for id, delivery := range r.deliveryStore {
+		if delivery.Address == address && (status == "" || delivery.Status == status) {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	deliveries := make([]blocks.WebhookDelivery, 0)
+
+	for i := offset; i < len(ids) && i < offset+limit; i++ {
+		deliveries = append(deliveries, r.deliveryStore[ids[i]])
+	}
+
+	return deliveries, len(ids), nil
+}
+
+// Sizes returns the number of stored webhooks, deliveries and pending deliveries.
+func (r *WebhooksRepository) Sizes() (map[string]int, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return map[string]int{
+		"webhooks":                   r.webhookStore.Len(),
+		"webhook_deliveries":         len(r.deliveryStore),
+		"pending_webhook_deliveries": len(r.pendingDeliveries),
+	}, nil
+}
+
+// deliveryKey identifies a delivery among the deliveries of all addresses.
+func deliveryKey(delivery blocks.WebhookDelivery) string {
+	return delivery.Address + "/" + delivery.Key()
+}
+
+// deliveryID formats a delivery sequence number, so that IDs sort in delivery order.
+func deliveryID(seq uint64) string {
+	return fmt.Sprintf("%016x", seq)
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/configs"
+	"github.com/powerslider/ethereum-block-scanner/pkg/sdk"
+	"github.com/powerslider/ethereum-block-scanner/pkg/storage/boltdb"
+	"github.com/powerslider/ethereum-block-scanner/pkg/storage/memory"
+)
+
+const (
+	// MemoryBackend keeps all data in memory, so it is lost on restart.
+	MemoryBackend = "memory"
+	// BoltBackend persists all data in an embedded bbolt database file.
+	BoltBackend = "bolt"
+)
+
+// Storage holds the stores of the storage backend selected in the config.
+type Storage struct {
+	SubsStore    sdk.SubscriptionsStore
+	TxStore      sdk.TransactionHistoryStore
+	WebhookStore sdk.WebhookStore
+	UsageStore   sdk.RPCUsageStore
+	sizes        func() (map[string]int, error)
+	close        func() error
+}
+
+// Close releases the underlying storage backend.
+func (s *Storage) Close() error {
+	if s.close == nil {
+		return nil
+	}
+
+	return s.close()
+}
+
+// Sizes returns the number of stored entries per kind, e.g. observed transactions or webhook deliveries.
+func (s *Storage) Sizes() (map[string]int, error) {
+	return s.sizes()
+}
+
+// InitializeStorage wires all dependencies for the storage module.
+func InitializeStorage(config *configs.Config) (*Storage, error) {
+	switch config.StorageBackend {
+	case MemoryBackend, "":
+		subsStore := memory.NewSubscriptionsRepository()
+		txStore := memory.NewTransactionsRepository()
+		webhookStore := memory.NewWebhooksRepository()
+
+		return &Storage{
+			SubsStore:    subsStore,
+			TxStore:      txStore,
+			WebhookStore: webhookStore,
+			UsageStore:   memory.NewUsageRepository(),
+			sizes: func() (map[string]int, error) {
+				return mergeSizes(subsStore.Sizes, txStore.Sizes, webhookStore.Sizes)
+			},
+		}, nil
+	case BoltBackend:
+		db, err := boltdb.Open(config.StoragePath)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Storage{
+			SubsStore:    boltdb.NewSubscriptionsRepository(db),
+			TxStore:      boltdb.NewTransactionsRepository(db),
+			WebhookStore: boltdb.NewWebhooksRepository(db),
+			UsageStore:   boltdb.NewUsageRepository(db),
+			sizes:        db.Sizes,
+			close:        db.Close,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", config.StorageBackend)
+	}
+}
+
+// mergeSizes merges the numbers of stored entries per kind reported by multiple stores.
+func mergeSizes(sizers ...func() (map[string]int, error)) (map[string]int, error) {
+	merged := make(map[string]int)
+
+	for _, sizer := range sizers {
+		sizes, err := sizer()
+		if err != nil {
+			return nil, err
+		}
+
+		for kind, n := range sizes {
+			merged[kind] += n
+		}
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,38 @@
+package supervisor
+
+import "time"
+
+const (
+	_defaultInitialBackoff = time.Second
+	_defaultMaxBackoff     = time.Minute
+)
+
+type supervisorConfig struct {
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+func newSupervisorDefaultConfig() *supervisorConfig {
+	return &supervisorConfig{
+		initialBackoff: _defaultInitialBackoff,
+		maxBackoff:     _defaultMaxBackoff,
+	}
+}
+
+func (o *supervisorConfig) applyOptions(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// Option specifies a Supervisor setting.
+type Option func(config *supervisorConfig)
+
+// WithRestartBackoff specifies the delay before the first restart of a failed worker, which is doubled
+// with every further failure up to a maximum delay.
+func WithRestartBackoff(initialBackoff, maxBackoff time.Duration) Option {
+	return func(o *supervisorConfig) {
+		o.initialBackoff = initialBackoff
+		o.maxBackoff = maxBackoff
+	}
+}
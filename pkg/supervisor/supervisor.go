@@ -0,0 +1,190 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrAlreadyStarted is returned when starting a supervisor which is running already.
+var ErrAlreadyStarted = errors.New("supervisor has already been started")
+
+// Worker is a background task which runs until its context is canceled. A worker which returns an error
+// or panics is restarted with backoff, a worker which returns nil is done and not restarted.
+type Worker func(ctx context.Context) error
+
+// Supervisor runs background workers, restarts them with exponential backoff when they fail
+// and stops all of them together.
+type Supervisor struct {
+	config  *supervisorConfig
+	mu      sync.Mutex
+	workers []namedWorker
+	ctx     context.Context
+	cancel  context.CancelFunc
+	errCh   chan error
+	wg      sync.WaitGroup
+}
+
+type namedWorker struct {
+	name string
+	run  Worker
+}
+
+// New is a constructor function for Supervisor.
+func New(opts ...Option) *Supervisor {
+	config := newSupervisorDefaultConfig()
+
+	config.applyOptions(opts...)
+
+	return &Supervisor{
+		config: config,
+	}
+}
+
+// Add registers a worker under a name used in its error reports. Workers added after the supervisor
+// has been started are started right away.
+func (s *Supervisor) Add(name string, run Worker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := namedWorker{name: name, run: run}
+	s.workers = append(s.workers, w)
+
+	if s.cancel != nil {
+		s.wg.Add(1)
+
+		go s.supervise(s.ctx, w, s.errCh)
+	}
+}
+
+// Start starts all workers with a context derived from the given one. Failures of the workers are reported
+// on errCh, a send is abandoned when the supervisor is stopped.
+func (s *Supervisor) Start(ctx context.Context, errCh chan error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return ErrAlreadyStarted
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.errCh = errCh
+
+	for _, w := range s.workers {
+		s.wg.Add(1)
+
+		go s.supervise(s.ctx, w, errCh)
+	}
+
+	return nil
+}
+
+// Stop cancels the context of all workers and waits until they have returned. If the given context
+// is done first, Stop returns its error and leaves the remaining workers to finish in the background.
+func (s *Supervisor) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("workers did not stop in time: %w", ctx.Err())
+	}
+}
+
+// supervise runs a worker until its context is canceled or it returns without an error.
+func (s *Supervisor) supervise(ctx context.Context, w namedWorker, errCh chan error) {
+	defer s.wg.Done()
+
+	delay := s.config.initialBackoff
+
+	for {
+		start := time.Now()
+
+		err := runWorker(ctx, w.run)
+		if ctx.Err() != nil || err == nil {
+			return
+		}
+
+		// A worker which has been running for a while failed for a new reason, so it is restarted promptly.
+		if time.Since(start) > s.config.maxBackoff {
+			delay = s.config.initialBackoff
+		}
+
+		select {
+		case errCh <- &WorkerError{Worker: w.name, RestartIn: delay, Err: err}:
+		case <-ctx.Done():
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > s.config.maxBackoff {
+			delay = s.config.maxBackoff
+		}
+	}
+}
+
+// runWorker runs a worker once and turns a panic into an error.
+func runWorker(ctx context.Context, run Worker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return run(ctx)
+}
+
+// WorkerError is reported when a worker failed and is about to be restarted. When logged, it is tagged
+// with the name of the worker and the delay of the restart, while the cause keeps its own log attributes.
+type WorkerError struct {
+	// Worker is the name of the failed worker.
+	Worker string
+	// RestartIn is the delay before the worker is restarted.
+	RestartIn time.Duration
+	// Err is the error returned by the worker.
+	Err error
+}
+
+// Error function is provided to be used as error object.
+func (e *WorkerError) Error() string {
+	return fmt.Sprintf("worker %s failed, restarting in %s: %v", e.Worker, e.RestartIn, e.Err)
+}
+
+// Unwrap returns the error returned by the worker.
+func (e *WorkerError) Unwrap() error {
+	return e.Err
+}
+
+// LogValue implements slog.LogValuer.
+func (e *WorkerError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("worker", e.Worker),
+		slog.Duration("restart_in", e.RestartIn),
+		slog.Any("cause", e.Err),
+	)
+}
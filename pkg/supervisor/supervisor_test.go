@@ -0,0 +1,187 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSupervisorRestartsFailedWorkers(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name        string
+		failures    int
+		fail        func() error
+		wantRuns    int
+		wantReports int
+	}{
+		{
+			name:     "worker returning nil is not restarted",
+			failures: 0,
+			wantRuns: 1,
+		},
+		{
+			name:        "failed worker is restarted",
+			failures:    3,
+			fail:        func() error { return errFailed },
+			wantRuns:    4,
+			wantReports: 3,
+		},
+		{
+			name:        "panicking worker is restarted",
+			failures:    2,
+			fail:        func() error { panic("boom") },
+			wantRuns:    3,
+			wantReports: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu   sync.Mutex
+				runs int
+			)
+
+			done := make(chan struct{})
+			errCh := make(chan error, tt.wantReports)
+			s := New(WithRestartBackoff(time.Millisecond, 4*time.Millisecond))
+
+			s.Add("test", func(ctx context.Context) error {
+				mu.Lock()
+				runs++
+				run := runs
+				mu.Unlock()
+
+				if run <= tt.failures {
+					return tt.fail()
+				}
+
+				close(done)
+
+				return nil
+			})
+
+			if err := s.Start(context.Background(), errCh); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("worker did not succeed in time")
+			}
+
+			if err := s.Stop(context.Background()); err != nil {
+				t.Fatalf("Stop() error = %v", err)
+			}
+
+			if runs != tt.wantRuns {
+				t.Errorf("runs = %d, want %d", runs, tt.wantRuns)
+			}
+
+			if len(errCh) != tt.wantReports {
+				t.Fatalf("reported errors = %d, want %d", len(errCh), tt.wantReports)
+			}
+
+			for i := 0; i < tt.wantReports; i++ {
+				var workerErr *WorkerError
+				if err := <-errCh; !errors.As(err, &workerErr) || workerErr.Worker != "test" {
+					t.Errorf("reported error = %v, want a WorkerError of worker test", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSupervisorBackoff(t *testing.T) {
+	const (
+		initialBackoff = 20 * time.Millisecond
+		maxBackoff     = 50 * time.Millisecond
+	)
+
+	errCh := make(chan error, 4)
+	s := New(WithRestartBackoff(initialBackoff, maxBackoff))
+
+	s.Add("failing", func(ctx context.Context) error {
+		return errors.New("failed")
+	})
+
+	if err := s.Start(context.Background(), errCh); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	wantDelays := []time.Duration{initialBackoff, 2 * initialBackoff, maxBackoff, maxBackoff}
+
+	for i, want := range wantDelays {
+		var workerErr *WorkerError
+
+		select {
+		case err := <-errCh:
+			if !errors.As(err, &workerErr) {
+				t.Fatalf("reported error = %v, want a WorkerError", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("restart %d was not reported in time", i)
+		}
+
+		if workerErr.RestartIn != want {
+			t.Errorf("restart %d delay = %s, want %s", i, workerErr.RestartIn, want)
+		}
+	}
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+}
+
+func TestSupervisorStop(t *testing.T) {
+	tests := []struct {
+		name    string
+		linger  time.Duration
+		timeout time.Duration
+		wantErr bool
+	}{
+		{
+			name:    "workers stop in time",
+			timeout: time.Second,
+		},
+		{
+			name:    "workers do not stop in time",
+			linger:  time.Second,
+			timeout: 10 * time.Millisecond,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			s := New()
+
+			s.Add("lingering", func(ctx context.Context) error {
+				close(started)
+				<-ctx.Done()
+				time.Sleep(tt.linger)
+
+				return nil
+			})
+
+			if err := s.Start(context.Background(), make(chan error)); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			<-started
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			if err := s.Stop(ctx); (err != nil) != tt.wantErr {
+				t.Errorf("Stop() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package tracing
+
+const (
+	_defaultServiceName = "ethereum-block-scanner"
+	_defaultFilePath    = "traces.json"
+	_defaultSampleRatio = 1.0
+)
+
+type providerConfig struct {
+	serviceName  string
+	filePath     string
+	otlpEndpoint string
+	sampleRatio  float64
+}
+
+func newProviderDefaultConfig() *providerConfig {
+	return &providerConfig{
+		serviceName: _defaultServiceName,
+		filePath:    _defaultFilePath,
+		sampleRatio: _defaultSampleRatio,
+	}
+}
+
+func (o *providerConfig) applyOptions(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// Option specifies a Provider setting.
+type Option func(config *providerConfig)
+
+// WithServiceName specifies the service name the spans are reported under.
+func WithServiceName(serviceName string) Option {
+	return func(o *providerConfig) {
+		o.serviceName = serviceName
+	}
+}
+
+// WithFilePath specifies the file the spans are appended to by the file exporter.
+func WithFilePath(path string) Option {
+	return func(o *providerConfig) {
+		o.filePath = path
+	}
+}
+
+// WithOTLPEndpoint specifies the URL of the collector the spans are sent to by the OTLP exporter,
+// e.g. "http://localhost:4318". If it is empty, the standard OTEL_EXPORTER_OTLP_* env vars apply.
+func WithOTLPEndpoint(endpoint string) Option {
+	return func(o *providerConfig) {
+		o.otlpEndpoint = endpoint
+	}
+}
+
+// WithSampleRatio specifies the ratio of traces started by the scanner which are sampled. Traces started
+// by a caller, e.g. a request carrying a sampled trace context, follow the decision of the caller.
+func WithSampleRatio(ratio float64) Option {
+	return func(o *providerConfig) {
+		o.sampleRatio = ratio
+	}
+}
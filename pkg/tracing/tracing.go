@@ -0,0 +1,151 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+const (
+	// ExporterNone disables exporting spans. The trace context of incoming requests is still passed on
+	// to the Ethereum node.
+	ExporterNone = "none"
+	// ExporterStdout writes spans to the standard output as JSON, one per line.
+	ExporterStdout = "stdout"
+	// ExporterFile appends spans to a file as JSON, one per line.
+	ExporterFile = "file"
+	// ExporterOTLP sends spans to an OpenTelemetry collector over OTLP/HTTP.
+	ExporterOTLP = "otlp"
+)
+
+// Provider exports the spans recorded by the scanner.
+type Provider struct {
+	tracerProvider *sdktrace.TracerProvider
+	closer         io.Closer
+}
+
+// NewProvider creates the exporter of a given kind and installs a tracer provider using it as the global one
+// together with the W3C trace context propagator.
+//
+// The instrumented packages get their tracers from the global provider, so spans are only recorded
+// once the provider is installed, and not at all with ExporterNone.
+func NewProvider(ctx context.Context, exporter string, opts ...Option) (*Provider, error) {
+	config := newProviderDefaultConfig()
+
+	config.applyOptions(opts...)
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("tracing error", "error", err)
+	}))
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	p := &Provider{}
+
+	var (
+		spanExporter sdktrace.SpanExporter
+		err          error
+	)
+
+	switch exporter {
+	case ExporterNone, "":
+		return p, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		spanExporter, err = p.newFileExporter(config.filePath)
+	case ExporterOTLP:
+		spanExporter, err = newOTLPExporter(ctx, config.otlpEndpoint)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	p.tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.sampleRatio))),
+	)
+
+	otel.SetTracerProvider(p.tracerProvider)
+
+	return p, nil
+}
+
+// Shutdown exports the remaining spans and stops the exporter.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.tracerProvider == nil {
+		return nil
+	}
+
+	err := p.tracerProvider.Shutdown(ctx)
+
+	if p.closer != nil {
+		err = errors.Join(err, p.closer.Close())
+	}
+
+	return err
+}
+
+// newFileExporter opens a file for appending and creates an exporter writing to it. The file is closed
+// on shutdown.
+func (p *Provider) newFileExporter(path string) (sdktrace.SpanExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open trace file: %w", err)
+	}
+
+	p.closer = f
+
+	return stdouttrace.New(stdouttrace.WithWriter(f))
+}
+
+// newOTLPExporter creates an OTLP/HTTP exporter for a collector URL. Plain http URLs are sent without TLS.
+func newOTLPExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	var opts []otlptracehttp.Option
+
+	if endpoint != "" {
+		endpointURL, err := url.Parse(endpoint)
+		if err != nil || endpointURL.Host == "" {
+			return nil, fmt.Errorf("invalid OTLP endpoint %q, expected a URL like http://localhost:4318", endpoint)
+		}
+
+		opts = append(opts, otlptracehttp.WithEndpoint(endpointURL.Host))
+
+		if endpointURL.Scheme == "http" {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+
+		if endpointURL.Path != "" && endpointURL.Path != "/" {
+			opts = append(opts, otlptracehttp.WithURLPath(endpointURL.Path))
+		}
+	}
+
+	return otlptracehttp.New(ctx, opts...)
+}
@@ -22,6 +22,7 @@ func (e *Error) Error() string {
 // Client represents an HTTP client wrapper.
 type Client struct {
 	clientInstance *http.Client
+	retryPolicy    *RetryPolicy
 }
 
 // NewClient is a constructor function for Client.
@@ -45,12 +46,17 @@ func NewClient(options ...ClientOption) *Client {
 
 	return &Client{
 		clientInstance: client,
+		retryPolicy:    config.retryPolicy,
 	}
 }
 
-// Do executes a passed HTTP request.
+// Do executes a passed HTTP request. If a retry policy is configured, failed requests are retried.
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
-	return c.clientInstance.Do(req)
+	if c.retryPolicy == nil || c.retryPolicy.MaxAttempts < 2 {
+		return c.clientInstance.Do(req)
+	}
+
+	return c.doWithRetries(req)
 }
 
 // Get executes an HTTP GET request.
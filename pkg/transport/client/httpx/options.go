@@ -16,6 +16,7 @@ type clientConfig struct {
 	tlsHandshakeTimeout int
 	dialerTimeout       int
 	redirectPolicy      RedirectPolicy
+	retryPolicy         *RetryPolicy
 }
 
 // RedirectPolicy specifies the policy for handling redirects.
@@ -69,9 +70,17 @@ func WithCustomRedirectPolicy(policy RedirectPolicy) ClientOption {
 	}
 }
 
+// WithRetryPolicy specifies a RetryPolicy for failed requests. Requests are not retried by default.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(o *clientConfig) {
+		o.retryPolicy = &policy
+	}
+}
+
 type requestConfig struct {
 	queryParams map[string]string
 	formParams  map[string]string
+	headers     map[string]string
 	body        io.Reader
 	basicAuth   *basicAuth
 }
@@ -88,6 +97,7 @@ func newHTTPRequestDefaultConfig() *requestConfig {
 	return &requestConfig{
 		basicAuth:   &basicAuth{},
 		queryParams: make(map[string]string),
+		headers:     make(map[string]string),
 		body:        nil,
 	}
 }
@@ -120,6 +130,13 @@ func WithFormParam(key, val string) RequestOption {
 	}
 }
 
+// WithHeader sets a request header.
+func WithHeader(key, val string) RequestOption {
+	return func(o *requestConfig) {
+		o.headers[key] = val
+	}
+}
+
 // WithBasicAuth adds a basic authentication header.
 func WithBasicAuth(username, password string) RequestOption {
 	return func(o *requestConfig) {
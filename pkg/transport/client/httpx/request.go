@@ -75,8 +75,12 @@ func NewRequest(ctx context.Context, method Method, url string, options ...Reque
 		config.body = strings.NewReader(form.Encode())
 	}
 
+	for k, v := range config.headers {
+		req.Header.Set(k, v)
+	}
+
 	auth := config.basicAuth
-	if auth != nil {
+	if auth != nil && (auth.username != "" || auth.password != "") {
 		req.SetBasicAuth(auth.username, auth.password)
 	}
 
@@ -0,0 +1,217 @@
+package httpx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	_defaultMaxAttempts    = 3
+	_defaultInitialBackoff = 250 * time.Millisecond
+	_defaultMaxBackoff     = 5 * time.Second
+	_defaultMaxRetryAfter  = 30 * time.Second
+
+	// _maxDrainedBodySize limits how much of a discarded response body is read to reuse the connection.
+	_maxDrainedBodySize = 4096
+)
+
+// RetryPolicy specifies how failed requests are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one. A value below 2 disables retries.
+	MaxAttempts int
+	// InitialBackoff is the upper bound of the delay before the first retry. It doubles with every retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the upper bound of the delay between two attempts.
+	MaxBackoff time.Duration
+	// MaxRetryAfter is the longest Retry-After delay requested by the server that is waited for.
+	// A response asking for a longer delay is returned as it is.
+	MaxRetryAfter time.Duration
+	// RetryableStatusCodes are the HTTP status codes of responses which are retried.
+	RetryableStatusCodes []int
+	// RetryableError reports if a request failing with a given error is retried.
+	RetryableError func(err error) bool
+}
+
+// DefaultRetryPolicy returns a RetryPolicy retrying rate limited requests, temporary server errors
+// and network errors up to 3 times in total.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    _defaultMaxAttempts,
+		InitialBackoff: _defaultInitialBackoff,
+		MaxBackoff:     _defaultMaxBackoff,
+		MaxRetryAfter:  _defaultMaxRetryAfter,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableError: IsNetworkError,
+	}
+}
+
+// IsNetworkError reports if an error is a timeout, a refused or reset connection or a connection closed
+// before the response was complete. The request may or may not have reached the server.
+func IsNetworkError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// doWithRetries executes a request and retries it according to the retry policy of the client.
+func (c *Client) doWithRetries(req *http.Request) (*http.Response, error) {
+	policy := c.retryPolicy
+
+	if err := makeReplayable(req); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := c.clientInstance.Do(attemptReq)
+		if attempt >= policy.MaxAttempts || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		delay, retry := policy.retryDelay(attempt, resp, err)
+		if !retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			//nolint:errcheck
+			io.CopyN(io.Discard, resp.Body, _maxDrainedBodySize)
+			//nolint:errcheck
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay reports if a failed attempt should be retried and how long to wait before the retry.
+// A delay requested by the server with a Retry-After header takes precedence over the backoff.
+func (p RetryPolicy) retryDelay(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if err != nil {
+		return p.backoff(attempt), p.RetryableError != nil && p.RetryableError(err)
+	}
+
+	retryableStatus := false
+
+	for _, code := range p.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			retryableStatus = true
+
+			break
+		}
+	}
+
+	if !retryableStatus {
+		return 0, false
+	}
+
+	if retryAfter, found := parseRetryAfter(resp.Header.Get("Retry-After")); found {
+		return retryAfter, retryAfter <= p.MaxRetryAfter
+	}
+
+	return p.backoff(attempt), true
+}
+
+// backoff returns a random delay between zero and the exponentially growing upper bound for a given attempt,
+// so that clients failing at the same time do not retry at the same time.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	upperBound := p.InitialBackoff
+
+	for i := 1; i < attempt && upperBound < p.MaxBackoff; i++ {
+		upperBound *= 2
+	}
+
+	if upperBound > p.MaxBackoff {
+		upperBound = p.MaxBackoff
+	}
+
+	if upperBound <= 0 {
+		return 0
+	}
+
+	//nolint:gosec
+	return time.Duration(rand.Int63n(int64(upperBound)))
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// makeReplayable buffers the body of a request which cannot be read again, so that it can be sent
+// on every attempt. Bodies created from bytes or strings already are replayable.
+func makeReplayable(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+
+	if err = req.Body.Close(); err != nil {
+		return err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return nil
+}
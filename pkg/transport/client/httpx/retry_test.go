@@ -0,0 +1,276 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testResponse is a response returned by a test server for one attempt. A zero status closes the connection
+// without a response.
+type testResponse struct {
+	status     int
+	retryAfter string
+}
+
+func TestDoWithRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		responses    []testResponse
+		wantStatus   int
+		wantErr      bool
+		wantAttempts int
+		wantMin      time.Duration
+		wantMax      time.Duration
+	}{
+		{
+			name:         "first attempt succeeds",
+			responses:    []testResponse{{status: http.StatusOK}},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 1,
+			wantMax:      time.Second,
+		},
+		{
+			name:         "server error is retried",
+			responses:    []testResponse{{status: http.StatusServiceUnavailable}, {status: http.StatusOK}},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 2,
+			wantMax:      time.Second,
+		},
+		{
+			name:         "closed connection is retried",
+			responses:    []testResponse{{}, {status: http.StatusOK}},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 2,
+			wantMax:      time.Second,
+		},
+		{
+			name: "last attempt is returned",
+			responses: []testResponse{
+				{status: http.StatusBadGateway},
+				{status: http.StatusBadGateway},
+				{status: http.StatusBadGateway},
+				{status: http.StatusOK},
+			},
+			wantStatus:   http.StatusBadGateway,
+			wantAttempts: 3,
+			wantMax:      time.Second,
+		},
+		{
+			name:         "client error is not retried",
+			responses:    []testResponse{{status: http.StatusBadRequest}, {status: http.StatusOK}},
+			wantStatus:   http.StatusBadRequest,
+			wantAttempts: 1,
+			wantMax:      time.Second,
+		},
+		{
+			name: "retry after is waited for",
+			responses: []testResponse{
+				{status: http.StatusTooManyRequests, retryAfter: "1"},
+				{status: http.StatusOK},
+			},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 2,
+			wantMin:      time.Second,
+			wantMax:      2 * time.Second,
+		},
+		{
+			name: "retry after longer than the maximum is returned",
+			responses: []testResponse{
+				{status: http.StatusTooManyRequests, retryAfter: "60"},
+				{status: http.StatusOK},
+			},
+			wantStatus:   http.StatusTooManyRequests,
+			wantAttempts: 1,
+			wantMax:      time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, bodies := newTestServer(t, tt.responses)
+
+			policy := DefaultRetryPolicy()
+			policy.InitialBackoff = 10 * time.Millisecond
+			policy.MaxBackoff = 20 * time.Millisecond
+			policy.MaxRetryAfter = 5 * time.Second
+
+			client := NewClient(WithRetryPolicy(policy))
+			start := time.Now()
+
+			// The body is read from a reader which cannot be replayed by itself.
+			resp, err := client.Post(context.Background(), server.URL, io.MultiReader(strings.NewReader("payload")))
+			elapsed := time.Since(start)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Post() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if resp != nil {
+				resp.Body.Close()
+
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("Post() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+			}
+
+			if got := bodies(); len(got) != tt.wantAttempts {
+				t.Errorf("Post() made %d attempts, want %d", len(got), tt.wantAttempts)
+			} else {
+				for i, body := range got {
+					if body != "payload" {
+						t.Errorf("attempt #%d sent body %q, want %q", i+1, body, "payload")
+					}
+				}
+			}
+
+			if elapsed < tt.wantMin || elapsed > tt.wantMax {
+				t.Errorf("Post() took %s, want between %s and %s", elapsed, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestDoWithRetriesCanceled(t *testing.T) {
+	server, bodies := newTestServer(t, []testResponse{
+		{status: http.StatusServiceUnavailable, retryAfter: "10"},
+		{status: http.StatusOK},
+	})
+
+	policy := DefaultRetryPolicy()
+	policy.MaxRetryAfter = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	_, err := NewClient(WithRetryPolicy(policy)).Get(ctx, server.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Get() took %s, want it to return once the context is done", elapsed)
+	}
+
+	if got := len(bodies()); got != 1 {
+		t.Errorf("Get() made %d attempts, want 1", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		attempt        int
+		wantUpperBound time.Duration
+	}{
+		{attempt: 1, wantUpperBound: 100 * time.Millisecond},
+		{attempt: 2, wantUpperBound: 200 * time.Millisecond},
+		{attempt: 4, wantUpperBound: 800 * time.Millisecond},
+		{attempt: 5, wantUpperBound: time.Second},
+		{attempt: 20, wantUpperBound: time.Second},
+	}
+
+	for _, tt := range tests {
+		var longest time.Duration
+
+		for i := 0; i < 1000; i++ {
+			delay := policy.backoff(tt.attempt)
+			if delay < 0 || delay >= tt.wantUpperBound {
+				t.Fatalf("backoff(%d) = %s, want a delay in [0, %s)", tt.attempt, delay, tt.wantUpperBound)
+			}
+
+			longest = max(longest, delay)
+		}
+
+		// The delays are spread over the whole range, so the longest of many is close to the upper bound.
+		if longest < tt.wantUpperBound/2 {
+			t.Errorf("backoff(%d) never exceeded %s, want delays up to %s", tt.attempt, longest, tt.wantUpperBound)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		wantMin   time.Duration
+		wantMax   time.Duration
+		wantFound bool
+	}{
+		{name: "missing", value: ""},
+		{name: "seconds", value: "3", wantMin: 3 * time.Second, wantMax: 3 * time.Second, wantFound: true},
+		{name: "negative seconds", value: "-1"},
+		{
+			name:      "date",
+			value:     time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat),
+			wantMin:   8 * time.Second,
+			wantMax:   10 * time.Second,
+			wantFound: true,
+		},
+		{name: "past date", value: "Mon, 02 Jan 2006 15:04:05 GMT", wantFound: true},
+		{name: "invalid", value: "soon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := parseRetryAfter(tt.value)
+			if found != tt.wantFound || got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("parseRetryAfter(%q) = %s, %v, want between %s and %s, %v",
+					tt.value, got, found, tt.wantMin, tt.wantMax, tt.wantFound)
+			}
+		})
+	}
+}
+
+// newTestServer starts a server returning a response per attempt and returns it together with a function
+// listing the bodies received so far.
+func newTestServer(t *testing.T, responses []testResponse) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		response := responses[len(bodies)-1]
+		mu.Unlock()
+
+		if response.status == 0 {
+			conn, _, err := rw.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+
+			return
+		}
+
+		if response.retryAfter != "" {
+			rw.Header().Set("Retry-After", response.retryAfter)
+		}
+
+		rw.WriteHeader(response.status)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), bodies...)
+	}
+}
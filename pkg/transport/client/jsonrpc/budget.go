@@ -0,0 +1,274 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// _defaultComputeUnits is the cost of methods missing from the cost table of a Budget.
+const _defaultComputeUnits = 20
+
+// ErrBudgetExhausted is returned by low priority calls made after the compute unit budget was used up.
+var ErrBudgetExhausted = errors.New("rpc compute unit budget exhausted")
+
+// DefaultComputeUnits returns the compute unit costs of common methods, loosely following
+// the pricing of hosted node providers. Expensive methods like tracing cost an order of magnitude more.
+func DefaultComputeUnits() map[string]int {
+	return map[string]int{
+		"eth_chainId":               0,
+		"eth_syncing":               0,
+		"eth_blockNumber":           10,
+		"eth_getBlockByNumber":      16,
+		"eth_getTransactionReceipt": 15,
+		"eth_getBlockReceipts":      500,
+		"eth_getLogs":               75,
+		"debug_traceBlockByNumber":  500,
+		"trace_block":               500,
+	}
+}
+
+type priorityKey struct{}
+
+// WithLowPriority marks the calls made with the returned context as low priority, e.g. history backfills,
+// which are rejected once the compute unit budget is exhausted.
+func WithLowPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, priorityKey{}, true)
+}
+
+// IsLowPriority reports if the calls made with a context are low priority.
+func IsLowPriority(ctx context.Context) bool {
+	lowPriority, _ := ctx.Value(priorityKey{}).(bool)
+
+	return lowPriority
+}
+
+// MethodUsage represents the calls and compute units spent on a JSON-RPC method.
+type MethodUsage struct {
+	Calls        int64 `json:"calls"`
+	ComputeUnits int64 `json:"computeUnits"`
+}
+
+// BudgetUsage represents the compute units spent in the current day and month against their limits.
+type BudgetUsage struct {
+	Day          string                 `json:"day"`
+	DailyUsed    int64                  `json:"dailyUsed"`
+	DailyLimit   int64                  `json:"dailyLimit"`
+	Month        string                 `json:"month"`
+	MonthlyUsed  int64                  `json:"monthlyUsed"`
+	MonthlyLimit int64                  `json:"monthlyLimit"`
+	Exhausted    bool                   `json:"exhausted"`
+	Methods      map[string]MethodUsage `json:"methods"`
+}
+
+// BudgetStore persists the usage of a Budget, so that the compute units spent survive a restart.
+type BudgetStore interface {
+	// GetBudgetUsage returns the stored usage or nil if no usage has been stored yet.
+	GetBudgetUsage() (*BudgetUsage, error)
+
+	// UpdateBudgetUsage stores the usage.
+	UpdateBudgetUsage(usage BudgetUsage) error
+}
+
+// Budget tracks the compute units spent per JSON-RPC method against a daily and a monthly limit.
+// Days and months are counted in UTC. Once a limit is reached, low priority calls are rejected
+// until the next period starts, while all other calls still go through.
+//
+// If a BudgetStore is configured, the usage is stored periodically while the Budget is run, so that the limits
+// still hold after a restart.
+type Budget struct {
+	sync.Mutex
+	dailyLimit   int64
+	monthlyLimit int64
+	costs        map[string]int
+	config       *budgetConfig
+	restored     bool
+
+	day         string
+	dailyUsed   int64
+	month       string
+	monthlyUsed int64
+	methods     map[string]MethodUsage
+}
+
+// NewBudget returns a new Budget with given daily and monthly limits in compute units and compute unit costs
+// per method. A non-positive limit disables it.
+func NewBudget(dailyLimit, monthlyLimit int64, costs map[string]int, opts ...BudgetOption) *Budget {
+	config := newBudgetDefaultConfig()
+
+	config.applyOptions(opts...)
+
+	return &Budget{
+		dailyLimit:   dailyLimit,
+		monthlyLimit: monthlyLimit,
+		costs:        costs,
+		config:       config,
+		methods:      make(map[string]MethodUsage),
+	}
+}
+
+// Run restores the usage stored by a previous run and then stores the usage periodically until the context
+// is canceled, when it is stored once more. Without a BudgetStore it just waits for the context to be canceled.
+// The usage is restored once, so that restarting Run after a failed store does not count it twice.
+func (b *Budget) Run(ctx context.Context) error {
+	store := b.config.store
+	if store == nil {
+		<-ctx.Done()
+
+		return nil
+	}
+
+	if err := b.restore(store); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(b.config.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := store.UpdateBudgetUsage(b.Usage()); err != nil {
+				return fmt.Errorf("could not store rpc budget usage: %w", err)
+			}
+
+			return nil
+		case <-ticker.C:
+			if err := store.UpdateBudgetUsage(b.Usage()); err != nil {
+				return fmt.Errorf("could not store rpc budget usage: %w", err)
+			}
+		}
+	}
+}
+
+// restore adds the usage stored by a previous run to the current usage, unless it has been restored already.
+// Only the usage of the current day and month is taken into account.
+func (b *Budget) restore(store BudgetStore) error {
+	if b.restored {
+		return nil
+	}
+
+	usage, err := store.GetBudgetUsage()
+	if err != nil {
+		return fmt.Errorf("could not load rpc budget usage: %w", err)
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.restored = true
+
+	if usage == nil {
+		return nil
+	}
+
+	b.rollOver(time.Now())
+
+	if usage.Day == b.day {
+		b.dailyUsed += usage.DailyUsed
+	}
+
+	if usage.Month != b.month {
+		return nil
+	}
+
+	b.monthlyUsed += usage.MonthlyUsed
+
+	for method, stored := range usage.Methods {
+		current := b.methods[method]
+		current.Calls += stored.Calls
+		current.ComputeUnits += stored.ComputeUnits
+		b.methods[method] = current
+	}
+
+	return nil
+}
+
+// Allow reports if a call may be made with a given context. Only low priority calls are ever rejected.
+func (b *Budget) Allow(ctx context.Context) error {
+	if !IsLowPriority(ctx) {
+		return nil
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.rollOver(time.Now())
+
+	if b.exhausted() {
+		return ErrBudgetExhausted
+	}
+
+	return nil
+}
+
+// Spend records n calls of a method.
+func (b *Budget) Spend(method string, n int) {
+	cost, found := b.costs[method]
+	if !found {
+		cost = _defaultComputeUnits
+	}
+
+	computeUnits := int64(cost * n)
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.rollOver(time.Now())
+
+	b.dailyUsed += computeUnits
+	b.monthlyUsed += computeUnits
+
+	usage := b.methods[method]
+	usage.Calls += int64(n)
+	usage.ComputeUnits += computeUnits
+	b.methods[method] = usage
+}
+
+// Usage returns the compute units spent in the current day and month. Usage per method covers the current month.
+func (b *Budget) Usage() BudgetUsage {
+	b.Lock()
+	defer b.Unlock()
+
+	b.rollOver(time.Now())
+
+	methods := make(map[string]MethodUsage, len(b.methods))
+
+	for method, usage := range b.methods {
+		methods[method] = usage
+	}
+
+	return BudgetUsage{
+		Day:          b.day,
+		DailyUsed:    b.dailyUsed,
+		DailyLimit:   b.dailyLimit,
+		Month:        b.month,
+		MonthlyUsed:  b.monthlyUsed,
+		MonthlyLimit: b.monthlyLimit,
+		Exhausted:    b.exhausted(),
+		Methods:      methods,
+	}
+}
+
+// rollOver resets the usage counters when a new day or month starts.
+func (b *Budget) rollOver(now time.Time) {
+	now = now.UTC()
+
+	if day := now.Format(time.DateOnly); day != b.day {
+		b.day = day
+		b.dailyUsed = 0
+	}
+
+	if month := now.Format("2006-01"); month != b.month {
+		b.month = month
+		b.monthlyUsed = 0
+		b.methods = make(map[string]MethodUsage)
+	}
+}
+
+func (b *Budget) exhausted() bool {
+	return (b.dailyLimit > 0 && b.dailyUsed >= b.dailyLimit) ||
+		(b.monthlyLimit > 0 && b.monthlyUsed >= b.monthlyLimit)
+}
@@ -0,0 +1,176 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeBudgetStore struct {
+	usage   *BudgetUsage
+	updates int
+}
+
+func (s *fakeBudgetStore) GetBudgetUsage() (*BudgetUsage, error) {
+	return s.usage, nil
+}
+
+func (s *fakeBudgetStore) UpdateBudgetUsage(usage BudgetUsage) error {
+	s.usage = &usage
+	s.updates++
+
+	return nil
+}
+
+func TestBudgetAllow(t *testing.T) {
+	costs := map[string]int{"eth_getLogs": 75}
+
+	tests := []struct {
+		name         string
+		dailyLimit   int64
+		monthlyLimit int64
+		calls        int
+		lowPriority  bool
+		wantErr      error
+	}{
+		{name: "within the daily limit", dailyLimit: 100, calls: 1, lowPriority: true},
+		{name: "daily limit reached", dailyLimit: 100, calls: 2, lowPriority: true, wantErr: ErrBudgetExhausted},
+		{name: "monthly limit reached", monthlyLimit: 150, calls: 2, lowPriority: true, wantErr: ErrBudgetExhausted},
+		{name: "normal priority call after the limit", dailyLimit: 100, calls: 2},
+		{name: "limits disabled", calls: 100, lowPriority: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := NewBudget(tt.dailyLimit, tt.monthlyLimit, costs)
+			budget.Spend("eth_getLogs", tt.calls)
+
+			ctx := context.Background()
+			if tt.lowPriority {
+				ctx = WithLowPriority(ctx)
+			}
+
+			if err := budget.Allow(ctx); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Allow() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBudgetSpend(t *testing.T) {
+	budget := NewBudget(0, 0, map[string]int{"eth_blockNumber": 10})
+	budget.Spend("eth_blockNumber", 3)
+	budget.Spend("eth_call", 1)
+
+	usage := budget.Usage()
+
+	if usage.DailyUsed != 50 || usage.MonthlyUsed != 50 {
+		t.Errorf("Usage() spent %d today and %d this month, want 50", usage.DailyUsed, usage.MonthlyUsed)
+	}
+
+	want := map[string]MethodUsage{
+		"eth_blockNumber": {Calls: 3, ComputeUnits: 30},
+		"eth_call":        {Calls: 1, ComputeUnits: _defaultComputeUnits},
+	}
+
+	for method, wantUsage := range want {
+		if got := usage.Methods[method]; got != wantUsage {
+			t.Errorf("Usage() of %s = %+v, want %+v", method, got, wantUsage)
+		}
+	}
+}
+
+func TestBudgetRun(t *testing.T) {
+	now := time.Now().UTC()
+	today := now.Format(time.DateOnly)
+	month := now.Format("2006-01")
+	lastYear := now.AddDate(-1, 0, 0)
+
+	tests := []struct {
+		name            string
+		stored          *BudgetUsage
+		wantDailyUsed   int64
+		wantMonthlyUsed int64
+		wantCalls       int64
+	}{
+		{name: "nothing stored", wantDailyUsed: 10, wantMonthlyUsed: 10, wantCalls: 1},
+		{
+			name: "usage of the current day",
+			stored: &BudgetUsage{
+				Day:         today,
+				DailyUsed:   40,
+				Month:       month,
+				MonthlyUsed: 90,
+				Methods:     map[string]MethodUsage{"eth_blockNumber": {Calls: 9, ComputeUnits: 90}},
+			},
+			wantDailyUsed:   50,
+			wantMonthlyUsed: 100,
+			wantCalls:       10,
+		},
+		{
+			name: "usage of a previous period",
+			stored: &BudgetUsage{
+				Day:         lastYear.Format(time.DateOnly),
+				DailyUsed:   40,
+				Month:       lastYear.Format("2006-01"),
+				MonthlyUsed: 90,
+				Methods:     map[string]MethodUsage{"eth_blockNumber": {Calls: 9, ComputeUnits: 90}},
+			},
+			wantDailyUsed:   10,
+			wantMonthlyUsed: 10,
+			wantCalls:       1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeBudgetStore{usage: tt.stored}
+			budget := NewBudget(100, 1000, map[string]int{"eth_blockNumber": 10},
+				WithBudgetStore(store), WithBudgetSyncInterval(time.Hour))
+			budget.Spend("eth_blockNumber", 1)
+
+			// Run twice, as after a failure, to check that the stored usage is restored once.
+			for i := 0; i < 2; i++ {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				if err := budget.Run(ctx); err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			}
+
+			usage := budget.Usage()
+
+			if usage.DailyUsed != tt.wantDailyUsed || usage.MonthlyUsed != tt.wantMonthlyUsed {
+				t.Errorf("Usage() spent %d today and %d this month, want %d and %d",
+					usage.DailyUsed, usage.MonthlyUsed, tt.wantDailyUsed, tt.wantMonthlyUsed)
+			}
+
+			if calls := usage.Methods["eth_blockNumber"].Calls; calls != tt.wantCalls {
+				t.Errorf("Usage() counted %d calls, want %d", calls, tt.wantCalls)
+			}
+
+			if store.updates != 2 || store.usage.MonthlyUsed != tt.wantMonthlyUsed {
+				t.Errorf("stored %d times with %+v, want the current usage stored twice", store.updates, store.usage)
+			}
+		})
+	}
+}
+
+func TestBudgetRunStoresPeriodically(t *testing.T) {
+	store := &fakeBudgetStore{}
+	budget := NewBudget(0, 0, nil, WithBudgetStore(store), WithBudgetSyncInterval(10*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+
+	if err := budget.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// The usage is stored on every tick and once more when the context is canceled.
+	if store.updates < 3 {
+		t.Errorf("stored the usage %d times, want at least 3", store.updates)
+	}
+}
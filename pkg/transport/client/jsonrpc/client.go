@@ -4,14 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-
+	"errors"
 	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/httpx"
 )
 
 const (
 	_jsonrpcVersion = "2.0"
+
+	// _internalErrorCode is the JSON-RPC error code reported for batch requests left without a response.
+	_internalErrorCode = -32603
 )
 
 // RPCClient represents a JSON-RPC client.
@@ -20,6 +26,9 @@ type RPCClient struct {
 	httpClient         *httpx.Client
 	allowUnknownFields bool
 	defaultRequestID   int
+	rateLimiter        *RateLimiter
+	budget             *Budget
+	callObserver       CallObserver
 }
 
 // NewDefaultClient returns a new RPCClient instance with default configuration.
@@ -40,6 +49,9 @@ func NewClient(httpClient *httpx.Client, endpoint string, opts ...ClientOption)
 
 	rpcClient.allowUnknownFields = config.allowUnknownFields
 	rpcClient.defaultRequestID = config.defaultRequestID
+	rpcClient.rateLimiter = config.rateLimiter
+	rpcClient.budget = config.budget
+	rpcClient.callObserver = config.callObserver
 
 	return rpcClient
 }
@@ -75,22 +87,100 @@ func (c *RPCClient) CallFor(ctx context.Context, out any, method string, params
 	return rpcResponse.GetObject(out)
 }
 
+// CallBatch calls multiple JSON-RPC methods in a single HTTP request.
+//
+// Every request gets a unique ID equal to its index in the batch, so the passed requests are copied
+// rather than modified. The responses are matched back by ID and returned in the order of the requests
+// regardless of the order in which the server replied. A request without a response gets a response
+// holding an RPCError, so failures are always reported per request.
+func (c *RPCClient) CallBatch(ctx context.Context, requests RPCRequests) (RPCResponses, error) {
+	if len(requests) == 0 {
+		return nil, errors.New("empty batch request")
+	}
+
+	batch := make(RPCRequests, len(requests))
+
+	for i, req := range requests {
+		batch[i] = &RPCRequest{
+			ID:      i,
+			Method:  req.Method,
+			Params:  req.Params,
+			JSONRPC: _jsonrpcVersion,
+		}
+	}
+
+	rpcResponses, err := c.doBatchCall(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	responsesByID := rpcResponses.AsMap()
+	orderedResponses := make(RPCResponses, len(batch))
+
+	for i, req := range batch {
+		rpcResponse, found := responsesByID[req.ID]
+		if !found {
+			rpcResponse = &RPCResponse{
+				JSONRPC: _jsonrpcVersion,
+				ID:      req.ID,
+				Error: &RPCError{
+					Code:    _internalErrorCode,
+					Message: fmt.Sprintf("rpc response for %v() with id %d is missing", req.Method, req.ID),
+				},
+			}
+		}
+
+		orderedResponses[i] = rpcResponse
+	}
+
+	return orderedResponses, nil
+}
+
+// CallBatchFor calls multiple JSON-RPC methods in a single HTTP request and deserializes each result
+// in the response object with the same index. If some of the requests fail, all other results are still
+// deserialized and a BatchError holding the RPCError of each failed request is returned.
+func (c *RPCClient) CallBatchFor(ctx context.Context, out []any, requests RPCRequests) error {
+	if len(out) != len(requests) {
+		return fmt.Errorf("got %d response objects for %d batch requests", len(out), len(requests))
+	}
+
+	rpcResponses, err := c.CallBatch(ctx, requests)
+	if err != nil {
+		return err
+	}
+
+	return rpcResponses.GetObjects(out)
+}
+
 func (c *RPCClient) doCall(
 	ctx context.Context, rpcReq *RPCRequest, options ...httpx.RequestOption) (*RPCResponse, error) {
+	ctx, span := startCallSpan(ctx, rpcReq.Method)
+	defer span.End()
+
+	if err := c.throttle(ctx, map[string]int{rpcReq.Method: 1}); err != nil {
+		err = fmt.Errorf("rpc call %v(): %w", rpcReq.Method, err)
+		failSpan(ctx, err)
+
+		return nil, err
+	}
+
 	body, err := json.Marshal(rpcReq)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq, err := httpx.PostRequest(ctx, c.endpoint, bytes.NewReader(body), options...)
+	httpReq, err := httpx.PostRequest(ctx, c.endpoint, bytes.NewReader(body), propagationOptions(ctx, options)...)
 	if err != nil {
 		return nil, err
 	}
 
 	redactedURL := httpReq.URL.Redacted()
+	start := time.Now()
 
 	httpResp, err := c.httpClient.Do(httpReq)
 	if err != nil {
+		c.observeCall(ctx, rpcReq.Method, 0, start, err)
+
 		return nil, fmt.Errorf("rpc call %v() on %v: %w", rpcReq.Method, redactedURL, err)
 	}
 
@@ -107,6 +197,105 @@ func (c *RPCClient) doCall(
 	decoder.UseNumber()
 
 	err = decoder.Decode(&rpcResponse)
+	err = HandleResponseError(err, httpResp, rpcReq, redactedURL, rpcResponse)
+
+	if err == nil && rpcResponse.Error != nil {
+		c.observeCall(ctx, rpcReq.Method, httpResp.StatusCode, start, rpcResponse.Error)
+	} else {
+		c.observeCall(ctx, rpcReq.Method, httpResp.StatusCode, start, err)
+	}
+
+	return rpcResponse, err
+}
+
+func (c *RPCClient) doBatchCall(
+	ctx context.Context, rpcReqs RPCRequests, options ...httpx.RequestOption) (RPCResponses, error) {
+	methodCalls := make(map[string]int)
+	methods := make([]string, 0, len(rpcReqs))
+
+	for _, rpcReq := range rpcReqs {
+		if methodCalls[rpcReq.Method] == 0 {
+			methods = append(methods, rpcReq.Method)
+		}
+
+		methodCalls[rpcReq.Method]++
+	}
+
+	ctx, span := startCallSpan(ctx, _batchMethod,
+		attribute.Int("rpc.jsonrpc.batch_size", len(rpcReqs)),
+		attribute.StringSlice("rpc.jsonrpc.batch_methods", methods),
+	)
+	defer span.End()
+
+	if err := c.throttle(ctx, methodCalls); err != nil {
+		err = fmt.Errorf("rpc batch call: %w", err)
+		failSpan(ctx, err)
+
+		return nil, err
+	}
+
+	body, err := json.Marshal(rpcReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := httpx.PostRequest(ctx, c.endpoint, bytes.NewReader(body), propagationOptions(ctx, options)...)
+	if err != nil {
+		return nil, err
+	}
+
+	redactedURL := httpReq.URL.Redacted()
+	start := time.Now()
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		c.observeCall(ctx, _batchMethod, 0, start, err)
+
+		return nil, fmt.Errorf("rpc batch call on %v: %w", redactedURL, err)
+	}
+
+	//nolint:errcheck
+	defer httpResp.Body.Close()
+
+	var rpcResponses RPCResponses
+
+	decoder := json.NewDecoder(httpResp.Body)
+	if !c.allowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	decoder.UseNumber()
+
+	err = decoder.Decode(&rpcResponses)
+	err = HandleBatchResponseError(err, httpResp, redactedURL, rpcResponses)
+
+	c.observeCall(ctx, _batchMethod, httpResp.StatusCode, start, err)
+
+	return rpcResponses, err
+}
+
+// throttle rejects low priority calls once the budget is exhausted, waits until the calls are allowed
+// by the rate limiter and records the compute units spent on them. It takes the number of calls per method.
+func (c *RPCClient) throttle(ctx context.Context, methodCalls map[string]int) error {
+	if c.budget != nil {
+		if err := c.budget.Allow(ctx); err != nil {
+			return err
+		}
+	}
+
+	if c.rateLimiter != nil {
+		for method, n := range methodCalls {
+			if err := c.rateLimiter.Wait(ctx, method, n); err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.budget != nil {
+		for method, n := range methodCalls {
+			c.budget.Spend(method, n)
+		}
+	}
 
-	return rpcResponse, HandleResponseError(err, httpResp, rpcReq, redactedURL, rpcResponse)
+	return nil
 }
@@ -0,0 +1,165 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCallBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(requests RPCRequests) RPCResponses
+		want    []string
+	}{
+		{
+			name:    "responses in request order",
+			respond: echoResponses,
+			want:    []string{"0x1", "0x2", "0x3"},
+		},
+		{
+			name: "shuffled responses",
+			respond: func(requests RPCRequests) RPCResponses {
+				responses := echoResponses(requests)
+
+				return RPCResponses{responses[2], responses[0], responses[1]}
+			},
+			want: []string{"0x1", "0x2", "0x3"},
+		},
+		{
+			name: "missing response",
+			respond: func(requests RPCRequests) RPCResponses {
+				responses := echoResponses(requests)
+
+				return RPCResponses{responses[2], responses[0]}
+			},
+			want: []string{"0x1", "error -32603", "0x3"},
+		},
+		{
+			name: "error response",
+			respond: func(requests RPCRequests) RPCResponses {
+				responses := echoResponses(requests)
+				responses[1] = &RPCResponse{
+					JSONRPC: _jsonrpcVersion,
+					ID:      requests[1].ID,
+					Error:   &RPCError{Code: -32000, Message: "header not found"},
+				}
+
+				return responses
+			},
+			want: []string{"0x1", "error -32000", "0x3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewDefaultClient(newBatchServer(t, tt.respond).URL)
+
+			rpcResponses, err := client.CallBatch(context.Background(), testBatchRequests())
+			if err != nil {
+				t.Fatalf("CallBatch() error = %v", err)
+			}
+
+			got := make([]string, len(rpcResponses))
+
+			for i, rpcResponse := range rpcResponses {
+				if rpcResponse.Error != nil {
+					got[i] = fmt.Sprintf("error %d", rpcResponse.Error.Code)
+
+					continue
+				}
+
+				if got[i], err = rpcResponse.GetString(); err != nil {
+					t.Fatalf("GetString() error = %v", err)
+				}
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("CallBatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallBatchFor(t *testing.T) {
+	client := NewDefaultClient(newBatchServer(t, func(requests RPCRequests) RPCResponses {
+		responses := echoResponses(requests)
+		responses[1].Result = nil
+		responses[1].Error = &RPCError{Code: -32000, Message: "header not found"}
+
+		// The response to the last request is missing and the others are sent in reverse order.
+		return RPCResponses{responses[1], responses[0]}
+	}).URL)
+
+	out := make([]string, 3)
+
+	err := client.CallBatchFor(context.Background(), []any{&out[0], &out[1], &out[2]}, testBatchRequests())
+
+	var batchErr *BatchError
+
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("CallBatchFor() error = %v, want a BatchError", err)
+	}
+
+	if len(batchErr.Errors) != 2 || batchErr.Errors[1].Code != -32000 || batchErr.Errors[2].Code != _internalErrorCode {
+		t.Errorf("CallBatchFor() errors = %v, want -32000 for request 1 and %d for request 2",
+			batchErr, _internalErrorCode)
+	}
+
+	if fmt.Sprint(out) != fmt.Sprint([]string{"0x1", "", ""}) {
+		t.Errorf("CallBatchFor() results = %q, want the result of the successful request only", out)
+	}
+}
+
+// newBatchServer returns a test JSON-RPC server replying to every batch request with the given responses.
+func newBatchServer(t *testing.T, respond func(requests RPCRequests) RPCResponses) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requests RPCRequests
+
+		if err := json.NewDecoder(r.Body).Decode(&requests); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		_ = json.NewEncoder(w).Encode(respond(requests))
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// echoResponses answers every request with its first param in the order of the requests.
+func echoResponses(requests RPCRequests) RPCResponses {
+	responses := make(RPCResponses, len(requests))
+
+	for i, request := range requests {
+		params, _ := request.Params.([]any)
+
+		responses[i] = &RPCResponse{
+			JSONRPC: _jsonrpcVersion,
+			ID:      request.ID,
+			Result:  params[0],
+		}
+	}
+
+	return responses
+}
+
+// testBatchRequests returns requests sharing the default ID, which the batch call has to make unique.
+func testBatchRequests() RPCRequests {
+	return RPCRequests{
+		NewRequest("eth_getBlockByNumber", "0x1"),
+		NewRequest("eth_getBlockByNumber", "0x2"),
+		NewRequest("eth_getBlockByNumber", "0x3"),
+	}
+}
@@ -1,7 +1,10 @@
 package jsonrpc
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/httpx"
 
@@ -9,6 +12,17 @@ import (
 	"strconv"
 )
 
+const (
+	// MethodNotFoundCode is the JSON-RPC error code of calls to a method the node does not implement.
+	MethodNotFoundCode = -32601
+	// InvalidParamsCode is the JSON-RPC error code of calls whose params the node rejects, e.g. an unknown block tag.
+	InvalidParamsCode = -32602
+)
+
+// _unsupportedMessages are parts of the messages with which providers reject methods or params they do not support
+// under a generic error code.
+var _unsupportedMessages = []string{"not supported", "unsupported", "does not exist"}
+
 // RPCError represents a JSON-RPC error object if an RPC error occurred.
 // See: http://www.jsonrpc.org/specification#error_object
 type RPCError struct {
@@ -22,6 +36,42 @@ func (e *RPCError) Error() string {
 	return strconv.Itoa(e.Code) + ": " + e.Message
 }
 
+// IsMethodNotFound reports if a call failed because the node does not implement the method.
+// Unlike rate limits or internal errors, this does not change when the call is retried.
+func IsMethodNotFound(err error) bool {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+
+	return rpcErr.Code == MethodNotFoundCode || rpcErr.isUnsupported()
+}
+
+// IsUnsupportedParams reports if a call failed because the node does not implement the method or does not
+// support its params, e.g. the safe and finalized block tags. Unlike rate limits or internal errors, this does
+// not change when the call is retried.
+func IsUnsupportedParams(err error) bool {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+
+	return rpcErr.Code == MethodNotFoundCode || rpcErr.Code == InvalidParamsCode || rpcErr.isUnsupported()
+}
+
+// isUnsupported reports if the message of an error tells that a method or its params are not supported.
+func (e *RPCError) isUnsupported() bool {
+	message := strings.ToLower(e.Message)
+
+	for _, unsupported := range _unsupportedMessages {
+		if strings.Contains(message, unsupported) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // HandleResponseError handles errouneous responses.
 func HandleResponseError(
 	err error, httpResp *http.Response, rpcReq *RPCRequest, redactedURL string, rpcResponse *RPCResponse) error {
@@ -79,3 +129,65 @@ func HandleResponseError(
 
 	return nil
 }
+
+// BatchError represents the errors of the failed requests in a batch call keyed by the index of the request.
+type BatchError struct {
+	Errors map[int]*RPCError
+}
+
+// Error function is provided to be used as error object.
+func (e *BatchError) Error() string {
+	indices := make([]int, 0, len(e.Errors))
+
+	for i := range e.Errors {
+		indices = append(indices, i)
+	}
+
+	sort.Ints(indices)
+
+	messages := make([]string, len(indices))
+
+	for i, index := range indices {
+		messages[i] = fmt.Sprintf("request %d: %v", index, e.Errors[index])
+	}
+
+	return fmt.Sprintf("%d batch requests failed: %s", len(indices), strings.Join(messages, "; "))
+}
+
+// HandleBatchResponseError handles erroneous responses of batch calls.
+func HandleBatchResponseError(
+	err error, httpResp *http.Response, redactedURL string, rpcResponses RPCResponses) error {
+	if err != nil {
+		return withHTTPStatus(httpResp.StatusCode, fmt.Errorf(
+			"rpc batch call on %v status code: %v. could not decode body to rpc responses: %w",
+			redactedURL, httpResp.StatusCode, err))
+	}
+
+	// response body empty
+	if len(rpcResponses) == 0 {
+		return withHTTPStatus(httpResp.StatusCode, fmt.Errorf(
+			"rpc batch call on %v status code: %v. rpc responses missing",
+			redactedURL, httpResp.StatusCode))
+	}
+
+	// if we have a response body, but also an HTTP error situation, return the HTTP error
+	if httpResp.StatusCode >= 400 {
+		return withHTTPStatus(httpResp.StatusCode, fmt.Errorf(
+			"rpc batch call on %v status code: %v",
+			redactedURL, httpResp.StatusCode))
+	}
+
+	return nil
+}
+
+// withHTTPStatus wraps an error in an httpx.Error if the HTTP status code signals an error.
+func withHTTPStatus(statusCode int, err error) error {
+	if statusCode >= 400 {
+		return &httpx.Error{
+			Code: statusCode,
+			Err:  err,
+		}
+	}
+
+	return err
+}
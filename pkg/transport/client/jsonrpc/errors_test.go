@@ -0,0 +1,70 @@
+package jsonrpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestUnsupportedErrors(t *testing.T) {
+	tests := []struct {
+		name                  string
+		err                   error
+		wantMethodNotFound    bool
+		wantUnsupportedParams bool
+	}{
+		{
+			name: "method not found",
+			err: &RPCError{
+				Code:    MethodNotFoundCode,
+				Message: "the method eth_getBlockReceipts does not exist",
+			},
+			wantMethodNotFound:    true,
+			wantUnsupportedParams: true,
+		},
+		{
+			name:                  "wrapped method not found",
+			err:                   fmt.Errorf("rpc call: %w", &RPCError{Code: MethodNotFoundCode, Message: "not found"}),
+			wantMethodNotFound:    true,
+			wantUnsupportedParams: true,
+		},
+		{
+			name:                  "unsupported method message",
+			err:                   &RPCError{Code: -32000, Message: "Method not supported"},
+			wantMethodNotFound:    true,
+			wantUnsupportedParams: true,
+		},
+		{
+			name:                  "invalid params",
+			err:                   &RPCError{Code: InvalidParamsCode, Message: "invalid block tag finalized"},
+			wantUnsupportedParams: true,
+		},
+		{
+			name: "rate limit",
+			err:  &RPCError{Code: 429, Message: "too many requests"},
+		},
+		{
+			name: "internal error",
+			err:  &RPCError{Code: _internalErrorCode, Message: "internal error"},
+		},
+		{
+			name: "transport error",
+			err:  errors.New("connection refused"),
+		},
+		{
+			name: "no error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsMethodNotFound(tt.err); got != tt.wantMethodNotFound {
+				t.Errorf("IsMethodNotFound() = %v, want %v", got, tt.wantMethodNotFound)
+			}
+
+			if got := IsUnsupportedParams(tt.err); got != tt.wantUnsupportedParams {
+				t.Errorf("IsUnsupportedParams() = %v, want %v", got, tt.wantUnsupportedParams)
+			}
+		})
+	}
+}
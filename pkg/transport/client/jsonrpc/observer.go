@@ -0,0 +1,46 @@
+package jsonrpc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// _batchMethod is the method reported to a CallObserver for batch calls, which may mix several methods.
+const _batchMethod = "batch"
+
+// CallObserver is notified about the outcome of every call made by a client, e.g. to export metrics.
+// The same CallObserver can be shared by multiple clients.
+type CallObserver interface {
+	// ObserveCall records the duration of a call of a method, the HTTP status code of its response
+	// or 0 if no response was received and the error of the call, if any. Batch calls are reported
+	// as a single call of the "batch" method.
+	ObserveCall(method string, statusCode int, duration time.Duration, err error)
+}
+
+// observeCall logs a call which has started at a given time, records its outcome on the span of the call
+// and reports it to the call observer, if any. The log record gets the attributes of the context,
+// e.g. the ID of the API request the call is made for.
+func (c *RPCClient) observeCall(ctx context.Context, method string, statusCode int, start time.Time, err error) {
+	duration := time.Since(start)
+
+	if statusCode > 0 {
+		trace.SpanFromContext(ctx).SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+	}
+
+	if err != nil {
+		failSpan(ctx, err)
+
+		slog.WarnContext(ctx, "rpc call failed",
+			"method", method, "status", statusCode, "duration", duration, "error", err)
+	} else {
+		slog.DebugContext(ctx, "rpc call", "method", method, "status", statusCode, "duration", duration)
+	}
+
+	if c.callObserver != nil {
+		c.callObserver.ObserveCall(method, statusCode, duration, err)
+	}
+}
@@ -1,8 +1,16 @@
 package jsonrpc
 
+import "time"
+
+// _defaultBudgetSyncInterval is the interval at which a Budget stores its usage by default.
+const _defaultBudgetSyncInterval = time.Minute
+
 type clientConfig struct {
 	allowUnknownFields bool
 	defaultRequestID   int
+	rateLimiter        *RateLimiter
+	budget             *Budget
+	callObserver       CallObserver
 }
 
 func newRPCClientDefaultConfig() *clientConfig {
@@ -34,3 +42,60 @@ func WithDefaultRequestID(defaultRequestID int) ClientOption {
 		o.defaultRequestID = defaultRequestID
 	}
 }
+
+// WithRateLimiter specifies a RateLimiter delaying calls which exceed the rate limit of their method.
+// The same RateLimiter can be shared by multiple clients.
+func WithRateLimiter(rateLimiter *RateLimiter) ClientOption {
+	return func(o *clientConfig) {
+		o.rateLimiter = rateLimiter
+	}
+}
+
+// WithBudget specifies a Budget tracking the compute units spent by the calls of the client.
+// The same Budget can be shared by multiple clients.
+func WithBudget(budget *Budget) ClientOption {
+	return func(o *clientConfig) {
+		o.budget = budget
+	}
+}
+
+// WithCallObserver specifies a CallObserver notified about the outcome of every call of the client.
+func WithCallObserver(callObserver CallObserver) ClientOption {
+	return func(o *clientConfig) {
+		o.callObserver = callObserver
+	}
+}
+
+type budgetConfig struct {
+	store        BudgetStore
+	syncInterval time.Duration
+}
+
+func newBudgetDefaultConfig() *budgetConfig {
+	return &budgetConfig{
+		syncInterval: _defaultBudgetSyncInterval,
+	}
+}
+
+func (o *budgetConfig) applyOptions(opts ...BudgetOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// BudgetOption specifies a Budget setting.
+type BudgetOption func(config *budgetConfig)
+
+// WithBudgetStore specifies a BudgetStore persisting the usage of the Budget while it is run.
+func WithBudgetStore(store BudgetStore) BudgetOption {
+	return func(o *budgetConfig) {
+		o.store = store
+	}
+}
+
+// WithBudgetSyncInterval specifies the interval at which the Budget stores its usage. Defaults to one minute.
+func WithBudgetSyncInterval(syncInterval time.Duration) BudgetOption {
+	return func(o *budgetConfig) {
+		o.syncInterval = syncInterval
+	}
+}
@@ -0,0 +1,91 @@
+package jsonrpc
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimit specifies the maximum rate of calls of a JSON-RPC method.
+type RateLimit struct {
+	// RequestsPerSecond is the sustained rate of calls. A non-positive value disables the limit.
+	RequestsPerSecond float64
+	// Burst is the number of calls allowed at once. It is at least 1.
+	Burst int
+}
+
+// RateLimiter delays calls exceeding the rate limit of their JSON-RPC method.
+// Methods without a dedicated limit share the default limit.
+type RateLimiter struct {
+	sync.Mutex
+	defaultLimit   RateLimit
+	methodLimits   map[string]RateLimit
+	limiters       map[string]*rate.Limiter
+	defaultLimiter *rate.Limiter
+}
+
+// NewRateLimiter returns a new RateLimiter with a default limit and limits for specific methods.
+func NewRateLimiter(defaultLimit RateLimit, methodLimits map[string]RateLimit) *RateLimiter {
+	return &RateLimiter{
+		defaultLimit:   defaultLimit,
+		methodLimits:   methodLimits,
+		limiters:       make(map[string]*rate.Limiter),
+		defaultLimiter: newLimiter(defaultLimit),
+	}
+}
+
+// Wait blocks until n calls of a method are allowed or the context is canceled.
+func (l *RateLimiter) Wait(ctx context.Context, method string, n int) error {
+	limiter := l.limiter(method)
+	if limiter == nil {
+		return nil
+	}
+
+	// A limiter rejects waiting for more calls than its burst at once, so larger batches wait in steps.
+	for n > 0 {
+		step := n
+		if step > limiter.Burst() {
+			step = limiter.Burst()
+		}
+
+		if err := limiter.WaitN(ctx, step); err != nil {
+			return err
+		}
+
+		n -= step
+	}
+
+	return nil
+}
+
+func (l *RateLimiter) limiter(method string) *rate.Limiter {
+	methodLimit, found := l.methodLimits[method]
+	if !found {
+		return l.defaultLimiter
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	limiter, found := l.limiters[method]
+	if !found {
+		limiter = newLimiter(methodLimit)
+		l.limiters[method] = limiter
+	}
+
+	return limiter
+}
+
+func newLimiter(limit RateLimit) *rate.Limiter {
+	if limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	burst := limit.Burst
+	if burst < 1 {
+		burst = 1
+	}
+
+	return rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), burst)
+}
@@ -0,0 +1,92 @@
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	methodLimits := map[string]RateLimit{"eth_getLogs": {RequestsPerSecond: 50, Burst: 1}}
+
+	tests := []struct {
+		name         string
+		defaultLimit RateLimit
+		method       string
+		calls        []int
+		wantMin      time.Duration
+		wantMax      time.Duration
+	}{
+		{
+			name:    "disabled limit",
+			method:  "eth_blockNumber",
+			calls:   []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+			wantMax: 25 * time.Millisecond,
+		},
+		{
+			name:         "calls within the burst",
+			defaultLimit: RateLimit{RequestsPerSecond: 100, Burst: 5},
+			method:       "eth_blockNumber",
+			calls:        []int{1, 1, 1, 1, 1},
+			wantMax:      25 * time.Millisecond,
+		},
+		{
+			name:         "calls beyond the burst",
+			defaultLimit: RateLimit{RequestsPerSecond: 100, Burst: 1},
+			method:       "eth_blockNumber",
+			calls:        []int{1, 1, 1, 1},
+			wantMin:      30 * time.Millisecond,
+		},
+		{
+			name:         "batch larger than the burst",
+			defaultLimit: RateLimit{RequestsPerSecond: 100, Burst: 2},
+			method:       "eth_blockNumber",
+			calls:        []int{6},
+			wantMin:      40 * time.Millisecond,
+		},
+		{
+			name:    "method limit",
+			method:  "eth_getLogs",
+			calls:   []int{1, 1, 1},
+			wantMin: 40 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(tt.defaultLimit, methodLimits)
+			start := time.Now()
+
+			for _, n := range tt.calls {
+				if err := limiter.Wait(context.Background(), tt.method, n); err != nil {
+					t.Fatalf("Wait() error = %v", err)
+				}
+			}
+
+			elapsed := time.Since(start)
+
+			if elapsed < tt.wantMin {
+				t.Errorf("Wait() took %s, want at least %s", elapsed, tt.wantMin)
+			}
+
+			if tt.wantMax > 0 && elapsed > tt.wantMax {
+				t.Errorf("Wait() took %s, want at most %s", elapsed, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{RequestsPerSecond: 1, Burst: 1}, nil)
+
+	if err := limiter.Wait(context.Background(), "eth_blockNumber", 1); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx, "eth_blockNumber", 1); err == nil {
+		t.Error("Wait() error = nil, want an error once the context is done before the call is allowed")
+	}
+}
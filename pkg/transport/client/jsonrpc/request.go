@@ -50,6 +50,11 @@ type RPCRequest struct {
 	JSONRPC string `json:"jsonrpc"`
 }
 
+// RPCRequests represents a batch of JSON-RPC requests sent in a single HTTP request.
+//
+// See: http://www.jsonrpc.org/specification#batch
+type RPCRequests []*RPCRequest
+
 // NewRequest returns a new RPCRequest that can be created using the same convenient parameter syntax as Call()
 //
 // Default RPCRequest id is 0. If you want to use an id other than 0, use NewRequestWithID() or set the ID field
@@ -23,6 +23,54 @@ type RPCResponse struct {
 	ID      int       `json:"id"`
 }
 
+// RPCResponses represents the responses of a batch call.
+type RPCResponses []*RPCResponse
+
+// AsMap returns the responses of a batch call mapped by their request ID.
+func (res RPCResponses) AsMap() map[int]*RPCResponse {
+	resMap := make(map[int]*RPCResponse, len(res))
+
+	for _, r := range res {
+		if r != nil {
+			resMap[r.ID] = r
+		}
+	}
+
+	return resMap
+}
+
+// GetObjects deserializes each result of a batch call in the response object with the same index.
+//
+// If some of the responses hold an RPCError, all other results are still deserialized and a BatchError
+// holding the RPCError of each failed request is returned.
+func (res RPCResponses) GetObjects(out []any) error {
+	if len(out) != len(res) {
+		return fmt.Errorf("got %d response objects for %d batch responses", len(out), len(res))
+	}
+
+	batchErr := &BatchError{
+		Errors: make(map[int]*RPCError),
+	}
+
+	for i, rpcResponse := range res {
+		if rpcResponse.Error != nil {
+			batchErr.Errors[i] = rpcResponse.Error
+
+			continue
+		}
+
+		if err := rpcResponse.GetObject(out[i]); err != nil {
+			return err
+		}
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return batchErr
+	}
+
+	return nil
+}
+
 // GetInt converts the rpc response to an int64 and returns it.
 //
 // If result was not an integer an error is returned.
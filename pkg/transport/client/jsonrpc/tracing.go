@@ -0,0 +1,59 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/logging"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/httpx"
+)
+
+var _tracer = otel.Tracer("github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc")
+
+// startCallSpan starts the client span of a call of a method. The span covers the time spent waiting
+// for the rate limiter as well, so that throttled calls can be told apart from slow nodes.
+func startCallSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return _tracer.Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.RPCSystemKey.String("jsonrpc"), semconv.RPCMethod(method)),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// failSpan marks the span of a context as failed. Errors returned by the node are recorded with their code.
+func failSpan(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		span.SetAttributes(semconv.RPCJsonrpcErrorCode(rpcErr.Code), semconv.RPCJsonrpcErrorMessage(rpcErr.Message))
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// propagationOptions adds the trace context and the ID of the API request a call is made for
+// to its HTTP request, so that the call can be correlated in the traces and logs of the Ethereum node.
+func propagationOptions(ctx context.Context, options []httpx.RequestOption) []httpx.RequestOption {
+	carrier := propagation.MapCarrier{}
+
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	for key, value := range carrier {
+		options = append(options, httpx.WithHeader(key, value))
+	}
+
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		options = append(options, httpx.WithHeader(logging.RequestIDHeader, requestID))
+	}
+
+	return options
+}
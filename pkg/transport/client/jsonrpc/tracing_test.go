@@ -0,0 +1,137 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/logging"
+)
+
+var (
+	_spanRecorderOnce sync.Once
+	_spanRecorder     *tracetest.SpanRecorder
+)
+
+func TestCallPropagatesTraceContext(t *testing.T) {
+	tests := []struct {
+		name         string
+		rpcError     *RPCError
+		wantStatus   codes.Code
+		wantErrorSet bool
+	}{
+		{name: "successful call", wantStatus: codes.Unset},
+		{
+			name:         "error of the node",
+			rpcError:     &RPCError{Code: -32000, Message: "header not found"},
+			wantStatus:   codes.Error,
+			wantErrorSet: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := testSpanRecorder()
+
+			var headers http.Header
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				headers = r.Header.Clone()
+
+				var request RPCRequest
+
+				if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+
+					return
+				}
+
+				response := &RPCResponse{JSONRPC: _jsonrpcVersion, ID: request.ID, Error: tt.rpcError}
+				if tt.rpcError == nil {
+					response.Result = "0x1"
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+
+				_ = json.NewEncoder(w).Encode(response)
+			}))
+			t.Cleanup(server.Close)
+
+			ctx, parent := otel.Tracer("test").Start(logging.WithRequestID(context.Background(), "abc"), "GET /test")
+
+			_, err := NewDefaultClient(server.URL).Call(ctx, "eth_blockNumber")
+			if err != nil {
+				t.Fatalf("Call() error = %v", err)
+			}
+
+			parent.End()
+
+			traceID := parent.SpanContext().TraceID()
+
+			if got := headers.Get("traceparent"); got == "" || got[3:35] != traceID.String() {
+				t.Errorf("traceparent = %q, want one of trace %s", got, traceID)
+			}
+
+			if got := headers.Get(logging.RequestIDHeader); got != "abc" {
+				t.Errorf("%s = %q, want abc", logging.RequestIDHeader, got)
+			}
+
+			span := callSpan(recorder, parent)
+			if span == nil {
+				t.Fatal("no span recorded for the call")
+			}
+
+			if span.Name() != "eth_blockNumber" || span.Status().Code != tt.wantStatus {
+				t.Errorf("span %s with status %v, want eth_blockNumber with status %v",
+					span.Name(), span.Status().Code, tt.wantStatus)
+			}
+
+			errorSet := false
+
+			for _, attr := range span.Attributes() {
+				if attr.Key == semconv.RPCJsonrpcErrorCodeKey {
+					errorSet = true
+				}
+			}
+
+			if errorSet != tt.wantErrorSet {
+				t.Errorf("span has the error code of the node %v, want %v", errorSet, tt.wantErrorSet)
+			}
+		})
+	}
+}
+
+// testSpanRecorder installs a tracer provider recording all spans as the global one. The tracers of the
+// package are bound to the first global provider, so it is installed only once.
+func testSpanRecorder() *tracetest.SpanRecorder {
+	_spanRecorderOnce.Do(func() {
+		_spanRecorder = tracetest.NewSpanRecorder()
+
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(_spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	return _spanRecorder
+}
+
+// callSpan returns the recorded child span of a given parent span or nil if there is none.
+func callSpan(recorder *tracetest.SpanRecorder, parent trace.Span) sdktrace.ReadOnlySpan {
+	for _, span := range recorder.Ended() {
+		if span.Parent().SpanID() == parent.SpanContext().SpanID() {
+			return span
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package rpcpool
+
+import (
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+// _ewmaWeight is the weight of the latest observation in the moving averages of latency and error rate.
+const _ewmaWeight = 0.2
+
+// EndpointStatus represents the health of an upstream endpoint as seen by the pool.
+type EndpointStatus struct {
+	URL                 string        `json:"url"`
+	Healthy             bool          `json:"healthy"`
+	Latency             time.Duration `json:"latency"`
+	ErrorRate           float64       `json:"errorRate"`
+	HeadBlock           int           `json:"headBlock"`
+	Lagging             bool          `json:"lagging"`
+	ConsecutiveFailures int           `json:"consecutiveFailures"`
+	EjectedUntil        time.Time     `json:"ejectedUntil"`
+}
+
+// endpoint tracks the health of a single upstream node.
+type endpoint struct {
+	sync.Mutex
+	url    string
+	client *jsonrpc.RPCClient
+
+	latency             time.Duration
+	errorRate           float64
+	headBlock           int
+	lagging             bool
+	consecutiveFailures int
+	ejectedUntil        time.Time
+}
+
+func newEndpoint(endpointURL string, client *jsonrpc.RPCClient) *endpoint {
+	redactedURL := endpointURL
+	if parsedURL, err := url.Parse(endpointURL); err == nil {
+		redactedURL = parsedURL.Redacted()
+	}
+
+	return &endpoint{
+		url:       redactedURL,
+		client:    client,
+		headBlock: -1,
+	}
+}
+
+// record updates the latency and error rate averages with the outcome of a call.
+// An endpoint failing too many times in a row is ejected for a cooldown period.
+func (e *endpoint) record(latency time.Duration, failed bool, config *poolConfig) {
+	e.Lock()
+	defer e.Unlock()
+
+	failure := 0.0
+	if failed {
+		failure = 1
+	}
+
+	e.errorRate = _ewmaWeight*failure + (1-_ewmaWeight)*e.errorRate
+
+	if !failed {
+		if e.latency == 0 {
+			e.latency = latency
+		} else {
+			e.latency = time.Duration(_ewmaWeight*float64(latency) + (1-_ewmaWeight)*float64(e.latency))
+		}
+
+		e.consecutiveFailures = 0
+		e.ejectedUntil = time.Time{}
+
+		return
+	}
+
+	e.consecutiveFailures++
+
+	if config.maxFailures > 0 && e.consecutiveFailures >= config.maxFailures {
+		e.ejectedUntil = time.Now().Add(config.cooldown)
+	}
+}
+
+// setHead records the latest head block reported by the endpoint.
+func (e *endpoint) setHead(headBlock int) {
+	e.Lock()
+	e.headBlock = headBlock
+	e.Unlock()
+}
+
+// updateLagging ejects the endpoint while its head lags behind the best head of the pool by more than maxHeadLag.
+func (e *endpoint) updateLagging(bestHead, maxHeadLag int) {
+	e.Lock()
+	e.lagging = maxHeadLag >= 0 && e.headBlock >= 0 && bestHead-e.headBlock > maxHeadLag
+	e.Unlock()
+}
+
+// healthy reports if calls should be routed to the endpoint.
+func (e *endpoint) healthy(now time.Time) bool {
+	e.Lock()
+	defer e.Unlock()
+
+	return !e.lagging && !now.Before(e.ejectedUntil)
+}
+
+// score estimates the latency of a successful call, so that slow and failing endpoints are ranked last.
+func (e *endpoint) score() float64 {
+	e.Lock()
+	defer e.Unlock()
+
+	errorRate := e.errorRate
+	if errorRate > 0.99 {
+		errorRate = 0.99
+	}
+
+	return float64(e.latency) / (1 - errorRate)
+}
+
+func (e *endpoint) status(now time.Time) EndpointStatus {
+	healthy := e.healthy(now)
+
+	e.Lock()
+	defer e.Unlock()
+
+	return EndpointStatus{
+		URL:                 e.url,
+		Healthy:             healthy,
+		Latency:             e.latency,
+		ErrorRate:           e.errorRate,
+		HeadBlock:           e.headBlock,
+		Lagging:             e.lagging,
+		ConsecutiveFailures: e.consecutiveFailures,
+		EjectedUntil:        e.ejectedUntil,
+	}
+}
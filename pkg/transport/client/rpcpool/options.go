@@ -0,0 +1,65 @@
+package rpcpool
+
+import "time"
+
+const (
+	_defaultMaxHeadLag          = 5
+	_defaultHealthCheckInterval = 10 * time.Second
+	_defaultMaxFailures         = 3
+	_defaultCooldown            = 30 * time.Second
+)
+
+type poolConfig struct {
+	maxHeadLag          int
+	healthCheckInterval time.Duration
+	maxFailures         int
+	cooldown            time.Duration
+}
+
+func newPoolDefaultConfig() *poolConfig {
+	return &poolConfig{
+		maxHeadLag:          _defaultMaxHeadLag,
+		healthCheckInterval: _defaultHealthCheckInterval,
+		maxFailures:         _defaultMaxFailures,
+		cooldown:            _defaultCooldown,
+	}
+}
+
+func (o *poolConfig) applyOptions(opts ...PoolOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// PoolOption specifies a Pool setting.
+type PoolOption func(config *poolConfig)
+
+// WithMaxHeadLag specifies by how many blocks an endpoint may lag behind the best head of the pool
+// before it is ejected. A negative value disables the check.
+func WithMaxHeadLag(maxHeadLag int) PoolOption {
+	return func(o *poolConfig) {
+		o.maxHeadLag = maxHeadLag
+	}
+}
+
+// WithHealthCheckInterval specifies how often the head and latency of all endpoints are checked.
+func WithHealthCheckInterval(interval time.Duration) PoolOption {
+	return func(o *poolConfig) {
+		o.healthCheckInterval = interval
+	}
+}
+
+// WithMaxFailures specifies after how many consecutive failed calls an endpoint is ejected
+// for the cooldown period.
+func WithMaxFailures(maxFailures int) PoolOption {
+	return func(o *poolConfig) {
+		o.maxFailures = maxFailures
+	}
+}
+
+// WithCooldown specifies for how long an endpoint is ejected after too many consecutive failed calls.
+func WithCooldown(cooldown time.Duration) PoolOption {
+	return func(o *poolConfig) {
+		o.cooldown = cooldown
+	}
+}
@@ -0,0 +1,322 @@
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/numbers"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/httpx"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+// ErrNoEndpoints is returned by calls made on a pool without endpoints.
+var ErrNoEndpoints = errors.New("rpc pool has no endpoints")
+
+// errNullResult signals that an endpoint responded with null to a call for block data.
+var errNullResult = errors.New("endpoint returned null block data")
+
+// _blockDataMethods lists the methods returning a block or its receipts. A null result means that the endpoint
+// has not seen the block yet, e.g. because its head lags behind the others, so the call is failed over.
+var _blockDataMethods = map[string]bool{
+	"eth_getBlockByNumber":      true,
+	"eth_getBlockByHash":        true,
+	"eth_getBlockReceipts":      true,
+	"eth_getTransactionReceipt": true,
+}
+
+// Pool represents a JSON-RPC client spreading calls over multiple upstream endpoints.
+//
+// Calls are routed to the healthy endpoint with the best latency and error rate. A call failing
+// on transport level or with an HTTP 5xx or 429 status is retried on the next endpoint. Endpoints failing
+// repeatedly are ejected for a cooldown period and endpoints whose head lags behind the best head
+// of the pool are ejected until they catch up. A call for a block or receipts answered with null is retried
+// on the next endpoint as well, in case the endpoint lags behind but has not been ejected yet.
+type Pool struct {
+	endpoints []*endpoint
+	config    *poolConfig
+}
+
+// NewPool returns a new Pool instance with custom JSON-RPC clients keyed by endpoint URL.
+func NewPool(endpointURLs []string, clients map[string]*jsonrpc.RPCClient, opts ...PoolOption) *Pool {
+	config := newPoolDefaultConfig()
+
+	config.applyOptions(opts...)
+
+	endpoints := make([]*endpoint, 0, len(endpointURLs))
+
+	for _, endpointURL := range endpointURLs {
+		endpoints = append(endpoints, newEndpoint(endpointURL, clients[endpointURL]))
+	}
+
+	return &Pool{
+		endpoints: endpoints,
+		config:    config,
+	}
+}
+
+// Run checks the head and latency of all endpoints periodically until the context is canceled.
+// Endpoints which could not be checked are logged and recorded in their health, since a failing endpoint
+// is routed around rather than a failure of the pool, so it always returns nil.
+func (p *Pool) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.config.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.CheckHealth(ctx); err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "rpc pool health check failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckHealth fetches the head of all endpoints concurrently and ejects the endpoints lagging behind
+// the best head of the pool. It returns the errors of all endpoints that could not be checked.
+func (p *Pool) CheckHealth(ctx context.Context) error {
+	var wg sync.WaitGroup
+
+	errs := make([]error, len(p.endpoints))
+
+	for i, e := range p.endpoints {
+		wg.Add(1)
+
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+
+			var head string
+
+			start := time.Now()
+			err := e.client.CallFor(ctx, &head, "eth_blockNumber")
+			e.record(time.Since(start), err != nil, p.config)
+
+			if err != nil {
+				errs[i] = fmt.Errorf("health check of %s failed: %w", e.url, err)
+
+				return
+			}
+
+			headBlock, err := numbers.HexToInt(head)
+			if err != nil {
+				errs[i] = fmt.Errorf("health check of %s returned invalid head %q: %w", e.url, head, err)
+
+				return
+			}
+
+			e.setHead(headBlock)
+		}(i, e)
+	}
+
+	wg.Wait()
+
+	bestHead := -1
+
+	for _, e := range p.endpoints {
+		if status := e.status(time.Now()); status.HeadBlock > bestHead {
+			bestHead = status.HeadBlock
+		}
+	}
+
+	for _, e := range p.endpoints {
+		e.updateLagging(bestHead, p.config.maxHeadLag)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Endpoints returns the health of all endpoints of the pool.
+func (p *Pool) Endpoints() []EndpointStatus {
+	now := time.Now()
+	statuses := make([]EndpointStatus, len(p.endpoints))
+
+	for i, e := range p.endpoints {
+		statuses[i] = e.status(now)
+	}
+
+	return statuses
+}
+
+// Call calls a JSON-RPC method with optional params.
+func (p *Pool) Call(ctx context.Context, method string, params ...any) (*jsonrpc.RPCResponse, error) {
+	var rpcResponse *jsonrpc.RPCResponse
+
+	err := p.do(ctx, func(client *jsonrpc.RPCClient) error {
+		var err error
+
+		rpcResponse, err = client.Call(ctx, method, params...)
+		if err == nil && isNullBlockData(method, rpcResponse) {
+			return errNullResult
+		}
+
+		return err
+	})
+	if err != nil && !errors.Is(err, errNullResult) {
+		return nil, err
+	}
+
+	return rpcResponse, nil
+}
+
+// CallFor calls a JSON-RPC method and deserializes the response in a specified response object.
+func (p *Pool) CallFor(ctx context.Context, out any, method string, params ...any) error {
+	rpcResponse, err := p.Call(ctx, method, params...)
+	if err != nil {
+		return err
+	}
+
+	if rpcResponse.Error != nil {
+		return rpcResponse.Error
+	}
+
+	return rpcResponse.GetObject(out)
+}
+
+// CallBatch calls multiple JSON-RPC methods in a single HTTP request.
+func (p *Pool) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	var rpcResponses jsonrpc.RPCResponses
+
+	err := p.do(ctx, func(client *jsonrpc.RPCClient) error {
+		var err error
+
+		rpcResponses, err = client.CallBatch(ctx, requests)
+		if err != nil {
+			return err
+		}
+
+		for i, rpcResponse := range rpcResponses {
+			if isNullBlockData(requests[i].Method, rpcResponse) {
+				return errNullResult
+			}
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errNullResult) {
+		return nil, err
+	}
+
+	return rpcResponses, nil
+}
+
+// CallBatchFor calls multiple JSON-RPC methods in a single HTTP request and deserializes each result
+// in the response object with the same index.
+func (p *Pool) CallBatchFor(ctx context.Context, out []any, requests jsonrpc.RPCRequests) error {
+	if len(out) != len(requests) {
+		return fmt.Errorf("got %d response objects for %d batch requests", len(out), len(requests))
+	}
+
+	rpcResponses, err := p.CallBatch(ctx, requests)
+	if err != nil {
+		return err
+	}
+
+	return rpcResponses.GetObjects(out)
+}
+
+// do runs a call on the endpoints in the order of their score and fails over to the next endpoint
+// as long as the call fails because of the endpoint rather than because of the request.
+func (p *Pool) do(ctx context.Context, call func(client *jsonrpc.RPCClient) error) error {
+	if len(p.endpoints) == 0 {
+		return ErrNoEndpoints
+	}
+
+	var errs []error
+
+	for _, e := range p.candidates() {
+		start := time.Now()
+		err := call(e.client)
+
+		if ctx.Err() != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		if errors.Is(err, errNullResult) {
+			// The endpoint is reachable, but may lag behind, so it is neither penalized nor trusted.
+			continue
+		}
+
+		failover := isFailoverError(err)
+		e.record(time.Since(start), failover, p.config)
+
+		if !failover {
+			return err
+		}
+
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		// All endpoints returned null, so the block or receipt does not exist yet.
+		return errNullResult
+	}
+
+	return fmt.Errorf("all %d endpoints failed: %w", len(p.endpoints), errors.Join(errs...))
+}
+
+// candidates returns the healthy endpoints ordered by score. If no endpoint is healthy,
+// all endpoints are returned, since an unhealthy endpoint is still better than none.
+func (p *Pool) candidates() []*endpoint {
+	now := time.Now()
+	candidates := make([]*endpoint, 0, len(p.endpoints))
+
+	for _, e := range p.endpoints {
+		if e.healthy(now) {
+			candidates = append(candidates, e)
+		}
+	}
+
+	if len(candidates) == 0 {
+		candidates = append(candidates, p.endpoints...)
+	}
+
+	scores := make(map[*endpoint]float64, len(candidates))
+
+	for _, e := range candidates {
+		scores[e] = e.score()
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scores[candidates[i]] < scores[candidates[j]]
+	})
+
+	return candidates
+}
+
+// isFailoverError reports if a call failed because of the endpoint, e.g. on transport level
+// or with an HTTP 5xx status, so that it should be retried on another endpoint.
+// Rate limited calls are failed over as well, since another provider may still have quota left.
+// Calls rejected by the client side compute unit budget are not, since the budget is shared by all endpoints.
+func isFailoverError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var (
+		rpcErr   *jsonrpc.RPCError
+		batchErr *jsonrpc.BatchError
+		httpErr  *httpx.Error
+	)
+
+	switch {
+	case errors.As(err, &rpcErr), errors.As(err, &batchErr), errors.Is(err, jsonrpc.ErrBudgetExhausted):
+		return false
+	case errors.As(err, &httpErr):
+		return httpErr.Code >= http.StatusInternalServerError || httpErr.Code == http.StatusTooManyRequests
+	default:
+		return true
+	}
+}
+
+// isNullBlockData reports if an endpoint responded with null to a call for a block or its receipts.
+func isNullBlockData(method string, rpcResponse *jsonrpc.RPCResponse) bool {
+	return _blockDataMethods[method] && rpcResponse.Error == nil && rpcResponse.Result == nil
+}
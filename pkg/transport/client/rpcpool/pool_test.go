@@ -0,0 +1,207 @@
+package rpcpool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/httpx"
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+type block struct {
+	Number string `json:"number"`
+}
+
+// fakeNode answers every JSON-RPC request with the same result, error or HTTP status code and counts the calls.
+type fakeNode struct {
+	status int
+	result any
+	err    *jsonrpc.RPCError
+	calls  atomic.Int32
+}
+
+func (n *fakeNode) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	n.calls.Add(1)
+
+	if n.status != 0 {
+		rw.WriteHeader(n.status)
+
+		return
+	}
+
+	var request jsonrpc.RPCRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	response := map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": n.result}
+	if n.err != nil {
+		response = map[string]any{"jsonrpc": "2.0", "id": request.ID, "error": n.err}
+	}
+
+	_ = json.NewEncoder(rw).Encode(response)
+}
+
+func newTestPool(t *testing.T, nodes ...*fakeNode) *Pool {
+	t.Helper()
+
+	urls := make([]string, 0, len(nodes))
+	clients := make(map[string]*jsonrpc.RPCClient, len(nodes))
+
+	for _, node := range nodes {
+		server := httptest.NewServer(node)
+		t.Cleanup(server.Close)
+
+		urls = append(urls, server.URL)
+		clients[server.URL] = jsonrpc.NewClient(httpx.NewClient(), server.URL)
+	}
+
+	return NewPool(urls, clients)
+}
+
+func TestPoolCallForFailover(t *testing.T) {
+	tests := []struct {
+		name       string
+		first      *fakeNode
+		second     *fakeNode
+		wantBlock  *block
+		wantRPCErr bool
+		wantCalls  [2]int32
+	}{
+		{
+			name:      "first endpoint answers",
+			first:     &fakeNode{result: block{Number: "0x1"}},
+			second:    &fakeNode{result: block{Number: "0x2"}},
+			wantBlock: &block{Number: "0x1"},
+			wantCalls: [2]int32{1, 0},
+		},
+		{
+			name:      "server error fails over",
+			first:     &fakeNode{status: http.StatusServiceUnavailable},
+			second:    &fakeNode{result: block{Number: "0x2"}},
+			wantBlock: &block{Number: "0x2"},
+			wantCalls: [2]int32{1, 1},
+		},
+		{
+			name:      "null block fails over",
+			first:     &fakeNode{},
+			second:    &fakeNode{result: block{Number: "0x2"}},
+			wantBlock: &block{Number: "0x2"},
+			wantCalls: [2]int32{1, 1},
+		},
+		{
+			name:      "null block on all endpoints is returned",
+			first:     &fakeNode{},
+			second:    &fakeNode{},
+			wantBlock: nil,
+			wantCalls: [2]int32{1, 1},
+		},
+		{
+			name:       "rpc error is not failed over",
+			first:      &fakeNode{err: &jsonrpc.RPCError{Code: -32602, Message: "invalid params"}},
+			second:     &fakeNode{result: block{Number: "0x2"}},
+			wantRPCErr: true,
+			wantCalls:  [2]int32{1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newTestPool(t, tt.first, tt.second)
+
+			var got *block
+
+			err := pool.CallFor(context.Background(), &got, "eth_getBlockByNumber", "0x2", false)
+
+			var rpcErr *jsonrpc.RPCError
+
+			switch {
+			case tt.wantRPCErr && !errors.As(err, &rpcErr):
+				t.Fatalf("CallFor() error = %v, want rpc error", err)
+			case !tt.wantRPCErr && err != nil:
+				t.Fatalf("CallFor() error = %v", err)
+			case !tt.wantRPCErr && !equalBlocks(got, tt.wantBlock):
+				t.Errorf("CallFor() = %+v, want %+v", got, tt.wantBlock)
+			}
+
+			if calls := [2]int32{tt.first.calls.Load(), tt.second.calls.Load()}; calls != tt.wantCalls {
+				t.Errorf("calls per endpoint = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPoolEjectsFailingEndpoint(t *testing.T) {
+	failing := &fakeNode{status: http.StatusInternalServerError}
+	healthy := &fakeNode{result: "0x10"}
+	pool := newTestPool(t, failing, healthy)
+
+	for i := 0; i < _defaultMaxFailures+2; i++ {
+		var head string
+
+		if err := pool.CallFor(context.Background(), &head, "eth_blockNumber"); err != nil {
+			t.Fatalf("CallFor() error = %v", err)
+		}
+	}
+
+	if calls := failing.calls.Load(); calls != _defaultMaxFailures {
+		t.Errorf("calls to failing endpoint = %d, want %d", calls, _defaultMaxFailures)
+	}
+
+	statuses := pool.Endpoints()
+	if statuses[0].Healthy || !statuses[1].Healthy {
+		t.Errorf("Endpoints() healthy = [%v %v], want [false true]", statuses[0].Healthy, statuses[1].Healthy)
+	}
+}
+
+func TestPoolCheckHealth(t *testing.T) {
+	ahead := &fakeNode{result: "0x64"}
+	lagging := &fakeNode{result: "0x5a"}
+	failing := &fakeNode{status: http.StatusBadGateway}
+	pool := newTestPool(t, ahead, lagging, failing)
+
+	if err := pool.CheckHealth(context.Background()); err == nil {
+		t.Error("CheckHealth() error = nil, want the error of the failing endpoint")
+	}
+
+	statuses := pool.Endpoints()
+
+	if statuses[0].HeadBlock != 100 || statuses[1].HeadBlock != 90 || statuses[2].HeadBlock != -1 {
+		t.Errorf("Endpoints() heads = [%d %d %d], want [100 90 -1]",
+			statuses[0].HeadBlock, statuses[1].HeadBlock, statuses[2].HeadBlock)
+	}
+
+	if statuses[0].Lagging || !statuses[1].Lagging || statuses[1].Healthy {
+		t.Errorf("Endpoints() lagging = [%v %v], healthy = [%v %v], want the second endpoint ejected as lagging",
+			statuses[0].Lagging, statuses[1].Lagging, statuses[0].Healthy, statuses[1].Healthy)
+	}
+
+	// Calls are routed away from the lagging endpoint.
+	var head string
+
+	for i := 0; i < 3; i++ {
+		if err := pool.CallFor(context.Background(), &head, "eth_blockNumber"); err != nil {
+			t.Fatalf("CallFor() error = %v", err)
+		}
+	}
+
+	if calls := lagging.calls.Load(); calls != 1 {
+		t.Errorf("calls to lagging endpoint = %d, want only the health check", calls)
+	}
+}
+
+func equalBlocks(a, b *block) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
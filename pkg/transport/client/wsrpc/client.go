@@ -0,0 +1,386 @@
+package wsrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/transport/client/jsonrpc"
+)
+
+const _jsonrpcVersion = "2.0"
+
+var (
+	// ErrNotConnected is returned by calls made while there is no connection to the endpoint.
+	ErrNotConnected = errors.New("websocket connection is not established")
+	// ErrConnectionClosed is returned by calls whose connection broke before a response was received.
+	ErrConnectionClosed = errors.New("websocket connection closed before a response was received")
+)
+
+// Client represents a JSON-RPC client over a WebSocket connection. Besides plain calls it supports
+// eth_subscribe notifications, which are renewed whenever Run establishes a new connection
+// after the previous one broke.
+type Client struct {
+	endpoint    string
+	redactedURL string
+	config      *clientConfig
+
+	mu                sync.Mutex
+	conn              *websocket.Conn
+	lastID            int
+	pending           map[int]chan *jsonrpc.RPCResponse
+	subscriptions     map[*Subscription]struct{}
+	subscriptionsByID map[string]*Subscription
+
+	writeMu sync.Mutex
+
+	// subscribeMu serializes creating subscriptions on the server, so that a subscription created while
+	// the subscriptions are renewed after a reconnect is not created twice.
+	subscribeMu sync.Mutex
+}
+
+// NewClient returns a new Client instance for a given ws:// or wss:// endpoint.
+// The connection is not established until Run is called.
+func NewClient(endpoint string, opts ...ClientOption) *Client {
+	config := newClientDefaultConfig()
+
+	config.applyOptions(opts...)
+
+	redactedURL := endpoint
+	if endpointURL, err := url.Parse(endpoint); err == nil {
+		redactedURL = endpointURL.Redacted()
+	}
+
+	return &Client{
+		endpoint:          endpoint,
+		redactedURL:       redactedURL,
+		config:            config,
+		pending:           make(map[int]chan *jsonrpc.RPCResponse),
+		subscriptions:     make(map[*Subscription]struct{}),
+		subscriptionsByID: make(map[string]*Subscription),
+	}
+}
+
+// Connected reports if the connection to the endpoint is currently established.
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.conn != nil
+}
+
+// Call calls a JSON-RPC method with optional params over the current connection.
+func (c *Client) Call(ctx context.Context, method string, params ...any) (*jsonrpc.RPCResponse, error) {
+	c.mu.Lock()
+
+	conn := c.conn
+	if conn == nil {
+		c.mu.Unlock()
+
+		return nil, ErrNotConnected
+	}
+
+	c.lastID++
+
+	id := c.lastID
+	respCh := make(chan *jsonrpc.RPCResponse, 1)
+	c.pending[id] = respCh
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	request := &jsonrpc.RPCRequest{
+		ID:      id,
+		Method:  method,
+		Params:  jsonrpc.Params(params...),
+		JSONRPC: _jsonrpcVersion,
+	}
+
+	if err := c.write(conn, request); err != nil {
+		return nil, fmt.Errorf("rpc call %v() on %v: %w", method, c.redactedURL, err)
+	}
+
+	select {
+	case rpcResponse, ok := <-respCh:
+		if !ok {
+			return nil, fmt.Errorf("rpc call %v() on %v: %w", method, c.redactedURL, ErrConnectionClosed)
+		}
+
+		return rpcResponse, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// CallFor calls a JSON-RPC method and deserializes the response in a specified response object.
+func (c *Client) CallFor(ctx context.Context, out any, method string, params ...any) error {
+	rpcResponse, err := c.Call(ctx, method, params...)
+	if err != nil {
+		return err
+	}
+
+	if rpcResponse.Error != nil {
+		return rpcResponse.Error
+	}
+
+	return rpcResponse.GetObject(out)
+}
+
+// Subscribe creates an eth_subscribe subscription with given params, e.g. "newHeads". The subscription
+// is kept across reconnections, so it can be created even while the connection is down,
+// in which case it becomes active as soon as the connection is established.
+func (c *Client) Subscribe(ctx context.Context, params ...any) (*Subscription, error) {
+	sub := &Subscription{
+		client:        c,
+		params:        params,
+		notifications: make(chan json.RawMessage, c.config.notificationsSize),
+	}
+
+	c.subscribeMu.Lock()
+
+	c.mu.Lock()
+	c.subscriptions[sub] = struct{}{}
+	c.mu.Unlock()
+
+	err := c.subscribe(ctx, sub)
+
+	c.subscribeMu.Unlock()
+
+	if err != nil && !errors.Is(err, ErrNotConnected) && !errors.Is(err, ErrConnectionClosed) {
+		_ = sub.Unsubscribe(ctx)
+
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// SubscribeNewHeads subscribes for the headers of new blocks added to the chain.
+func (c *Client) SubscribeNewHeads(ctx context.Context) (<-chan json.RawMessage, error) {
+	sub, err := c.Subscribe(ctx, "newHeads")
+	if err != nil {
+		return nil, err
+	}
+
+	return sub.Notifications(), nil
+}
+
+// Run connects to the endpoint, renews all subscriptions and keeps the connection open until the context
+// is canceled, in which case it returns nil. When the connection cannot be established or breaks,
+// the error is returned, so that the client is restarted with the backoff of its supervisor.
+func (c *Client) Run(ctx context.Context) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("could not connect to %v: %w", c.redactedURL, err)
+	}
+
+	pongTimeout := 2 * c.config.pingInterval
+
+	if err = conn.SetReadDeadline(time.Now().Add(pongTimeout)); err != nil {
+		return errors.Join(err, conn.Close())
+	}
+
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+
+	readErrCh := make(chan error, 1)
+
+	go func() {
+		readErrCh <- c.readMessages(conn)
+	}()
+
+	if err = c.resubscribe(ctx); err != nil {
+		c.disconnect(conn)
+
+		return errors.Join(err, <-readErrCh)
+	}
+
+	ticker := time.NewTicker(c.config.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err = <-readErrCh:
+			return fmt.Errorf("connection to %v broke: %w", c.redactedURL, err)
+		case <-ctx.Done():
+			c.disconnect(conn)
+			<-readErrCh
+
+			return nil
+		case <-ticker.C:
+			c.writeMu.Lock()
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.config.writeTimeout))
+			c.writeMu.Unlock()
+
+			if err != nil {
+				c.disconnect(conn)
+
+				return fmt.Errorf("connection to %v broke: %w", c.redactedURL, errors.Join(err, <-readErrCh))
+			}
+		}
+	}
+}
+
+// readMessages dispatches the responses and notifications received over a connection until it breaks.
+func (c *Client) readMessages(conn *websocket.Conn) error {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			c.disconnect(conn)
+
+			return err
+		}
+
+		c.handleMessage(message)
+	}
+}
+
+// handleMessage routes a notification to its subscription and a response to its pending call.
+// Messages which are neither are ignored.
+func (c *Client) handleMessage(message []byte) {
+	var notification struct {
+		Method string `json:"method"`
+		Params struct {
+			Subscription string          `json:"subscription"`
+			Result       json.RawMessage `json:"result"`
+		} `json:"params"`
+	}
+
+	if err := json.Unmarshal(message, &notification); err != nil {
+		return
+	}
+
+	if notification.Method == "eth_subscription" {
+		c.mu.Lock()
+		if sub, found := c.subscriptionsByID[notification.Params.Subscription]; found {
+			// Notifications are dropped if the channel is not drained fast enough.
+			select {
+			case sub.notifications <- notification.Params.Result:
+			default:
+			}
+		}
+		c.mu.Unlock()
+
+		return
+	}
+
+	var rpcResponse *jsonrpc.RPCResponse
+
+	decoder := json.NewDecoder(bytes.NewReader(message))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&rpcResponse); err != nil || rpcResponse == nil {
+		return
+	}
+
+	c.mu.Lock()
+	if respCh, found := c.pending[rpcResponse.ID]; found {
+		respCh <- rpcResponse
+
+		delete(c.pending, rpcResponse.ID)
+	}
+	c.mu.Unlock()
+}
+
+// write sends a single message over a connection. Only one writer is allowed at a time.
+func (c *Client) write(conn *websocket.Conn, message any) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := conn.SetWriteDeadline(time.Now().Add(c.config.writeTimeout)); err != nil {
+		return err
+	}
+
+	return conn.WriteJSON(message)
+}
+
+// disconnect closes a connection, aborts all calls waiting for a response over it
+// and forgets the server side IDs of all subscriptions. It is safe to call it more than once.
+func (c *Client) disconnect(conn *websocket.Conn) {
+	c.mu.Lock()
+	if c.conn == conn {
+		c.conn = nil
+
+		for id, respCh := range c.pending {
+			close(respCh)
+			delete(c.pending, id)
+		}
+
+		for id, sub := range c.subscriptionsByID {
+			sub.id = ""
+			delete(c.subscriptionsByID, id)
+		}
+	}
+	c.mu.Unlock()
+
+	_ = conn.Close()
+}
+
+// resubscribe renews all subscriptions after a connection has been established.
+func (c *Client) resubscribe(ctx context.Context) error {
+	c.subscribeMu.Lock()
+	defer c.subscribeMu.Unlock()
+
+	c.mu.Lock()
+	subs := make([]*Subscription, 0, len(c.subscriptions))
+
+	for sub := range c.subscriptions {
+		subs = append(subs, sub)
+	}
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		if err := c.subscribe(ctx, sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// subscribe creates a subscription on the server and maps the returned server side ID to it, unless
+// the subscription is active on the current connection already. The caller must hold subscribeMu.
+func (c *Client) subscribe(ctx context.Context, sub *Subscription) error {
+	c.mu.Lock()
+	conn := c.conn
+	subscribed := sub.id != ""
+	c.mu.Unlock()
+
+	if subscribed {
+		return nil
+	}
+
+	var id string
+
+	if err := c.CallFor(ctx, &id, "eth_subscribe", sub.params...); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The connection may break right after the server replied. The ID is not mapped then,
+	// since it is stale and the subscription is renewed on the next connection.
+	if _, active := c.subscriptions[sub]; active && conn != nil && c.conn == conn {
+		sub.id = id
+		c.subscriptionsByID[id] = sub
+	}
+
+	return nil
+}
@@ -0,0 +1,315 @@
+package wsrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/supervisor"
+)
+
+// notifyingNode accepts WebSocket connections, answers eth_subscribe with a new subscription ID and pushes
+// a newHeads notification holding the number of the connection to all subscriptions when test_notify is called.
+// Other calls are answered with a fixed block number. The first connection is dropped after its first
+// subscription if dropFirst is set.
+type notifyingNode struct {
+	dropFirst bool
+
+	mu            sync.Mutex
+	connections   int
+	subscriptions int
+}
+
+func (n *notifyingNode) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(rw, r, nil)
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	n.mu.Lock()
+	n.connections++
+	connNum := n.connections
+	n.mu.Unlock()
+
+	var subIDs []string
+
+	for {
+		var request struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+		}
+
+		if err = conn.ReadJSON(&request); err != nil {
+			return
+		}
+
+		var result any = "0x10"
+
+		switch request.Method {
+		case "eth_subscribe":
+			n.mu.Lock()
+			n.subscriptions++
+			result = fmt.Sprintf("0x%x", n.subscriptions)
+			n.mu.Unlock()
+
+			subIDs = append(subIDs, result.(string))
+		case "test_notify":
+			for _, id := range subIDs {
+				err = conn.WriteJSON(map[string]any{
+					"jsonrpc": "2.0",
+					"method":  "eth_subscription",
+					"params":  map[string]any{"subscription": id, "result": map[string]any{"number": connNum}},
+				})
+				if err != nil {
+					return
+				}
+			}
+		}
+
+		if err = conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": result}); err != nil {
+			return
+		}
+
+		if n.dropFirst && connNum == 1 && request.Method == "eth_subscribe" {
+			return
+		}
+	}
+}
+
+func (n *notifyingNode) connectionCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.connections
+}
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name           string
+		dropFirst      bool
+		wantConnection int
+	}{
+		{name: "calls and notifications", wantConnection: 1},
+		{name: "subscriptions are renewed after a reconnect", dropFirst: true, wantConnection: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &notifyingNode{dropFirst: tt.dropFirst}
+			server := httptest.NewServer(node)
+			t.Cleanup(server.Close)
+
+			client := NewClient("ws" + strings.TrimPrefix(server.URL, "http"))
+
+			heads, err := client.SubscribeNewHeads(context.Background())
+			if err != nil {
+				t.Fatalf("SubscribeNewHeads() error = %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The client is restarted by a supervisor whenever its connection breaks.
+			workers := supervisor.New(supervisor.WithRestartBackoff(time.Millisecond, 10*time.Millisecond))
+			workers.Add("wsrpc", client.Run)
+
+			if err = workers.Start(ctx, make(chan error, 10)); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			waitFor(t, func() bool {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+
+				return node.connectionCount() == tt.wantConnection && len(client.subscriptionsByID) == 1
+			})
+
+			var blockNum string
+
+			if err = client.CallFor(context.Background(), &blockNum, "eth_blockNumber"); err != nil {
+				t.Fatalf("CallFor() error = %v", err)
+			}
+
+			if blockNum != "0x10" {
+				t.Errorf("CallFor() = %s, want 0x10", blockNum)
+			}
+
+			if _, err = client.Call(context.Background(), "test_notify"); err != nil {
+				t.Fatalf("Call() error = %v", err)
+			}
+
+			select {
+			case head := <-heads:
+				var header struct {
+					Number int `json:"number"`
+				}
+
+				if err = json.Unmarshal(head, &header); err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+
+				if header.Number != tt.wantConnection {
+					t.Errorf("notification sent over connection %d, want %d", header.Number, tt.wantConnection)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("no notification received")
+			}
+		})
+	}
+}
+
+// fakeNode accepts WebSocket connections and answers every eth_subscribe call with a new subscription ID.
+type fakeNode struct {
+	mu            sync.Mutex
+	subscriptions int
+}
+
+func (n *fakeNode) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(rw, r, nil)
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	for {
+		var request struct {
+			ID int `json:"id"`
+		}
+
+		if err = conn.ReadJSON(&request); err != nil {
+			return
+		}
+
+		n.mu.Lock()
+		n.subscriptions++
+		id := fmt.Sprintf("0x%x", n.subscriptions)
+		n.mu.Unlock()
+
+		if err = conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": id}); err != nil {
+			return
+		}
+	}
+}
+
+func (n *fakeNode) subscriptionCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.subscriptions
+}
+
+func TestClientSubscribesOnce(t *testing.T) {
+	tests := []struct {
+		name           string
+		beforeConnect  int
+		afterConnect   int
+		renewConnected bool
+	}{
+		{
+			name:          "subscriptions created before connecting are created on connect",
+			beforeConnect: 3,
+		},
+		{
+			name:         "subscriptions created while connected are created right away",
+			afterConnect: 2,
+		},
+		{
+			name:           "renewing skips subscriptions active on the connection",
+			beforeConnect:  1,
+			afterConnect:   2,
+			renewConnected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &fakeNode{}
+			server := httptest.NewServer(node)
+			t.Cleanup(server.Close)
+
+			client := NewClient("ws" + strings.TrimPrefix(server.URL, "http"))
+
+			for i := 0; i < tt.beforeConnect; i++ {
+				if _, err := client.SubscribeNewHeads(context.Background()); err != nil {
+					t.Fatalf("SubscribeNewHeads() error = %v", err)
+				}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			runErrCh := make(chan error, 1)
+
+			go func() {
+				runErrCh <- client.Run(ctx)
+			}()
+
+			waitFor(t, func() bool { return client.Connected() && node.subscriptionCount() == tt.beforeConnect })
+
+			for i := 0; i < tt.afterConnect; i++ {
+				if _, err := client.SubscribeNewHeads(context.Background()); err != nil {
+					t.Fatalf("SubscribeNewHeads() error = %v", err)
+				}
+			}
+
+			if tt.renewConnected {
+				if err := client.resubscribe(context.Background()); err != nil {
+					t.Fatalf("resubscribe() error = %v", err)
+				}
+			}
+
+			want := tt.beforeConnect + tt.afterConnect
+
+			if got := node.subscriptionCount(); got != want {
+				t.Errorf("subscriptions created on the server = %d, want %d", got, want)
+			}
+
+			client.mu.Lock()
+			if got := len(client.subscriptionsByID); got != want {
+				t.Errorf("active subscriptions = %d, want %d", got, want)
+			}
+			client.mu.Unlock()
+
+			cancel()
+
+			if err := <-runErrCh; err != nil {
+				t.Errorf("Run() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestClientRunFailsWithoutEndpoint(t *testing.T) {
+	client := NewClient("ws://127.0.0.1:1")
+
+	if _, err := client.Call(context.Background(), "eth_blockNumber"); err != ErrNotConnected {
+		t.Errorf("Call() error = %v, want %v", err, ErrNotConnected)
+	}
+
+	if err := client.Run(context.Background()); err == nil {
+		t.Error("Run() error = nil, want a connection error")
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
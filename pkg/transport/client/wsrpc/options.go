@@ -0,0 +1,54 @@
+package wsrpc
+
+import "time"
+
+const (
+	_defaultPingInterval      = 30 * time.Second
+	_defaultWriteTimeout      = 10 * time.Second
+	_defaultNotificationsSize = 16
+)
+
+type clientConfig struct {
+	pingInterval      time.Duration
+	writeTimeout      time.Duration
+	notificationsSize int
+}
+
+func newClientDefaultConfig() *clientConfig {
+	return &clientConfig{
+		pingInterval:      _defaultPingInterval,
+		writeTimeout:      _defaultWriteTimeout,
+		notificationsSize: _defaultNotificationsSize,
+	}
+}
+
+func (o *clientConfig) applyOptions(opts ...ClientOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// ClientOption specifies a Client setting.
+type ClientOption func(config *clientConfig)
+
+// WithPingInterval specifies how often the connection is checked with a ping. A connection which
+// does not answer within two intervals is considered broken and reconnected.
+func WithPingInterval(pingInterval time.Duration) ClientOption {
+	return func(o *clientConfig) {
+		o.pingInterval = pingInterval
+	}
+}
+
+// WithWriteTimeout specifies the maximum duration of writing a single message to the connection.
+func WithWriteTimeout(writeTimeout time.Duration) ClientOption {
+	return func(o *clientConfig) {
+		o.writeTimeout = writeTimeout
+	}
+}
+
+// WithNotificationsBufferSize specifies how many notifications are buffered per subscription.
+func WithNotificationsBufferSize(size int) ClientOption {
+	return func(o *clientConfig) {
+		o.notificationsSize = size
+	}
+}
@@ -0,0 +1,48 @@
+package wsrpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Subscription represents an eth_subscribe subscription, which is renewed on every reconnection of its Client.
+type Subscription struct {
+	client        *Client
+	params        []any
+	id            string
+	notifications chan json.RawMessage
+}
+
+// Notifications returns a channel of the results pushed for the subscription.
+// Notifications are dropped if the channel is not drained fast enough.
+func (s *Subscription) Notifications() <-chan json.RawMessage {
+	return s.notifications
+}
+
+// Unsubscribe cancels the subscription and closes its notifications channel.
+func (s *Subscription) Unsubscribe(ctx context.Context) error {
+	c := s.client
+
+	c.mu.Lock()
+	if _, active := c.subscriptions[s]; !active {
+		c.mu.Unlock()
+
+		return nil
+	}
+
+	id := s.id
+	connected := c.conn != nil
+
+	delete(c.subscriptions, s)
+	delete(c.subscriptionsByID, id)
+	close(s.notifications)
+	c.mu.Unlock()
+
+	if !connected || id == "" {
+		return nil
+	}
+
+	var unsubscribed bool
+
+	return c.CallFor(ctx, &unsubscribed, "eth_unsubscribe", id)
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/logging"
+)
+
+var _tracer = otel.Tracer("github.com/powerslider/ethereum-block-scanner/pkg/transport/server")
+
+// requestLogging assigns an ID to every request, returns it in the X-Request-ID response header and logs
+// the request once it has been served. The ID sent by the client is adopted if it is valid. It is carried
+// by the context of the request, so that everything logged on its behalf, e.g. calls to the Ethereum node,
+// is tagged with it.
+func requestLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(logging.RequestIDHeader)
+		if !logging.ValidRequestID(requestID) {
+			requestID = logging.NewRequestID()
+		}
+
+		rw.Header().Set(logging.RequestIDHeader, requestID)
+
+		ctx := logging.WithRequestID(r.Context(), requestID)
+		recorder := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+		start := time.Now()
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		slog.InfoContext(ctx, "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"duration", time.Since(start),
+			"remote_addr", r.RemoteAddr,
+		)
+	})
+}
+
+// traceRequests starts a server span for every request to a route of the router, continuing the trace
+// of the caller if the request carries a W3C trace context. The span is named after the path template
+// of the route, so that the spans of a route can be aggregated.
+func traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r, r.URL.Path)
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := _tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+				attribute.String("request.id", logging.RequestID(ctx)),
+			),
+		)
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.status))
+
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// instrumentRequests reports the duration and status code of every request to a route of the router
+// to an Instrumentation. Requests are reported by the path template of their route, so that the number
+// of distinct routes stays bounded.
+func instrumentRequests(instrumentation Instrumentation) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r, "unknown")
+			recorder := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+			start := time.Now()
+
+			next.ServeHTTP(recorder, r)
+
+			instrumentation.ObserveRequest(route, r.Method, recorder.status, time.Since(start))
+		})
+	}
+}
+
+// routeTemplate returns the path template of the route matched by a request or a fallback if there is none.
+func routeTemplate(r *http.Request, fallback string) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+
+	return fallback
+}
+
+// statusRecorder captures the status code written by a handler. It supports flushing,
+// so that streaming handlers keep working.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/powerslider/ethereum-block-scanner/pkg/logging"
+)
+
+type observedRequest struct {
+	route  string
+	method string
+	status int
+}
+
+type fakeInstrumentation struct {
+	requests []observedRequest
+}
+
+func (f *fakeInstrumentation) Handler() http.Handler {
+	return http.NotFoundHandler()
+}
+
+func (f *fakeInstrumentation) ObserveRequest(route, method string, status int, _ time.Duration) {
+	f.requests = append(f.requests, observedRequest{route: route, method: method, status: status})
+}
+
+func TestInstrumentRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		handler http.HandlerFunc
+		want    observedRequest
+	}{
+		{
+			name:    "implicit status",
+			path:    "/api/v1/subscription/0xabc",
+			handler: func(rw http.ResponseWriter, r *http.Request) { _, _ = rw.Write([]byte("{}")) },
+			want:    observedRequest{route: "/api/v1/subscription/{address}", method: "GET", status: http.StatusOK},
+		},
+		{
+			name:    "explicit status",
+			path:    "/api/v1/subscription/0xdef",
+			handler: func(rw http.ResponseWriter, r *http.Request) { rw.WriteHeader(http.StatusNotFound) },
+			want:    observedRequest{route: "/api/v1/subscription/{address}", method: "GET", status: http.StatusNotFound},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instrumentation := &fakeInstrumentation{}
+			router := mux.NewRouter()
+
+			router.HandleFunc("/api/v1/subscription/{address}", tt.handler).Methods("GET")
+			router.Use(instrumentRequests(instrumentation))
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if len(instrumentation.requests) != 1 || instrumentation.requests[0] != tt.want {
+				t.Errorf("observed requests = %+v, want [%+v]", instrumentation.requests, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestLogging(t *testing.T) {
+	tests := []struct {
+		name          string
+		requestID     string
+		wantRequestID string
+	}{
+		{name: "request ID of the client", requestID: "abc-123", wantRequestID: "abc-123"},
+		{name: "missing request ID", requestID: ""},
+		{name: "forged request ID", requestID: "abc\nlevel=ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+
+			logger, err := logging.New(&logs, "info", logging.FormatJSON)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			defaultLogger := slog.Default()
+			slog.SetDefault(logger)
+
+			t.Cleanup(func() {
+				slog.SetDefault(defaultLogger)
+			})
+
+			var handlerRequestID string
+
+			handler := requestLogging(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				handlerRequestID = logging.RequestID(r.Context())
+
+				rw.WriteHeader(http.StatusNotFound)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/subscription", nil)
+			req.Header.Set(logging.RequestIDHeader, tt.requestID)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			requestID := rec.Header().Get(logging.RequestIDHeader)
+
+			if tt.wantRequestID != "" && requestID != tt.wantRequestID {
+				t.Errorf("%s = %q, want %q", logging.RequestIDHeader, requestID, tt.wantRequestID)
+			}
+
+			if !logging.ValidRequestID(requestID) || requestID != handlerRequestID {
+				t.Errorf("%s = %q, request ID of the handler %q", logging.RequestIDHeader, requestID, handlerRequestID)
+			}
+
+			var record struct {
+				RequestID string `json:"request_id"`
+				Status    int    `json:"status"`
+			}
+
+			if err = json.Unmarshal(logs.Bytes(), &record); err != nil {
+				t.Fatalf("Unmarshal() of the log record error = %v", err)
+			}
+
+			if record.RequestID != requestID || record.Status != http.StatusNotFound {
+				t.Errorf("logged request %+v, want request ID %q and status %d", record, requestID, http.StatusNotFound)
+			}
+		})
+	}
+}
+
+func TestTraceRequests(t *testing.T) {
+	const (
+		traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+		traceparent = "00-" + traceID + "-00f067aa0ba902b7-01"
+	)
+
+	// The tracer of the package is bound to the first global provider, so it is installed for all cases.
+	recorder := tracetest.NewSpanRecorder()
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	tests := []struct {
+		name        string
+		traceparent string
+		status      int
+		wantStatus  codes.Code
+	}{
+		{name: "trace of the caller", traceparent: traceparent, status: http.StatusOK, wantStatus: codes.Unset},
+		{name: "new trace", status: http.StatusOK, wantStatus: codes.Unset},
+		{name: "failed request", traceparent: traceparent, status: http.StatusBadGateway, wantStatus: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+
+			router.HandleFunc("/api/v1/subscription/{address}", func(rw http.ResponseWriter, r *http.Request) {
+				rw.WriteHeader(tt.status)
+			}).Methods(http.MethodGet)
+			router.Use(traceRequests)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/subscription/0xabc", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			span := spans[len(spans)-1]
+
+			if want := "GET /api/v1/subscription/{address}"; span.Name() != want {
+				t.Errorf("span name = %q, want %q", span.Name(), want)
+			}
+
+			if continued := span.SpanContext().TraceID().String() == traceID; continued != (tt.traceparent != "") {
+				t.Errorf("span of trace %s, want the trace of the caller %v", span.SpanContext().TraceID(), tt.traceparent != "")
+			}
+
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("span status = %v, want %v", span.Status().Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// Instrumentation exposes the metrics of the server and records the requests to its routes.
+type Instrumentation interface {
+	// Handler returns the HTTP handler exposing the metrics.
+	Handler() http.Handler
+
+	// ObserveRequest records the duration and status code of a request to a route of the server.
+	ObserveRequest(route, method string, status int, duration time.Duration)
+}
+
+type serverConfig struct {
+	instrumentation Instrumentation
+}
+
+func newServerDefaultConfig() *serverConfig {
+	return &serverConfig{}
+}
+
+func (o *serverConfig) applyOptions(opts ...ServerOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// ServerOption specifies a Server setting.
+type ServerOption func(config *serverConfig)
+
+// WithInstrumentation specifies an Instrumentation whose metrics are exposed on /metrics
+// and which records the requests to all routes.
+func WithInstrumentation(instrumentation Instrumentation) ServerOption {
+	return func(o *serverConfig) {
+		o.instrumentation = instrumentation
+	}
+}
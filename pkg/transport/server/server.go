@@ -3,7 +3,8 @@ package server
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"time"
 
@@ -21,12 +22,34 @@ type Server struct {
 func NewServer(
 	config *configs.Config,
 	muxer *mux.Router,
+	opts ...ServerOption,
 ) *Server {
+	serverConfig := newServerDefaultConfig()
+
+	serverConfig.applyOptions(opts...)
+
+	// Requests to the routes are traced, continuing the trace context sent by the caller.
+	muxer.Use(traceRequests)
+
+	if serverConfig.instrumentation != nil {
+		muxer.Handle("/metrics", serverConfig.instrumentation.Handler()).Methods("GET")
+		muxer.Use(instrumentRequests(serverConfig.instrumentation))
+	}
+
+	// Requests share a base context canceled on shutdown, so that long-lived requests like event streams end
+	// instead of holding up the shutdown.
+	baseCtx, cancelBaseCtx := context.WithCancel(context.Background())
+
 	server := &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", config.Host, config.Port),
-		Handler: muxer,
+		Handler: requestLogging(muxer),
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
 	}
 
+	server.RegisterOnShutdown(cancelBaseCtx)
+
 	return &Server{
 		server: server,
 	}
@@ -34,7 +57,7 @@ func NewServer(
 
 // Start starts the HTTP server.
 func (s *Server) Start(ctx context.Context, errChan chan error) {
-	log.Printf("[Start] HTTP server is starting on %s:\n", s.server.Addr)
+	slog.Info("HTTP server is starting", "addr", s.server.Addr)
 
 	err := s.server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
@@ -44,7 +67,7 @@ func (s *Server) Start(ctx context.Context, errChan chan error) {
 
 // Stop stops the HTTP server.
 func (s *Server) Stop(ctx context.Context) error {
-	log.Println("[Shutdown] HTTP server is shutting down...")
+	slog.Info("HTTP server is shutting down")
 
 	shutdownCtx, cancel := context.WithTimeout(ctx, time.Second*5)
 	defer cancel()